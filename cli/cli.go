@@ -0,0 +1,160 @@
+// Package cli implements the kogase command-line interface
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/atqamz/kogase-backend/config"
+	"github.com/atqamz/kogase-backend/server"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const usage = `Usage: kogase [command]
+
+Commands:
+  serve                    Run the HTTP server (default)
+  migrate up|down|status   Manage database migrations
+  user create|reset-password|list
+                           Manage dashboard users
+  project create|list|rotate-key
+                           Manage projects and their API keys
+  events export            Export the events of a project as JSON lines
+  retention run            Purge data past its retention once
+
+Run 'kogase <command> -h' for the flags of a command.`
+
+// Run runs the command named by args, serving the API when there is none
+func Run(args []string) error {
+	err := run(args)
+	if errors.Is(err, flag.ErrHelp) {
+		// The flags were already printed
+		return nil
+	}
+	return err
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		return runServe(nil)
+	}
+
+	switch args[0] {
+	case "serve":
+		return runServe(args[1:])
+	case "migrate":
+		return runMigrate(args[1:])
+	case "user":
+		return runUser(args[1:])
+	case "project":
+		return runProject(args[1:])
+	case "events":
+		return runEvents(args[1:])
+	case "retention":
+		return runRetention(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Println(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], usage)
+	}
+}
+
+// openDB connects to the configured database. SQL statements are only logged when they
+// fail or are slow, and never to stdout, which commands keep for their output.
+func openDB() (*gorm.DB, *config.Config, error) {
+	cfg := config.NewConfigFromEnv()
+	db, err := server.OpenDB(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db.Logger = logger.New(
+		log.New(os.Stderr, "\r\n", log.LstdFlags),
+		logger.Config{
+			SlowThreshold:             time.Second,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+		},
+	)
+
+	return db, cfg, nil
+}
+
+// newFlagSet creates the flag set of a subcommand, reporting errors instead of exiting
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("kogase "+name, flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	return flags
+}
+
+// parseFlags parses the flags of a subcommand and rejects positional arguments
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+	return nil
+}
+
+// subcommand returns the subcommand of a command group, or an error listing the valid ones
+func subcommand(group string, args []string, names ...string) (string, error) {
+	if len(args) > 0 {
+		for _, name := range names {
+			if args[0] == name {
+				return name, nil
+			}
+		}
+	}
+
+	list := strings.Join(names, "|")
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: kogase %s %s", group, list)
+	}
+	return "", fmt.Errorf("unknown %s command %q, expected %s", group, args[0], list)
+}
+
+// signalContext returns a context cancelled on SIGINT or SIGTERM, so long commands stop cleanly
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+// parseTime parses an optional RFC3339 time flag
+func parseTime(name string, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -%s, expected an RFC3339 time such as 2024-01-31T00:00:00Z", name)
+	}
+	return t, nil
+}
+
+// closeOutput closes an output file, keeping the first error
+func closeOutput(out io.Closer, err *error) {
+	if closeErr := out.Close(); closeErr != nil && *err == nil {
+		*err = closeErr
+	}
+}
+
+// requireFlag reports a missing required flag
+func requireFlag(name string, value string) error {
+	if value == "" {
+		return fmt.Errorf("-%s is required", name)
+	}
+	return nil
+}
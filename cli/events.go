@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/google/uuid"
+)
+
+// runEvents runs the events commands
+func runEvents(args []string) error {
+	if _, err := subcommand("events", args, "export"); err != nil {
+		return err
+	}
+
+	return runEventsExport(args[1:])
+}
+
+// runEventsExport writes the events of a project as JSON lines, in event time order
+func runEventsExport(args []string) (err error) {
+	flags := newFlagSet("events export")
+	id := flags.String("project", "", "Project ID (required)")
+	fromFlag := flags.String("from", "", "Only events at or after this time (RFC3339)")
+	toFlag := flags.String("to", "", "Only events before this time (RFC3339)")
+	outPath := flags.String("out", "", "File to write, stdout when empty")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := requireFlag("project", *id); err != nil {
+		return err
+	}
+	projectID, err := uuid.Parse(*id)
+	if err != nil {
+		return fmt.Errorf("invalid project ID %q", *id)
+	}
+	from, err := parseTime("from", *fromFlag)
+	if err != nil {
+		return err
+	}
+	to, err := parseTime("to", *toFlag)
+	if err != nil {
+		return err
+	}
+
+	db, _, err := openDB()
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		file, createErr := os.Create(*outPath)
+		if createErr != nil {
+			return createErr
+		}
+		defer closeOutput(file, &err)
+		out = file
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	query := db.WithContext(ctx).Model(&models.Event{}).Where("project_id = ?", projectID)
+	if !from.IsZero() {
+		query = query.Where("timestamp >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("timestamp < ?", to)
+	}
+
+	// Stream the rows, a project can have more events than fit in memory
+	rows, err := query.Order("timestamp, id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	writer := bufio.NewWriter(out)
+	encoder := json.NewEncoder(writer)
+	var count int64
+	for rows.Next() {
+		var event models.Event
+		if err := db.ScanRows(rows, &event); err != nil {
+			return err
+		}
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	log.Printf("Exported %d events", count)
+	return nil
+}
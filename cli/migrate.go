@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/atqamz/kogase-backend/migrations"
+	"github.com/atqamz/kogase-backend/models"
+)
+
+// runMigrate runs the migrate commands: up, down [n] and status
+func runMigrate(args []string) error {
+	command, err := subcommand("migrate", args, "up", "down", "status")
+	if err != nil {
+		return err
+	}
+
+	db, _, err := openDB()
+	if err != nil {
+		return err
+	}
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	switch command {
+	case "up":
+		count, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migrations\n", count)
+
+		// Servers started without migrations leave the partitions to this command
+		if err := models.EnsureEventPartitions(db.WithContext(ctx), time.Now()); err != nil {
+			return fmt.Errorf("failed to create event partitions: %w", err)
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of migrations %q", args[1])
+			}
+		}
+
+		count, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %d migrations\n", count)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			name := status.Name
+			if !status.Known {
+				name += " (unknown to this binary)"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, name, appliedAt)
+		}
+		return w.Flush()
+	}
+
+	return nil
+}
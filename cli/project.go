@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// runProject runs the project commands
+func runProject(args []string) error {
+	command, err := subcommand("project", args, "create", "list", "rotate-key")
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "create":
+		return runProjectCreate(args[1:])
+	case "rotate-key":
+		return runProjectRotateKey(args[1:])
+	default:
+		return runProjectList(args[1:])
+	}
+}
+
+func runProjectCreate(args []string) error {
+	flags := newFlagSet("project create")
+	name := flags.String("name", "", "Project name (required)")
+	ownerEmail := flags.String("owner", "", "Email address of the owning user (required)")
+	organizationFlag := flags.String("organization", "", "Organization ID, the owner's only organization by default")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := requireFlag("name", *name); err != nil {
+		return err
+	}
+	if err := requireFlag("owner", *ownerEmail); err != nil {
+		return err
+	}
+
+	db, _, err := openDB()
+	if err != nil {
+		return err
+	}
+
+	var owner models.User
+	if err := db.Where("email = ?", *ownerEmail).First(&owner).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("no user with email %s", *ownerEmail)
+		}
+		return err
+	}
+
+	var organizationID uuid.UUID
+	if *organizationFlag != "" {
+		organizationID, err = uuid.Parse(*organizationFlag)
+		if err != nil {
+			return fmt.Errorf("invalid organization ID %q", *organizationFlag)
+		}
+
+		var members int64
+		if err := db.Model(&models.OrganizationMember{}).
+			Where("organization_id = ? AND user_id = ?", organizationID, owner.ID).
+			Count(&members).Error; err != nil {
+			return err
+		}
+		if members == 0 {
+			return fmt.Errorf("%s is not a member of organization %s", owner.Email, organizationID)
+		}
+	}
+
+	// Same records as POST /projects: the project, its owner membership and a default API key
+	project := models.Project{
+		Name:    *name,
+		OwnerID: owner.ID,
+	}
+	apiKey := models.ApiKey{
+		Name:        "Default",
+		CreatedByID: &owner.ID,
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if organizationID == uuid.Nil {
+			organization, err := models.DefaultOrganization(tx, owner)
+			if errors.Is(err, models.ErrOrganizationRequired) {
+				return fmt.Errorf("%s belongs to several organizations, choose one with -organization", owner.Email)
+			}
+			if err != nil {
+				return err
+			}
+			organizationID = organization.ID
+		}
+
+		project.OrganizationID = organizationID
+		if err := tx.Create(&project).Error; err != nil {
+			return err
+		}
+
+		member := models.ProjectMember{
+			ProjectID: project.ID,
+			UserID:    owner.ID,
+			Role:      models.ProjectRoleOwner,
+		}
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+
+		apiKey.ProjectID = project.ID
+		return tx.Create(&apiKey).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created project %s (%s) owned by %s in organization %s\n", project.Name, project.ID, owner.Email, project.OrganizationID)
+	fmt.Printf("API key: %s\n", apiKey.Secret)
+	return nil
+}
+
+func runProjectList(args []string) error {
+	flags := newFlagSet("project list")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	db, _, err := openDB()
+	if err != nil {
+		return err
+	}
+
+	var projects []models.Project
+	if err := db.Preload("Owner").Preload("Organization").Order("created_at").Find(&projects).Error; err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tOWNER\tORGANIZATION\tCREATED AT")
+	for _, project := range projects {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", project.ID, project.Name, project.Owner.Email, project.Organization.Name, project.CreatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+func runProjectRotateKey(args []string) error {
+	flags := newFlagSet("project rotate-key")
+	id := flags.String("project", "", "Project ID (required)")
+	gracePeriod := flags.Duration("grace", 168*time.Hour, "How long the current keys keep working, 0 retires them now")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := requireFlag("project", *id); err != nil {
+		return err
+	}
+	projectID, err := uuid.Parse(*id)
+	if err != nil {
+		return fmt.Errorf("invalid project ID %q", *id)
+	}
+	if *gracePeriod < 0 {
+		return errors.New("-grace must not be negative")
+	}
+
+	db, _, err := openDB()
+	if err != nil {
+		return err
+	}
+
+	var project models.Project
+	if err := db.Where("id = ?", projectID).First(&project).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("project %s not found", projectID)
+		}
+		return err
+	}
+
+	// Same rotation as POST /projects/{id}/apikey
+	retiresAt := time.Now().Add(*gracePeriod)
+	apiKey := models.ApiKey{
+		ProjectID: project.ID,
+		Name:      "Rotated " + time.Now().Format("2006-01-02"),
+	}
+
+	var retired int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ApiKey{}).
+			Scopes(models.ActiveApiKeys).
+			Where("project_id = ?", project.ID).
+			Where("(expires_at IS NULL OR expires_at > ?)", retiresAt).
+			Update("expires_at", retiresAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		retired = result.RowsAffected
+
+		return tx.Create(&apiKey).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created API key %s for project %s, %d previous keys retire at %s\n",
+		apiKey.ID, project.Name, retired, retiresAt.Format(time.RFC3339))
+	fmt.Printf("API key: %s\n", apiKey.Secret)
+	return nil
+}
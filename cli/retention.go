@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"github.com/atqamz/kogase-backend/jobs"
+)
+
+// runRetention runs the retention commands
+func runRetention(args []string) error {
+	if _, err := subcommand("retention", args, "run"); err != nil {
+		return err
+	}
+
+	flags := newFlagSet("retention run")
+	if err := parseFlags(flags, args[1:]); err != nil {
+		return err
+	}
+
+	db, cfg, err := openDB()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	// Same job as the server runs every DATA_PURGE_INTERVAL, it logs what it purges
+	return jobs.PurgeExpiredData(db, cfg.DeletedRowRetention)(ctx)
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/atqamz/kogase-backend/server"
+)
+
+// runServe runs the HTTP server until it receives SIGINT or SIGTERM
+func runServe(args []string) error {
+	flags := newFlagSet("serve")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	s, err := server.New()
+	if err != nil {
+		return fmt.Errorf("initializing backend: %w", err)
+	}
+
+	log.Println("Starting Kogase backend...")
+	if err := s.Run(); err != nil {
+		return fmt.Errorf("running backend: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/atqamz/kogase-backend/utils"
+	"gorm.io/gorm"
+)
+
+// minPasswordLength matches the password rule of the users API
+const minPasswordLength = 6
+
+// runUser runs the user commands
+func runUser(args []string) error {
+	command, err := subcommand("user", args, "create", "reset-password", "list")
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "create":
+		return runUserCreate(args[1:])
+	case "reset-password":
+		return runUserResetPassword(args[1:])
+	default:
+		return runUserList(args[1:])
+	}
+}
+
+func runUserCreate(args []string) error {
+	flags := newFlagSet("user create")
+	email := flags.String("email", "", "Email address the user logs in with (required)")
+	name := flags.String("name", "", "Display name, defaults to the start of the email address")
+	passwordFlag := flags.String("password", "", "Password, a random one is generated and printed when empty")
+	changePassword := flags.Bool("change-password", true, "Require the user to change the password on first login")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := requireFlag("email", *email); err != nil {
+		return err
+	}
+	if *name == "" {
+		*name, _, _ = strings.Cut(*email, "@")
+	}
+
+	password, generated, err := passwordOrGenerate(*passwordFlag)
+	if err != nil {
+		return err
+	}
+
+	db, _, err := openDB()
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	if err := db.Model(&models.User{}).Where("email = ?", *email).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("email %s is already in use", *email)
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	user := models.User{
+		Email:              *email,
+		Password:           hashedPassword,
+		Name:               *name,
+		MustChangePassword: *changePassword,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		return err
+	}
+
+	fmt.Printf("Created user %s (%s)\n", user.Email, user.ID)
+	if generated {
+		fmt.Printf("Password: %s\n", password)
+	}
+	return nil
+}
+
+func runUserResetPassword(args []string) error {
+	flags := newFlagSet("user reset-password")
+	email := flags.String("email", "", "Email address of the user (required)")
+	passwordFlag := flags.String("password", "", "New password, a random one is generated and printed when empty")
+	changePassword := flags.Bool("change-password", true, "Require the user to change the password on next login")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := requireFlag("email", *email); err != nil {
+		return err
+	}
+
+	password, generated, err := passwordOrGenerate(*passwordFlag)
+	if err != nil {
+		return err
+	}
+
+	db, _, err := openDB()
+	if err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := db.Where("email = ?", *email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("no user with email %s", *email)
+		}
+		return err
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	// Whoever knew the old password may still be logged in
+	var revoked int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Choosing a new password also unlocks the user
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password":             hashedPassword,
+			"must_change_password": *changePassword,
+			"locked_at":            nil,
+		}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.AuthToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", time.Now())
+		revoked = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Reset the password of %s and revoked %d sessions\n", user.Email, revoked)
+	if generated {
+		fmt.Printf("Password: %s\n", password)
+	}
+	return nil
+}
+
+func runUserList(args []string) error {
+	flags := newFlagSet("user list")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	db, _, err := openDB()
+	if err != nil {
+		return err
+	}
+
+	var users []models.User
+	if err := db.Order("created_at").Find(&users).Error; err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tNAME\tCREATED AT")
+	for _, user := range users {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", user.ID, user.Email, user.Name, user.CreatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+// passwordOrGenerate checks the given password, or generates one when it is empty
+func passwordOrGenerate(password string) (string, bool, error) {
+	if password != "" {
+		if len(password) < minPasswordLength {
+			return "", false, fmt.Errorf("the password must be at least %d characters long", minPasswordLength)
+		}
+		return password, false, nil
+	}
+
+	generated, err := models.GenerateSecret("", 12)
+	return generated, true, err
+}
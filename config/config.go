@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"gorm.io/driver/postgres"
@@ -66,3 +67,48 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// Helper function to get an integer environment variable with fallback
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value for %s, using default %d", key, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// Helper function to get a boolean environment variable with fallback
+func getEnvBool(key string, fallback bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid value for %s, using default %t", key, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// Helper function to get a duration environment variable with fallback
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid value for %s, using default %s", key, fallback)
+		return fallback
+	}
+	return parsed
+}
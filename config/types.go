@@ -1,5 +1,9 @@
 package config
 
+import (
+	"time"
+)
+
 // Config holds the configuration for the application
 type Config struct {
 	// Database connection details
@@ -15,7 +19,29 @@ type Config struct {
 	JWTExpiration string
 
 	// Server settings
-	Port string
+	Port              string
+	MigrateOnStart    bool // Apply pending schema migrations when the server starts
+	AllowRegistration bool // Let anyone create an account, otherwise only logged-in users create accounts
+
+	// First admin, created on a fresh installation. Without them a one-time setup token is printed.
+	AdminEmail    string
+	AdminPassword string
+
+	// Event ingestion settings
+	IngestWorkers       int
+	IngestQueueSize     int
+	IngestBatchSize     int
+	IngestFlushInterval time.Duration
+
+	// Background job settings
+	SessionSweepInterval time.Duration
+	DataPurgeInterval    time.Duration
+	RollupInterval       time.Duration
+	DeletedRowRetention  time.Duration // Soft-deleted rows are purged after this long
+
+	// Geolocation settings
+	GeoIPDatabasePath string // MaxMind DB file, geolocation is disabled when empty
+	GeoIPCacheSize    int
 }
 
 // NewConfigFromEnv creates a new Config from environment variables
@@ -30,5 +56,24 @@ func NewConfigFromEnv() *Config {
 		JWTSecret:     getEnv("JWT_SECRET", "kogase-jwt-secret"),
 		JWTExpiration: getEnv("JWT_EXPIRATION", "24h"),
 		Port:          getEnv("PORT", "8080"),
+
+		MigrateOnStart:    getEnvBool("MIGRATE_ON_START", true),
+		AllowRegistration: getEnvBool("ALLOW_REGISTRATION", false),
+
+		AdminEmail:    getEnv("ADMIN_EMAIL", ""),
+		AdminPassword: getEnv("ADMIN_PASSWORD", ""),
+
+		IngestWorkers:       getEnvInt("INGEST_WORKERS", 4),
+		IngestQueueSize:     getEnvInt("INGEST_QUEUE_SIZE", 10000),
+		IngestBatchSize:     getEnvInt("INGEST_BATCH_SIZE", 500),
+		IngestFlushInterval: getEnvDuration("INGEST_FLUSH_INTERVAL", time.Second),
+
+		SessionSweepInterval: getEnvDuration("SESSION_SWEEP_INTERVAL", time.Minute),
+		DataPurgeInterval:    getEnvDuration("DATA_PURGE_INTERVAL", 24*time.Hour),
+		RollupInterval:       getEnvDuration("ROLLUP_INTERVAL", time.Hour),
+		DeletedRowRetention:  getEnvDuration("DELETED_ROW_RETENTION", 30*24*time.Hour),
+
+		GeoIPDatabasePath: getEnv("GEOIP_DATABASE_PATH", ""),
+		GeoIPCacheSize:    getEnvInt("GEOIP_CACHE_SIZE", 10000),
 	}
 }
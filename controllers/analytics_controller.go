@@ -1,7 +1,11 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/atqamz/kogase-backend/dtos"
@@ -10,6 +14,12 @@ import (
 	"gorm.io/gorm"
 )
 
+// maxAnalyticsRange bounds the date range of analytics queries that scan raw rows
+const maxAnalyticsRange = 366 * 24 * time.Hour
+
+// maxTimeseriesPoints bounds the number of buckets returned by a time series query
+const maxTimeseriesPoints = 1000
+
 type AnalyticsController struct {
 	DB *gorm.DB
 }
@@ -20,16 +30,18 @@ func NewAnalyticsController(db *gorm.DB) *AnalyticsController {
 
 // GetAnalytics godoc
 // @Summary Get analytics data
-// @Description Retrieve analytics data for a project including DAU, MAU, total duration, and total installs
+// @Description Retrieve analytics data for a project including DAU, MAU (distinct devices with a session in the last day and 30 days), total duration, total installs and events that failed schema validation
 // @Tags analytics
 // @Produce json
 // @Security BearerAuth
 // @Param project_id query string false "Filter by project ID"
+// @Param organization_id query string false "Filter by organization ID, ignored with project_id"
 // @Param from_date query string false "Filter by start date (RFC3339)"
 // @Param to_date query string false "Filter by end date (RFC3339)"
 // @Success 200 {object} dtos.GetAnalyticsResponse
 // @Failure 400 {object} dtos.ErrorResponse
 // @Failure 401 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
 // @Router /analytics [get]
 func (ac *AnalyticsController) GetAnalytics(c *gin.Context) {
 	_, exist := c.Get("user_id")
@@ -38,6 +50,7 @@ func (ac *AnalyticsController) GetAnalytics(c *gin.Context) {
 			Message: "User not found",
 		}
 		c.JSON(http.StatusUnauthorized, response)
+		return
 	}
 
 	var request dtos.GetAnalyticsRequestQuery
@@ -49,10 +62,12 @@ func (ac *AnalyticsController) GetAnalytics(c *gin.Context) {
 		return
 	}
 
-	sessionQuery := ac.DB.Model(&models.Session{})
-	if request.ProjectID != "" {
-		sessionQuery = sessionQuery.Where("project_id = ?", request.ProjectID)
+	var projectIDs interface{} = request.ProjectID
+	if request.ProjectID == "" {
+		projectIDs = memberProjectIDs(c, ac.DB, request.OrganizationID)
 	}
+
+	sessionQuery := ac.DB.Model(&models.Session{}).Where("project_id IN (?)", projectIDs)
 	if !request.FromDate.IsZero() {
 		sessionQuery = sessionQuery.Where("begin_at >= ?", request.FromDate)
 	}
@@ -67,46 +82,878 @@ func (ac *AnalyticsController) GetAnalytics(c *gin.Context) {
 		TotalInstalls: 0,
 	}
 
-	var sessions []models.Session
-	if err := sessionQuery.Find(&sessions).Error; err != nil {
+	// DAU and MAU count distinct devices, not sessions
+	var activity struct {
+		DAU int
+		MAU int
+	}
+	now := time.Now()
+	if err := sessionQuery.Session(&gorm.Session{}).
+		Select("COUNT(DISTINCT device_id) FILTER (WHERE begin_at > ?) AS dau, COUNT(DISTINCT device_id) AS mau", now.AddDate(0, 0, -1)).
+		Where("begin_at > ?", now.AddDate(0, 0, -30)).
+		Scan(&activity).Error; err != nil {
 		response.DAU = 0
 		response.MAU = 0
+	} else {
+		response.DAU = activity.DAU
+		response.MAU = activity.MAU
+	}
+
+	eventQuery := ac.DB.Model(&models.Event{}).
+		Where("project_id IN (?)", projectIDs).
+		Where("event_type = ? AND event_name = ?", models.EventTypePredefined, "install")
+	if !request.FromDate.IsZero() {
+		eventQuery = eventQuery.Where("timestamp >= ?", request.FromDate)
+	}
+	if !request.ToDate.IsZero() {
+		eventQuery = eventQuery.Where("timestamp <= ?", request.ToDate)
+	}
+
+	// Whole days covered by the rollups are read from them, raw rows only around them
+	var rollupDuration, rollupInstalls int64
+	if fromDay, toDay, ok := rollupDays(ac.DB, projectIDs, request.FromDate, request.ToDate); ok {
+		if err := ac.DB.Model(&models.DailyActivityRollup{}).
+			Where("project_id IN (?) AND day >= ? AND day < ?", projectIDs, fromDay, toDay).
+			Select("COALESCE(SUM(total_duration), 0)").
+			Scan(&rollupDuration).Error; err == nil {
+			sessionQuery = sessionQuery.Where("NOT (begin_at >= ? AND begin_at < ?)", fromDay, toDay)
+		} else {
+			rollupDuration = 0
+		}
+
+		if err := ac.DB.Model(&models.DailyEventRollup{}).
+			Where("project_id IN (?) AND day >= ? AND day < ?", projectIDs, fromDay, toDay).
+			Where("event_type = ? AND event_name = ?", models.EventTypePredefined, "install").
+			Select("COALESCE(SUM(events), 0)").
+			Scan(&rollupInstalls).Error; err == nil {
+			eventQuery = eventQuery.Where("NOT (timestamp >= ? AND timestamp < ?)", fromDay, toDay)
+		} else {
+			rollupInstalls = 0
+		}
+	}
+
+	var totalDuration int64
+	if err := sessionQuery.
+		Select("COALESCE(SUM(duration), 0)").
+		Scan(&totalDuration).Error; err != nil {
 		response.TotalDuration = 0
 	} else {
-		for _, session := range sessions {
-			if session.BeginAt.After(time.Now().AddDate(0, 0, -1)) {
-				response.DAU++
+		response.TotalDuration = rollupDuration + totalDuration
+	}
+
+	var totalInstalls int64
+	if err := eventQuery.Count(&totalInstalls).Error; err != nil {
+		response.TotalInstalls = 0
+	} else {
+		response.TotalInstalls = int(rollupInstalls + totalInstalls)
+	}
+
+	statQuery := ac.DB.Model(&models.EventValidationStat{}).
+		Scopes(validationStatRange(request.FromDate, request.ToDate)).
+		Where("project_id IN (?)", projectIDs)
+
+	var validationTotals struct {
+		Warned   int64
+		Rejected int64
+	}
+	if err := statQuery.
+		Select("COALESCE(SUM(warned), 0) AS warned, COALESCE(SUM(rejected), 0) AS rejected").
+		Scan(&validationTotals).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to count validated events",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	response.WarnedEvents = validationTotals.Warned
+	response.RejectedEvents = validationTotals.Rejected
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetRetention godoc
+// @Summary Get retention cohorts
+// @Description Group devices into daily cohorts by their first seen day or install event, and report the share of each cohort that starts a session on each following day (D1/D7/D30 retention)
+// @Tags analytics
+// @Produce json
+// @Security BearerAuth
+// @Param project_id query string true "Project ID"
+// @Param from_date query string false "First cohort day (RFC3339), defaults to 30 days before to_date"
+// @Param to_date query string false "Last cohort day (RFC3339), defaults to today"
+// @Param cohort_by query string false "Cohort devices by first_seen (default) or install"
+// @Param max_day query int false "Last day after the cohort day to report (1-90, default 30)"
+// @Param platform query string false "Filter by device platform"
+// @Param country query string false "Filter by device country"
+// @Param app_version query string false "Filter by app version"
+// @Success 200 {object} dtos.GetRetentionResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /analytics/retention [get]
+func (ac *AnalyticsController) GetRetention(c *gin.Context) {
+	var request dtos.GetRetentionRequestQuery
+	if err := c.ShouldBindQuery(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request query",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if request.CohortBy == "" {
+		request.CohortBy = "first_seen"
+	}
+	if request.MaxDay == 0 {
+		request.MaxDay = 30
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	toDay := today
+	if !request.ToDate.IsZero() {
+		toDay = request.ToDate.UTC().Truncate(24 * time.Hour)
+	}
+	fromDay := toDay.AddDate(0, 0, -30)
+	if !request.FromDate.IsZero() {
+		fromDay = request.FromDate.UTC().Truncate(24 * time.Hour)
+	}
+	if fromDay.After(toDay) {
+		response := dtos.ErrorResponse{
+			Message: "from_date must not be after to_date",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	if toDay.Sub(fromDay) > maxAnalyticsRange {
+		response := dtos.ErrorResponse{
+			Message: "Date range is limited to 366 days",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	// Each query needs its own copy of the cohort subquery
+	cohorts := func() *gorm.DB {
+		return ac.retentionCohorts(request, fromDay, toDay)
+	}
+
+	var sizes []struct {
+		CohortDay time.Time
+		Size      int64
+	}
+	if err := ac.DB.Table("(?) AS cohorts", cohorts()).
+		Select("cohort_day, COUNT(*) AS size").
+		Group("cohort_day").
+		Order("cohort_day").
+		Scan(&sizes).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to compute retention cohorts",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	var activity []struct {
+		CohortDay time.Time
+		DayOffset int
+		Devices   int64
+	}
+	if err := ac.DB.Table("(?) AS cohorts", cohorts()).
+		Joins("JOIN sessions ON sessions.device_id = cohorts.device_id AND sessions.deleted_at IS NULL").
+		Select("cohorts.cohort_day, (sessions.begin_at AT TIME ZONE 'UTC')::date - cohorts.cohort_day AS day_offset, COUNT(DISTINCT cohorts.device_id) AS devices").
+		Where("(sessions.begin_at AT TIME ZONE 'UTC')::date BETWEEN cohorts.cohort_day AND cohorts.cohort_day + ?", request.MaxDay).
+		Group("cohorts.cohort_day, day_offset").
+		Scan(&activity).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to compute retention cohorts",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.GetRetentionResponse{
+		CohortBy: request.CohortBy,
+		MaxDay:   request.MaxDay,
+		Cohorts:  make([]dtos.RetentionCohortResponse, len(sizes)),
+	}
+
+	cohortIndexes := make(map[string]int, len(sizes))
+	for i, size := range sizes {
+		// Days after today can't be observed yet
+		observable := int(today.Sub(size.CohortDay.UTC()) / (24 * time.Hour))
+		if observable > request.MaxDay {
+			observable = request.MaxDay
+		}
+		if observable < 0 {
+			observable = 0
+		}
+
+		day := size.CohortDay.Format(time.DateOnly)
+		cohortIndexes[day] = i
+		resultResponse.Cohorts[i] = dtos.RetentionCohortResponse{
+			CohortDay: day,
+			Size:      size.Size,
+			Retained:  make([]int64, observable+1),
+			Rates:     make([]float64, observable+1),
+		}
+	}
+
+	for _, row := range activity {
+		i, ok := cohortIndexes[row.CohortDay.Format(time.DateOnly)]
+		if !ok || row.DayOffset >= len(resultResponse.Cohorts[i].Retained) {
+			continue
+		}
+		resultResponse.Cohorts[i].Retained[row.DayOffset] = row.Devices
+	}
+
+	for i := range resultResponse.Cohorts {
+		cohort := &resultResponse.Cohorts[i]
+		for day, retained := range cohort.Retained {
+			cohort.Rates[day] = float64(retained) / float64(cohort.Size)
+		}
+	}
+
+	resultResponse.D1 = retentionOnDay(resultResponse.Cohorts, 1)
+	resultResponse.D7 = retentionOnDay(resultResponse.Cohorts, 7)
+	resultResponse.D30 = retentionOnDay(resultResponse.Cohorts, 30)
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// retentionCohorts returns a subquery selecting device_id and cohort_day for every device
+// whose cohort day falls in the range and that matches the device filters
+func (ac *AnalyticsController) retentionCohorts(request dtos.GetRetentionRequestQuery, fromDay time.Time, toDay time.Time) *gorm.DB {
+	var query *gorm.DB
+	if request.CohortBy == "install" {
+		query = ac.DB.Model(&models.Event{}).
+			Select("events.device_id, MIN((events.timestamp AT TIME ZONE 'UTC')::date) AS cohort_day").
+			Joins("JOIN devices ON devices.id = events.device_id AND devices.deleted_at IS NULL").
+			Where("events.project_id = ? AND events.event_type = ? AND events.event_name = ?", request.ProjectID, models.EventTypePredefined, "install").
+			Group("events.device_id").
+			Having("MIN((events.timestamp AT TIME ZONE 'UTC')::date) BETWEEN ?::date AND ?::date", fromDay.Format(time.DateOnly), toDay.Format(time.DateOnly))
+	} else {
+		query = ac.DB.Model(&models.Device{}).
+			Select("devices.id AS device_id, (devices.first_seen AT TIME ZONE 'UTC')::date AS cohort_day").
+			Where("devices.project_id = ?", request.ProjectID).
+			Where("(devices.first_seen AT TIME ZONE 'UTC')::date BETWEEN ?::date AND ?::date", fromDay.Format(time.DateOnly), toDay.Format(time.DateOnly))
+	}
+
+	if request.Platform != "" {
+		query = query.Where("devices.platform = ?", request.Platform)
+	}
+	if request.Country != "" {
+		query = query.Where("devices.country = ?", request.Country)
+	}
+	if request.AppVersion != "" {
+		query = query.Where("devices.app_version = ?", request.AppVersion)
+	}
+
+	return query
+}
+
+// retentionOnDay returns the share of devices retained on the given day over every cohort
+// old enough to have reached it, or nil when none has
+func retentionOnDay(cohorts []dtos.RetentionCohortResponse, day int) *float64 {
+	var size, retained int64
+	for _, cohort := range cohorts {
+		if day < len(cohort.Retained) {
+			size += cohort.Size
+			retained += cohort.Retained[day]
+		}
+	}
+
+	if size == 0 {
+		return nil
+	}
+
+	rate := float64(retained) / float64(size)
+	return &rate
+}
+
+// GetTimeseries godoc
+// @Summary Get activity time series
+// @Description Retrieve distinct active devices, new devices, sessions and average session length per hour, day, week or month
+// @Tags analytics
+// @Produce json
+// @Security BearerAuth
+// @Param project_id query string false "Filter by project ID"
+// @Param organization_id query string false "Filter by organization ID, ignored with project_id"
+// @Param from_date query string false "Filter by start date (RFC3339), defaults to 30 days before to_date"
+// @Param to_date query string false "Filter by end date (RFC3339), defaults to now"
+// @Param granularity query string false "Bucket size: hour, day (default), week or month"
+// @Success 200 {object} dtos.GetTimeseriesResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /analytics/timeseries [get]
+func (ac *AnalyticsController) GetTimeseries(c *gin.Context) {
+	var request dtos.GetTimeseriesRequestQuery
+	if err := c.ShouldBindQuery(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request query",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if request.Granularity == "" {
+		request.Granularity = "day"
+	}
+	if request.ToDate.IsZero() {
+		request.ToDate = time.Now()
+	}
+	if request.FromDate.IsZero() {
+		request.FromDate = request.ToDate.AddDate(0, 0, -30)
+	}
+	if request.FromDate.After(request.ToDate) {
+		response := dtos.ErrorResponse{
+			Message: "from_date must not be after to_date",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var buckets []time.Time
+	for bucket := truncateToBucket(request.FromDate, request.Granularity); !bucket.After(request.ToDate); bucket = nextBucket(bucket, request.Granularity) {
+		if len(buckets) == maxTimeseriesPoints {
+			response := dtos.ErrorResponse{
+				Message: "Too many points, use a coarser granularity or a shorter range",
 			}
+			c.JSON(http.StatusBadRequest, response)
+			return
 		}
-		for _, session := range sessions {
-			if session.BeginAt.After(time.Now().AddDate(0, 0, -30)) {
-				response.MAU++
+		buckets = append(buckets, bucket)
+	}
+
+	// The granularity is one of the validated date_trunc units
+	bucketOf := func(column string) string {
+		return fmt.Sprintf("date_trunc('%s', %s AT TIME ZONE 'UTC')", request.Granularity, column)
+	}
+
+	var projectIDs interface{} = request.ProjectID
+	if request.ProjectID == "" {
+		projectIDs = memberProjectIDs(c, ac.DB, request.OrganizationID)
+	}
+
+	sessionQuery := ac.DB.Model(&models.Session{}).Where("project_id IN (?)", projectIDs)
+	deviceQuery := ac.DB.Model(&models.Device{}).Where("project_id IN (?)", projectIDs)
+
+	// Distinct devices only add up across days, so only daily points are read from the
+	// rollups. Whole days they cover come from them, raw rows fill in the rest.
+	var rollupRows []struct {
+		Bucket           time.Time
+		ActiveDevices    int64
+		NewDevices       int64
+		Sessions         int64
+		AvgSessionLength float64
+	}
+	if request.Granularity == "day" {
+		if fromDay, toDay, ok := rollupDays(ac.DB, projectIDs, request.FromDate, request.ToDate); ok {
+			if err := ac.DB.Model(&models.DailyActivityRollup{}).
+				Select("day AS bucket, SUM(active_devices) AS active_devices, SUM(new_devices) AS new_devices, SUM(sessions) AS sessions, COALESCE(SUM(total_duration) / NULLIF(SUM(ended_sessions), 0), 0) / 1e9 AS avg_session_length").
+				Where("project_id IN (?) AND day >= ? AND day < ?", projectIDs, fromDay, toDay).
+				Group("day").
+				Scan(&rollupRows).Error; err != nil {
+				response := dtos.ErrorResponse{
+					Message: "Failed to read activity rollups",
+				}
+				c.JSON(http.StatusInternalServerError, response)
+				return
 			}
+
+			sessionQuery = sessionQuery.Where("NOT (begin_at >= ? AND begin_at < ?)", fromDay, toDay)
+			deviceQuery = deviceQuery.Where("NOT (first_seen >= ? AND first_seen < ?)", fromDay, toDay)
 		}
-		for _, session := range sessions {
-			response.TotalDuration += session.Duration.Nanoseconds()
+	}
+
+	var sessionRows []struct {
+		Bucket           time.Time
+		ActiveDevices    int64
+		Sessions         int64
+		AvgSessionLength float64
+	}
+	if err := sessionQuery.
+		Select(bucketOf("begin_at")+" AS bucket, COUNT(DISTINCT device_id) AS active_devices, COUNT(*) AS sessions, COALESCE(AVG(duration) FILTER (WHERE duration > 0), 0) / 1e9 AS avg_session_length").
+		Where("begin_at >= ? AND begin_at <= ?", request.FromDate, request.ToDate).
+		Group("bucket").
+		Scan(&sessionRows).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to compute session activity",
 		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
 	}
 
-	eventQuery := ac.DB.Model(&models.Event{})
-	if request.ProjectID != "" {
-		eventQuery = eventQuery.Where("project_id = ?", request.ProjectID)
+	var deviceRows []struct {
+		Bucket     time.Time
+		NewDevices int64
 	}
-	if !request.FromDate.IsZero() {
-		eventQuery = eventQuery.Where("received_at >= ?", request.FromDate)
+	if err := deviceQuery.
+		Select(bucketOf("first_seen")+" AS bucket, COUNT(*) AS new_devices").
+		Where("first_seen >= ? AND first_seen <= ?", request.FromDate, request.ToDate).
+		Group("bucket").
+		Scan(&deviceRows).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to compute new devices",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
 	}
-	if !request.ToDate.IsZero() {
-		eventQuery = eventQuery.Where("received_at <= ?", request.ToDate)
+
+	resultResponse := dtos.GetTimeseriesResponse{
+		Granularity: request.Granularity,
+		FromDate:    request.FromDate,
+		ToDate:      request.ToDate,
+		Points:      make([]dtos.TimeseriesPointResponse, len(buckets)),
+	}
+	pointIndexes := make(map[time.Time]int, len(buckets))
+	for i, bucket := range buckets {
+		resultResponse.Points[i].Timestamp = bucket
+		pointIndexes[bucket] = i
 	}
 
-	var totalInstalls int64
-	if err := eventQuery.Model(&models.Event{}).
-		Where("event_type = ? AND event_name = ?", "predefined", "install").
-		Count(&totalInstalls).Error; err != nil {
-		response.TotalInstalls = 0
-	} else {
-		response.TotalInstalls = int(totalInstalls)
+	for _, row := range sessionRows {
+		if i, ok := pointIndexes[row.Bucket.UTC()]; ok {
+			resultResponse.Points[i].ActiveDevices = row.ActiveDevices
+			resultResponse.Points[i].Sessions = row.Sessions
+			resultResponse.Points[i].AvgSessionLength = row.AvgSessionLength
+		}
+	}
+	for _, row := range deviceRows {
+		if i, ok := pointIndexes[row.Bucket.UTC()]; ok {
+			resultResponse.Points[i].NewDevices = row.NewDevices
+		}
+	}
+	for _, row := range rollupRows {
+		if i, ok := pointIndexes[row.Bucket.UTC()]; ok {
+			resultResponse.Points[i].ActiveDevices = row.ActiveDevices
+			resultResponse.Points[i].NewDevices = row.NewDevices
+			resultResponse.Points[i].Sessions = row.Sessions
+			resultResponse.Points[i].AvgSessionLength = row.AvgSessionLength
+		}
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// truncateToBucket returns the start of the UTC bucket containing t, matching date_trunc
+func truncateToBucket(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	switch granularity {
+	case "hour":
+		return t.Truncate(time.Hour)
+	case "week":
+		// Weeks start on Monday
+		day := t.Truncate(24 * time.Hour)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return t.Truncate(24 * time.Hour)
+	}
+}
+
+// nextBucket returns the start of the bucket following the one starting at t
+func nextBucket(t time.Time, granularity string) time.Time {
+	switch granularity {
+	case "hour":
+		return t.Add(time.Hour)
+	case "week":
+		return t.AddDate(0, 0, 7)
+	case "month":
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// GetFunnel godoc
+// @Summary Get funnel conversion
+// @Description Count the devices that fire an ordered sequence of events, optionally within a conversion window measured from the first step, with conversion rates and the median time between steps
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param project_id query string true "Project ID"
+// @Param funnel body dtos.GetFunnelRequest true "Funnel definition"
+// @Success 200 {object} dtos.GetFunnelResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /analytics/funnel [post]
+func (ac *AnalyticsController) GetFunnel(c *gin.Context) {
+	var query dtos.GetFunnelRequestQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request query",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var request dtos.GetFunnelRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if request.ToDate.IsZero() {
+		request.ToDate = time.Now()
+	}
+	if request.FromDate.IsZero() {
+		request.FromDate = request.ToDate.AddDate(0, 0, -30)
+	}
+	if request.FromDate.After(request.ToDate) {
+		response := dtos.ErrorResponse{
+			Message: "from_date must not be after to_date",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	if request.ToDate.Sub(request.FromDate) > maxAnalyticsRange {
+		response := dtos.ErrorResponse{
+			Message: "Date range is limited to 366 days",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var window time.Duration
+	if request.Window != "" {
+		var err error
+		window, err = time.ParseDuration(request.Window)
+		if err != nil || window <= 0 {
+			response := dtos.ErrorResponse{
+				Message: "Invalid conversion window",
+			}
+			c.JSON(http.StatusBadRequest, response)
+			return
+		}
+	}
+
+	sql, args, err := funnelQuery(query.ProjectID, request, window)
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid step filters",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var rows []struct {
+		Step          int
+		Devices       int64
+		MedianSeconds *float64
+	}
+	if err := ac.DB.Raw(sql, args...).Scan(&rows).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to compute funnel",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.GetFunnelResponse{
+		FromDate: request.FromDate,
+		ToDate:   request.ToDate,
+		Window:   request.Window,
+		Steps:    make([]dtos.FunnelStepResponse, len(request.Steps)),
+	}
+	for i, step := range request.Steps {
+		resultResponse.Steps[i] = dtos.FunnelStepResponse{
+			Step:      i + 1,
+			EventName: step.EventName,
+			EventType: step.EventType,
+		}
+	}
+	for _, row := range rows {
+		if row.Step < 1 || row.Step > len(resultResponse.Steps) {
+			continue
+		}
+		resultResponse.Steps[row.Step-1].Devices = row.Devices
+		if row.Step > 1 {
+			resultResponse.Steps[row.Step-1].MedianSecondsFromPrior = row.MedianSeconds
+		}
+	}
+
+	first := resultResponse.Steps[0].Devices
+	for i := range resultResponse.Steps {
+		step := &resultResponse.Steps[i]
+		if first > 0 {
+			step.ConversionRate = float64(step.Devices) / float64(first)
+		}
+		if i == 0 {
+			step.StepConversionRate = step.ConversionRate
+		} else if previous := resultResponse.Steps[i-1].Devices; previous > 0 {
+			step.StepConversionRate = float64(step.Devices) / float64(previous)
+		}
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// funnelQuery builds a query returning, for every step, the number of devices that reached it
+// and the median seconds since the previous step. Each step keeps the earliest matching event
+// strictly after the device's previous step.
+func funnelQuery(projectID string, request dtos.GetFunnelRequest, window time.Duration) (string, []interface{}, error) {
+	var ctes []string
+	var selects []string
+	var args []interface{}
+
+	for i, step := range request.Steps {
+		condition := "e.event_name = ?"
+		conditionArgs := []interface{}{step.EventName}
+		if step.EventType != "" {
+			condition += " AND e.event_type = ?"
+			conditionArgs = append(conditionArgs, step.EventType)
+		}
+		if len(step.Filters) > 0 {
+			filters, err := json.Marshal(step.Filters)
+			if err != nil {
+				return "", nil, err
+			}
+			condition += " AND e.payloads @> ?::jsonb"
+			conditionArgs = append(conditionArgs, string(filters))
+		}
+
+		if i == 0 {
+			ctes = append(ctes, "step1 AS (SELECT e.device_id, MIN(e.timestamp) AS first_at, MIN(e.timestamp) AS reached_at, NULL::timestamptz AS previous_at"+
+				" FROM events e WHERE e.deleted_at IS NULL AND e.project_id = ? AND e.timestamp >= ? AND e.timestamp <= ? AND "+condition+
+				" GROUP BY e.device_id)")
+			args = append(args, projectID, request.FromDate, request.ToDate)
+			args = append(args, conditionArgs...)
+		} else {
+			deadline := "e.timestamp <= ?"
+			var deadlineArg interface{} = request.ToDate
+			if window > 0 {
+				deadline = "e.timestamp <= p.first_at + ? * INTERVAL '1 second'"
+				deadlineArg = window.Seconds()
+			}
+
+			ctes = append(ctes, fmt.Sprintf("step%d AS (SELECT p.device_id, p.first_at, MIN(e.timestamp) AS reached_at, p.reached_at AS previous_at"+
+				" FROM step%d p JOIN events e ON e.device_id = p.device_id AND e.deleted_at IS NULL AND e.project_id = ? AND e.timestamp > p.reached_at AND %s AND %s"+
+				" GROUP BY p.device_id, p.first_at, p.reached_at)", i+1, i, deadline, condition))
+			args = append(args, projectID, deadlineArg)
+			args = append(args, conditionArgs...)
+		}
+
+		selects = append(selects, fmt.Sprintf("SELECT %d AS step, COUNT(*) AS devices,"+
+			" percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM reached_at - previous_at)) AS median_seconds FROM step%d", i+1, i+1))
+	}
+
+	return "WITH " + strings.Join(ctes, ", ") + " " + strings.Join(selects, " UNION ALL "), args, nil
+}
+
+// AggregateEvents godoc
+// @Summary Aggregate events
+// @Description Compute a count, unique devices, or the sum, average, minimum, maximum or a percentile of a numeric payload property over events, grouped by up to two dimensions (platform, country, region, city, app_version or payload.<key>) and optionally bucketed by time
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param project_id query string true "Project ID"
+// @Param aggregation body dtos.AggregateEventsRequest true "Aggregation definition"
+// @Success 200 {object} dtos.AggregateEventsResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /analytics/aggregate [post]
+func (ac *AnalyticsController) AggregateEvents(c *gin.Context) {
+	var query dtos.AggregateEventsRequestQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request query",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var request dtos.AggregateEventsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if request.ToDate.IsZero() {
+		request.ToDate = time.Now()
+	}
+	if request.FromDate.IsZero() {
+		request.FromDate = request.ToDate.AddDate(0, 0, -30)
+	}
+	if request.FromDate.After(request.ToDate) {
+		response := dtos.ErrorResponse{
+			Message: "from_date must not be after to_date",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	if request.ToDate.Sub(request.FromDate) > maxAnalyticsRange {
+		response := dtos.ErrorResponse{
+			Message: "Date range is limited to 366 days",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	if request.Limit == 0 {
+		request.Limit = 1000
+	}
+
+	sql, args, err := aggregateEventsQuery(query.ProjectID, request)
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid aggregation: " + err.Error(),
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var rows []struct {
+		Bucket *time.Time
+		Dim1   *string
+		Dim2   *string
+		Value  *float64
+	}
+	if err := ac.DB.Raw(sql, args...).Scan(&rows).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to aggregate events",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.AggregateEventsResponse{
+		Metric:   request.Metric,
+		Property: request.Property,
+		GroupBy:  request.GroupBy,
+		Rows:     make([]dtos.AggregateEventsRow, 0, len(rows)),
+	}
+	if len(rows) > request.Limit {
+		resultResponse.Truncated = true
+		rows = rows[:request.Limit]
+	}
+
+	for _, row := range rows {
+		result := dtos.AggregateEventsRow{
+			Value: row.Value,
+		}
+		if row.Bucket != nil {
+			bucket := row.Bucket.UTC()
+			result.Timestamp = &bucket
+		}
+		if len(request.GroupBy) > 0 {
+			result.Dimensions = map[string]*string{request.GroupBy[0]: row.Dim1}
+			if len(request.GroupBy) > 1 {
+				result.Dimensions[request.GroupBy[1]] = row.Dim2
+			}
+		}
+		resultResponse.Rows = append(resultResponse.Rows, result)
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// aggregateEventsQuery builds a query returning bucket, dim1, dim2 and value columns for an
+// aggregation. It asks for one row more than the limit so truncation can be detected.
+func aggregateEventsQuery(projectID string, request dtos.AggregateEventsRequest) (string, []interface{}, error) {
+	var args []interface{}
+	var groups []string
+
+	bucket := "NULL::timestamp"
+	if request.Granularity != "" {
+		// The granularity is one of the validated date_trunc units
+		bucket = fmt.Sprintf("date_trunc('%s', e.timestamp AT TIME ZONE 'UTC')", request.Granularity)
+		groups = append(groups, "1")
+	}
+
+	dimensions := []string{"NULL::text", "NULL::text"}
+	for i, dimension := range request.GroupBy {
+		switch {
+		case dimension == "platform":
+			dimensions[i] = "d.platform"
+		case dimension == "country":
+			dimensions[i] = "d.country"
+		case dimension == "region":
+			dimensions[i] = "d.region"
+		case dimension == "city":
+			dimensions[i] = "d.city"
+		case dimension == "app_version":
+			dimensions[i] = "d.app_version"
+		case strings.HasPrefix(dimension, "payload.") && len(dimension) > len("payload."):
+			dimensions[i] = "e.payloads ->> ?::text"
+			args = append(args, strings.TrimPrefix(dimension, "payload."))
+		default:
+			return "", nil, fmt.Errorf("unknown dimension %q", dimension)
+		}
+		groups = append(groups, fmt.Sprint(i+2))
+	}
+
+	// Non-numeric values of the property are ignored
+	numeric := "CASE WHEN jsonb_typeof(e.payloads -> ?::text) = 'number' THEN (e.payloads ->> ?::text)::double precision END"
+	var value string
+	switch request.Metric {
+	case "count":
+		value = "COUNT(*)"
+	case "unique_devices":
+		value = "COUNT(DISTINCT e.device_id)"
+	default:
+		if request.Property == "" {
+			return "", nil, fmt.Errorf("metric %s requires a property", request.Metric)
+		}
+		switch request.Metric {
+		case "sum":
+			value = "SUM(" + numeric + ")"
+		case "avg":
+			value = "AVG(" + numeric + ")"
+		case "min":
+			value = "MIN(" + numeric + ")"
+		case "max":
+			value = "MAX(" + numeric + ")"
+		case "percentile":
+			if request.Percentile == 0 {
+				return "", nil, errors.New("metric percentile requires a percentile")
+			}
+			value = "percentile_cont(?) WITHIN GROUP (ORDER BY " + numeric + ")"
+			args = append(args, request.Percentile)
+		}
+		args = append(args, request.Property, request.Property)
+	}
+
+	sql := "SELECT " + bucket + " AS bucket, " + dimensions[0] + " AS dim1, " + dimensions[1] + " AS dim2, " + value + " AS value" +
+		" FROM events e LEFT JOIN devices d ON d.id = e.device_id" +
+		" WHERE e.deleted_at IS NULL AND e.project_id = ? AND e.timestamp >= ? AND e.timestamp <= ?"
+	args = append(args, projectID, request.FromDate, request.ToDate)
+
+	if request.EventName != "" {
+		sql += " AND e.event_name = ?"
+		args = append(args, request.EventName)
+	}
+	if request.EventType != "" {
+		sql += " AND e.event_type = ?"
+		args = append(args, request.EventType)
+	}
+	if len(request.Filters) > 0 {
+		filters, err := json.Marshal(request.Filters)
+		if err != nil {
+			return "", nil, errors.New("invalid filters")
+		}
+		sql += " AND e.payloads @> ?::jsonb"
+		args = append(args, string(filters))
+	}
+
+	if len(groups) > 0 {
+		sql += " GROUP BY " + strings.Join(groups, ", ")
+	}
+	sql += " ORDER BY 1, 4 DESC NULLS LAST LIMIT ?"
+	args = append(args, request.Limit+1)
+
+	return sql, args, nil
 }
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/atqamz/kogase-backend/dtos"
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ApiKeyController struct {
+	DB *gorm.DB
+}
+
+func NewApiKeyController(db *gorm.DB) *ApiKeyController {
+	return &ApiKeyController{DB: db}
+}
+
+// GetApiKeys godoc
+// @Summary Get project API keys
+// @Description Retrieve all API keys of a project, including retired and revoked ones
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} dtos.GetApiKeysResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/apikeys [get]
+func (kc *ApiKeyController) GetApiKeys(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var apiKeys []models.ApiKey
+	if err := kc.DB.Model(&models.ApiKey{}).
+		Where("project_id = ?", projectID).
+		Order("created_at DESC").
+		Find(&apiKeys).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to retrieve API keys",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.GetApiKeysResponse{
+		ApiKeys: make([]dtos.ApiKeyResponse, len(apiKeys)),
+	}
+	for i, apiKey := range apiKeys {
+		resultResponse.ApiKeys[i] = toApiKeyResponse(apiKey)
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// CreateApiKey godoc
+// @Summary Create an API key
+// @Description Create a new named API key with the given scopes for a project
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param apikey body dtos.CreateApiKeyRequest true "API key details"
+// @Success 201 {object} dtos.ApiKeyResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/apikeys [post]
+func (kc *ApiKeyController) CreateApiKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var request dtos.CreateApiKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		response := dtos.ErrorResponse{
+			Message: "Expiry must be in the future",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	createdBy := userID.(uuid.UUID)
+	apiKey := models.ApiKey{
+		ProjectID:   projectID.(uuid.UUID),
+		Name:        request.Name,
+		CreatedByID: &createdBy,
+		ExpiresAt:   request.ExpiresAt,
+	}
+	if len(request.Scopes) > 0 {
+		apiKey.Scopes = request.Scopes
+	}
+	if err := kc.DB.Create(&apiKey).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to create API key",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toApiKeyResponse(apiKey))
+}
+
+// RevokeApiKey godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key immediately, or retire it after a grace period so shipped builds keep working while new ones roll out
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param key_id path string true "API key ID"
+// @Param grace_period query string false "Keep the key working for this long (e.g. 72h)"
+// @Success 200 {object} dtos.RevokeApiKeyResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/apikeys/{key_id} [delete]
+func (kc *ApiKeyController) RevokeApiKey(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	keyID, err := uuid.Parse(c.Param("key_id"))
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid API key ID",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var query dtos.RevokeApiKeyRequestQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request query",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var gracePeriod time.Duration
+	if query.GracePeriod != "" {
+		gracePeriod, err = time.ParseDuration(query.GracePeriod)
+		if err != nil || gracePeriod < 0 {
+			response := dtos.ErrorResponse{
+				Message: "Invalid grace period",
+			}
+			c.JSON(http.StatusBadRequest, response)
+			return
+		}
+	}
+
+	var apiKey models.ApiKey
+	if err := kc.DB.Model(&models.ApiKey{}).
+		Scopes(models.ActiveApiKeys).
+		Where("id = ? AND project_id = ?", keyID, projectID).
+		First(&apiKey).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "API key not found or already revoked",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	revokedAt := time.Now().Add(gracePeriod)
+	if err := kc.DB.Model(&apiKey).Update("revoked_at", revokedAt).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to revoke API key",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.RevokeApiKeyResponse{
+		Message:   "API key revoked successfully",
+		RevokedAt: revokedAt,
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+func toApiKeyResponse(apiKey models.ApiKey) dtos.ApiKeyResponse {
+	response := dtos.ApiKeyResponse{
+		ApiKeyID:   apiKey.ID.String(),
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		ApiKey:     apiKey.Secret,
+		Scopes:     apiKey.Scopes,
+		LastUsedAt: apiKey.LastUsedAt,
+		ExpiresAt:  apiKey.ExpiresAt,
+		RevokedAt:  apiKey.RevokedAt,
+		Active:     apiKey.IsActive(time.Now()),
+		CreatedAt:  apiKey.CreatedAt,
+	}
+	if apiKey.CreatedByID != nil {
+		response.CreatedBy = apiKey.CreatedByID.String()
+	}
+	return response
+}
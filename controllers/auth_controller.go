@@ -1,15 +1,21 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/atqamz/kogase-backend/dtos"
 	"github.com/atqamz/kogase-backend/models"
 	"github.com/atqamz/kogase-backend/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var errRefreshTokenExpired = errors.New("refresh token expired")
+
 type AuthController struct {
 	DB *gorm.DB
 }
@@ -28,6 +34,7 @@ func NewAuthController(db *gorm.DB) *AuthController {
 // @Success 200 {object} dtos.LoginResponse
 // @Failure 400 {object} dtos.ErrorResponse
 // @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse "The account is locked"
 // @Failure 500 {object} dtos.ErrorResponse
 // @Router /auth/login [post]
 func (ac *AuthController) Login(c *gin.Context) {
@@ -59,7 +66,15 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	token, expiresAt, err := utils.CreateToken(user)
+	if user.LockedAt != nil {
+		response := dtos.ErrorResponse{
+			Message: "Account locked, an operator must reset its password",
+		}
+		c.JSON(http.StatusForbidden, response)
+		return
+	}
+
+	pair, err := issueTokenPair(ac.DB, c, user, uuid.Nil)
 	if err != nil {
 		response := dtos.ErrorResponse{
 			Message: "Failed to create token",
@@ -68,22 +83,109 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	authToken := models.AuthToken{
-		UserID:    user.ID,
-		Token:     token,
-		ExpiresAt: expiresAt,
+	resultResponse := dtos.LoginResponse{
+		Token:              pair.Token,
+		ExpiresAt:          pair.ExpiresAt,
+		RefreshToken:       pair.RefreshToken,
+		RefreshExpiresAt:   pair.RefreshExpiresAt,
+		MustChangePassword: user.MustChangePassword,
 	}
-	if err := ac.DB.Create(&authToken).Error; err != nil {
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// Refresh godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access and refresh token pair. Reusing a refresh token that was already exchanged revokes every token issued from the same login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param refresh body dtos.RefreshRequest true "Refresh token"
+// @Success 200 {object} dtos.RefreshResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /auth/refresh [post]
+func (ac *AuthController) Refresh(c *gin.Context) {
+	var request dtos.RefreshRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
 		response := dtos.ErrorResponse{
-			Message: "Failed to create token",
+			Message: "Invalid request",
 		}
-		c.JSON(http.StatusInternalServerError, response)
+		c.JSON(http.StatusBadRequest, response)
 		return
 	}
 
-	resultResponse := dtos.LoginResponse{
-		Token:     token,
-		ExpiresAt: expiresAt,
+	var resultResponse dtos.RefreshResponse
+	var reused bool
+	err := ac.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.AuthToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("refresh_token_hash = ?", models.HashSecret(request.RefreshToken)).
+			First(&current).Error; err != nil {
+			return err
+		}
+
+		// A refresh token that was already rotated or revoked is being replayed:
+		// assume it was stolen and shut down the whole login
+		if current.RotatedAt != nil || current.RevokedAt != nil {
+			reused = true
+			return models.RevokeAuthTokenFamily(tx, current.FamilyID)
+		}
+
+		if current.RefreshExpiresAt.Before(time.Now()) {
+			return errRefreshTokenExpired
+		}
+
+		var user models.User
+		if err := tx.First(&user, "id = ?", current.UserID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&current).Updates(map[string]interface{}{
+			"rotated_at": now,
+			"revoked_at": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		pair, err := issueTokenPair(tx, c, user, current.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		resultResponse = pair
+		return nil
+	})
+
+	if reused {
+		response := dtos.ErrorResponse{
+			Message: "Refresh token has already been used; all tokens from this login were revoked",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response := dtos.ErrorResponse{
+			Message: "Invalid refresh token",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+	if errors.Is(err, errRefreshTokenExpired) {
+		response := dtos.ErrorResponse{
+			Message: "Refresh token expired",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to refresh token",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
 	}
 
 	c.JSON(http.StatusOK, resultResponse)
@@ -118,11 +220,111 @@ func (ac *AuthController) Me(c *gin.Context) {
 	}
 
 	resultResponse := dtos.MeResponse{
-		ID:        user.ID.String(),
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:                 user.ID.String(),
+		Email:              user.Email,
+		Name:               user.Name,
+		MustChangePassword: user.MustChangePassword,
+		CreatedAt:          user.CreatedAt,
+		UpdatedAt:          user.UpdatedAt,
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Replace the current user's password and sign out their other logins. Users created with a password they didn't choose must call it before any other route.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param password body dtos.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} dtos.ChangePasswordResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /auth/password [post]
+func (ac *AuthController) ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+	currentFamilyID, _ := c.Get("auth_token_family_id")
+
+	var request dtos.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var user models.User
+	if err := ac.DB.First(&user, "id = ?", userID).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	if !utils.CheckPasswordHash(request.CurrentPassword, user.Password) {
+		response := dtos.ErrorResponse{
+			Message: "Current password is incorrect",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	if request.NewPassword == request.CurrentPassword {
+		response := dtos.ErrorResponse{
+			Message: "New password must differ from the current password",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(request.NewPassword)
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to hash password",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	var revoked int64
+	err = ac.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password":             hashedPassword,
+			"must_change_password": false,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Whoever knew the old password may still be signed in elsewhere
+		result := tx.Model(&models.AuthToken{}).
+			Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", user.ID, currentFamilyID).
+			Update("revoked_at", time.Now())
+		revoked = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to change password",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.ChangePasswordResponse{
+		Message: "Password changed successfully",
+		Revoked: revoked,
 	}
 
 	c.JSON(http.StatusOK, resultResponse)
@@ -130,7 +332,7 @@ func (ac *AuthController) Me(c *gin.Context) {
 
 // Logout godoc
 // @Summary User logout
-// @Description Invalidate the current auth token
+// @Description Invalidate the current access token and its refresh token
 // @Tags auth
 // @Produce json
 // @Security BearerAuth
@@ -138,8 +340,8 @@ func (ac *AuthController) Me(c *gin.Context) {
 // @Failure 401 {object} dtos.ErrorResponse
 // @Router /auth/logout [post]
 func (ac *AuthController) Logout(c *gin.Context) {
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
+	familyID, exists := c.Get("auth_token_family_id")
+	if !exists {
 		response := dtos.ErrorResponse{
 			Message: "Authorization header is required",
 		}
@@ -147,11 +349,13 @@ func (ac *AuthController) Logout(c *gin.Context) {
 		return
 	}
 
-	tokenString := authHeader[7:]
-
-	ac.DB.Model(&models.AuthToken{}).
-		Where("token = ?", tokenString).
-		Delete(&models.AuthToken{})
+	if err := models.RevokeAuthTokenFamily(ac.DB, familyID.(uuid.UUID)); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to log out",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
 
 	resultResponse := dtos.LogoutResponse{
 		Message: "Logged out successfully",
@@ -159,3 +363,249 @@ func (ac *AuthController) Logout(c *gin.Context) {
 
 	c.JSON(http.StatusOK, resultResponse)
 }
+
+// GetSessions godoc
+// @Summary Get login sessions
+// @Description List the current user's active logins with the device and IP address they were issued to
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dtos.GetAuthSessionsResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /auth/sessions [get]
+func (ac *AuthController) GetSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+	currentFamilyID, _ := c.Get("auth_token_family_id")
+
+	var tokens []models.AuthToken
+	if err := ac.DB.Model(&models.AuthToken{}).
+		Scopes(models.ActiveAuthTokens).
+		Where("user_id = ?", userID).
+		Order("last_used_at DESC").
+		Find(&tokens).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to retrieve sessions",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	var families []struct {
+		FamilyID   uuid.UUID
+		SignedInAt time.Time
+	}
+	if err := ac.DB.Model(&models.AuthToken{}).
+		Select("family_id, MIN(created_at) AS signed_in_at").
+		Where("user_id = ?", userID).
+		Group("family_id").
+		Scan(&families).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to retrieve sessions",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	signedInAt := make(map[uuid.UUID]time.Time, len(families))
+	for _, family := range families {
+		signedInAt[family.FamilyID] = family.SignedInAt
+	}
+
+	resultResponse := dtos.GetAuthSessionsResponse{
+		Sessions: make([]dtos.AuthSessionResponse, len(tokens)),
+	}
+	for i, token := range tokens {
+		resultResponse.Sessions[i] = dtos.AuthSessionResponse{
+			SessionID:       token.FamilyID.String(),
+			UserAgent:       token.UserAgent,
+			IpAddress:       token.IpAddress,
+			SignedInAt:      signedInAt[token.FamilyID],
+			LastRefreshedAt: token.CreatedAt,
+			LastUsedAt:      token.LastUsedAt,
+			ExpiresAt:       token.RefreshExpiresAt,
+			Current:         token.FamilyID == currentFamilyID,
+		}
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// RevokeSession godoc
+// @Summary Revoke a login session
+// @Description Revoke every token issued from one of the current user's logins
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} dtos.RevokeAuthSessionsResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /auth/sessions/{id} [delete]
+func (ac *AuthController) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	familyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid session ID",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	result := ac.DB.Model(&models.AuthToken{}).
+		Where("family_id = ? AND user_id = ? AND revoked_at IS NULL", familyID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to revoke session",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		response := dtos.ErrorResponse{
+			Message: "Session not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	resultResponse := dtos.RevokeAuthSessionsResponse{
+		Message: "Session revoked successfully",
+		Revoked: 1,
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// RevokeSessions godoc
+// @Summary Revoke login sessions
+// @Description Revoke the current user's other logins, optionally only those from a given IP address or user agent
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param ip_address query string false "Only revoke sessions issued to this IP address"
+// @Param user_agent query string false "Only revoke sessions issued to this user agent"
+// @Param include_current query bool false "Also revoke the session making this request"
+// @Success 200 {object} dtos.RevokeAuthSessionsResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /auth/sessions [delete]
+func (ac *AuthController) RevokeSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+	currentFamilyID, _ := c.Get("auth_token_family_id")
+
+	var request dtos.RevokeAuthSessionsRequestQuery
+	if err := c.ShouldBindQuery(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request query",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	// Select the families to revoke from their active tokens so the filters match the listed sessions
+	families := ac.DB.Model(&models.AuthToken{}).
+		Scopes(models.ActiveAuthTokens).
+		Select("family_id").
+		Where("user_id = ?", userID)
+	if request.IpAddress != "" {
+		families = families.Where("ip_address = ?", request.IpAddress)
+	}
+	if request.UserAgent != "" {
+		families = families.Where("user_agent = ?", request.UserAgent)
+	}
+	if !request.IncludeCurrent {
+		families = families.Where("family_id <> ?", currentFamilyID)
+	}
+
+	var familyIDs []uuid.UUID
+	if err := families.Pluck("family_id", &familyIDs).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to revoke sessions",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if len(familyIDs) > 0 {
+		if err := ac.DB.Model(&models.AuthToken{}).
+			Where("family_id IN ? AND revoked_at IS NULL", familyIDs).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			response := dtos.ErrorResponse{
+				Message: "Failed to revoke sessions",
+			}
+			c.JSON(http.StatusInternalServerError, response)
+			return
+		}
+	}
+
+	resultResponse := dtos.RevokeAuthSessionsResponse{
+		Message: "Sessions revoked successfully",
+		Revoked: len(familyIDs),
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// issueTokenPair creates and stores a new access and refresh token for the user.
+// Pass uuid.Nil as familyID to start a new login family.
+func issueTokenPair(tx *gorm.DB, c *gin.Context, user models.User, familyID uuid.UUID) (dtos.RefreshResponse, error) {
+	token, expiresAt, err := utils.CreateToken(user)
+	if err != nil {
+		return dtos.RefreshResponse{}, err
+	}
+
+	refreshToken, refreshExpiresAt, err := utils.CreateRefreshToken()
+	if err != nil {
+		return dtos.RefreshResponse{}, err
+	}
+
+	authToken := models.AuthToken{
+		UserID:           user.ID,
+		FamilyID:         familyID,
+		TokenHash:        models.HashSecret(token),
+		RefreshTokenHash: models.HashSecret(refreshToken),
+		ExpiresAt:        expiresAt,
+		RefreshExpiresAt: refreshExpiresAt,
+		UserAgent:        c.Request.UserAgent(),
+		IpAddress:        c.ClientIP(),
+	}
+	if err := tx.Create(&authToken).Error; err != nil {
+		return dtos.RefreshResponse{}, err
+	}
+
+	return dtos.RefreshResponse{
+		Token:            token,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
@@ -1,10 +1,12 @@
 package controllers
 
 import (
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/atqamz/kogase-backend/dtos"
+	"github.com/atqamz/kogase-backend/live"
 	"github.com/atqamz/kogase-backend/models"
 	"github.com/atqamz/kogase-backend/utils"
 	"github.com/gin-gonic/gin"
@@ -13,11 +15,13 @@ import (
 )
 
 type DeviceController struct {
-	DB *gorm.DB
+	DB   *gorm.DB
+	Live *live.Hub
+	Geo  utils.GeoResolver
 }
 
-func NewDeviceController(db *gorm.DB) *DeviceController {
-	return &DeviceController{DB: db}
+func NewDeviceController(db *gorm.DB, hub *live.Hub, geo utils.GeoResolver) *DeviceController {
+	return &DeviceController{DB: db, Live: hub, Geo: geo}
 }
 
 // CreateOrUpdateDevice godoc
@@ -53,12 +57,27 @@ func (dc *DeviceController) CreateOrUpdateDevice(c *gin.Context) {
 		return
 	}
 
+	var project models.Project
+	if err := dc.DB.Model(&models.Project{}).
+		Select("id, ip_privacy, ip_hash_salt").
+		Where("id = ?", projectID).
+		First(&project).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
 	var device models.Device
 	result := dc.DB.Model(&models.Device{}).
 		Where("project_id = ? AND identifier = ?", projectID, request.Identifier).
 		First(&device)
 
-	ipAddress := c.ClientIP()
+	// The location is resolved from the full address, which is then stored according
+	// to the project's privacy mode
+	clientIP := c.ClientIP()
+	ipAddress := project.AnonymizeIP(clientIP)
 
 	if result.Error == nil {
 		device.LastSeen = time.Now()
@@ -71,13 +90,16 @@ func (dc *DeviceController) CreateOrUpdateDevice(c *gin.Context) {
 			device.PlatformVersion = request.PlatformVersion
 		}
 
-		if device.IpAddress != ipAddress {
-			device.IpAddress = ipAddress
+		device.IpAddress = ipAddress
 
-			country, err := utils.GetCountryFromIP(ipAddress)
-			if err == nil && country != "Unknown" {
-				device.Country = country
-			}
+		// The stored address may not tell whether the device moved, so it is always located
+		location, err := dc.Geo.Lookup(clientIP)
+		if err != nil {
+			log.Printf("Failed to resolve location of device %s: %v", device.ID, err)
+		} else if location.Country != "" {
+			device.Country = location.Country
+			device.Region = location.Region
+			device.City = location.City
 		}
 
 		if err := dc.DB.Save(&device).Error; err != nil {
@@ -98,15 +120,17 @@ func (dc *DeviceController) CreateOrUpdateDevice(c *gin.Context) {
 			LastSeen:        device.LastSeen,
 			IpAddress:       device.IpAddress,
 			Country:         device.Country,
+			Region:          device.Region,
+			City:            device.City,
 		}
 
 		c.JSON(http.StatusOK, resultResponse)
 		return
 	}
 
-	country, err := utils.GetCountryFromIP(ipAddress)
+	location, err := dc.Geo.Lookup(clientIP)
 	if err != nil {
-		country = "Unknown"
+		log.Printf("Failed to resolve location of a new device: %v", err)
 	}
 
 	newDevice := models.Device{
@@ -118,7 +142,9 @@ func (dc *DeviceController) CreateOrUpdateDevice(c *gin.Context) {
 		FirstSeen:       time.Now(),
 		LastSeen:        time.Now(),
 		IpAddress:       ipAddress,
-		Country:         country,
+		Country:         location.Country,
+		Region:          location.Region,
+		City:            location.City,
 	}
 	if err := dc.DB.Create(&newDevice).Error; err != nil {
 		response := dtos.ErrorResponse{
@@ -131,12 +157,18 @@ func (dc *DeviceController) CreateOrUpdateDevice(c *gin.Context) {
 	event := models.Event{
 		ProjectID:  projectID.(uuid.UUID),
 		DeviceID:   newDevice.ID,
-		EventType:  "predefined",
+		EventType:  models.EventTypePredefined,
 		EventName:  "install",
 		Timestamp:  time.Now(),
 		ReceivedAt: time.Now(),
 	}
-	if err := dc.DB.Create(&event).Error; err != nil {
+	err = dc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+		return models.AddEventUsage(tx, event.ProjectID, models.UsageMonth(event.ReceivedAt), 1)
+	})
+	if err != nil {
 		response := dtos.ErrorResponse{
 			Message: "Failed to record install event",
 		}
@@ -144,6 +176,9 @@ func (dc *DeviceController) CreateOrUpdateDevice(c *gin.Context) {
 		return
 	}
 
+	dc.Live.RecordNewDevice(newDevice.ProjectID)
+	dc.Live.RecordEvents(event.ProjectID, event.EventName)
+
 	resultResponse := dtos.CreateOrUpdateDeviceResponse{
 		DeviceID:        newDevice.ID.String(),
 		Identifier:      newDevice.Identifier,
@@ -154,6 +189,8 @@ func (dc *DeviceController) CreateOrUpdateDevice(c *gin.Context) {
 		LastSeen:        newDevice.LastSeen,
 		IpAddress:       newDevice.IpAddress,
 		Country:         newDevice.Country,
+		Region:          newDevice.Region,
+		City:            newDevice.City,
 	}
 
 	c.JSON(http.StatusCreated, resultResponse)
@@ -165,6 +202,8 @@ func (dc *DeviceController) CreateOrUpdateDevice(c *gin.Context) {
 // @Tags devices
 // @Produce json
 // @Security BearerAuth
+// @Param project_id query string false "Filter by project ID"
+// @Param organization_id query string false "Filter by organization ID, ignored with project_id"
 // @Param platform query string false "Filter by platform"
 // @Param limit query int false "Limit results (default 20, max 100)"
 // @Param offset query int false "Offset results (default 0)"
@@ -204,6 +243,12 @@ func (dc *DeviceController) GetDevices(c *gin.Context) {
 
 	dbQuery := dc.DB.Model(&models.Device{})
 
+	if query.ProjectID != "" {
+		dbQuery = dbQuery.Where("devices.project_id = ?", query.ProjectID)
+	} else {
+		dbQuery = dbQuery.Where("devices.project_id IN (?)", memberProjectIDs(c, dc.DB, query.OrganizationID))
+	}
+
 	if query.Platform != "" {
 		dbQuery = dbQuery.Where("devices.platform = ?", query.Platform)
 	}
@@ -241,6 +286,8 @@ func (dc *DeviceController) GetDevices(c *gin.Context) {
 			LastSeen:        device.LastSeen,
 			IpAddress:       device.IpAddress,
 			Country:         device.Country,
+			Region:          device.Region,
+			City:            device.City,
 		}
 	}
 
@@ -306,6 +353,8 @@ func (dc *DeviceController) GetDevice(c *gin.Context) {
 		LastSeen:        device.LastSeen,
 		IpAddress:       device.IpAddress,
 		Country:         device.Country,
+		Region:          device.Region,
+		City:            device.City,
 	}
 
 	c.JSON(http.StatusOK, resultResponse)
@@ -1,36 +1,48 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/atqamz/kogase-backend/dtos"
+	"github.com/atqamz/kogase-backend/ingest"
+	"github.com/atqamz/kogase-backend/live"
 	"github.com/atqamz/kogase-backend/models"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type EventController struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Ingest *ingest.Pipeline
+	Live   *live.Hub
 }
 
-func NewEventController(db *gorm.DB) *EventController {
-	return &EventController{DB: db}
+func NewEventController(db *gorm.DB, pipeline *ingest.Pipeline, hub *live.Hub) *EventController {
+	return &EventController{DB: db, Ingest: pipeline, Live: hub}
 }
 
 // RecordEvent godoc
 // @Summary Record a single event
-// @Description Record a new telemetry event from a device
+// @Description Accept a new telemetry event from a device. The event is written asynchronously. Events with an event_id that was already received are not recorded again. Events that don't match the project's schema registry are rejected or reported as warnings, depending on its schema mode.
 // @Tags events
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param event body dtos.RecordEventRequest true "Event details"
-// @Success 201 {object} dtos.RecordEventResponse
+// @Success 200 {object} dtos.RecordEventResponse "Duplicate event, already recorded"
+// @Success 202 {object} dtos.RecordEventResponse
 // @Failure 400 {object} dtos.ErrorResponse
 // @Failure 401 {object} dtos.ErrorResponse
+// @Failure 422 {object} dtos.ErrorResponse "Event rejected by the project's schema registry"
+// @Failure 429 {object} dtos.ErrorResponse
 // @Failure 500 {object} dtos.ErrorResponse
+// @Failure 503 {object} dtos.ErrorResponse
 // @Router /events [post]
 func (tc *EventController) RecordEvent(c *gin.Context) {
 	projectID, exists := c.Get("project_id")
@@ -51,8 +63,17 @@ func (tc *EventController) RecordEvent(c *gin.Context) {
 		return
 	}
 
-	var device models.Device
-	if err := tc.DB.Where("identifier = ? AND project_id = ?", request.Identifier, projectID).First(&device).Error; err != nil {
+	devices, err := tc.Ingest.ResolveDevices(projectID.(uuid.UUID), []string{request.Identifier})
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to look up device",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	deviceID, found := devices[request.Identifier]
+	if !found {
 		response := dtos.ErrorResponse{
 			Message: "Device not found or doesn't belong to this project",
 		}
@@ -60,55 +81,96 @@ func (tc *EventController) RecordEvent(c *gin.Context) {
 		return
 	}
 
-	device.LastSeen = time.Now()
-	if err := tc.DB.Save(&device).Error; err != nil {
+	event, err := newEvent(projectID.(uuid.UUID), deviceID, request)
+	if err != nil {
 		response := dtos.ErrorResponse{
-			Message: "Failed to update device last seen",
+			Message: "Invalid event ID",
 		}
-		c.JSON(http.StatusInternalServerError, response)
+		c.JSON(http.StatusBadRequest, response)
 		return
 	}
 
-	timestamp := time.Now()
-	if request.Timestamp != nil {
-		timestamp = *request.Timestamp
-	}
-	event := models.Event{
-		ProjectID:  projectID.(uuid.UUID),
-		DeviceID:   device.ID,
-		EventType:  request.EventType,
-		EventName:  request.EventName,
-		Payloads:   request.Payloads,
-		Timestamp:  timestamp,
-		ReceivedAt: time.Now(),
+	if event.SessionID != nil {
+		sessions, err := tc.Ingest.ResolveSessions(event.ProjectID, []uuid.UUID{*event.SessionID})
+		if err != nil {
+			response := dtos.ErrorResponse{
+				Message: "Failed to look up session",
+			}
+			c.JSON(http.StatusInternalServerError, response)
+			return
+		}
+
+		if sessionDeviceID, found := sessions[*event.SessionID]; !found || sessionDeviceID != event.DeviceID {
+			response := dtos.ErrorResponse{
+				Message: "Session not found or doesn't belong to this device",
+			}
+			c.JSON(http.StatusBadRequest, response)
+			return
+		}
 	}
-	if err := tc.DB.Create(&event).Error; err != nil {
+
+	schemas, err := tc.Ingest.Schemas(projectID.(uuid.UUID))
+	if err != nil {
 		response := dtos.ErrorResponse{
-			Message: "Failed to record event",
+			Message: "Failed to load event schemas",
 		}
 		c.JSON(http.StatusInternalServerError, response)
 		return
 	}
 
+	violations := schemas.Validate(event)
+	if len(violations) > 0 && schemas.Mode == models.SchemaModeReject {
+		tc.Ingest.CountViolation(event.ProjectID, event.EventName, schemas.Mode)
+		response := dtos.ErrorResponse{
+			Message: "Event does not match its schema: " + strings.Join(violations, "; "),
+		}
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	duplicates, err := tc.Ingest.Enqueue(event)
+	if err != nil {
+		respondEnqueueError(c, err)
+		return
+	}
+
+	if duplicates[0] {
+		resultResponse := dtos.RecordEventResponse{
+			Message:   "Event already recorded",
+			Duplicate: true,
+		}
+		c.JSON(http.StatusOK, resultResponse)
+		return
+	}
+
+	if len(violations) > 0 {
+		tc.Ingest.CountViolation(event.ProjectID, event.EventName, schemas.Mode)
+	}
+	tc.Live.RecordEvents(event.ProjectID, event.EventName)
+
 	resultResponse := dtos.RecordEventResponse{
-		Message: "Event recorded successfully",
+		Message:  "Event accepted",
+		Warnings: violations,
 	}
 
-	c.JSON(http.StatusCreated, resultResponse)
+	c.JSON(http.StatusAccepted, resultResponse)
 }
 
 // RecordEvents godoc
 // @Summary Record multiple events
-// @Description Record a batch of telemetry events from a device
+// @Description Accept a batch of telemetry events from a device. Every event is validated on its own: valid events are written asynchronously while invalid ones are rejected with a reason code, see the per-index results. Events with an event_id that was already received are skipped and reported as duplicates. Events that don't match the project's schema registry are rejected or reported as warnings, depending on its schema mode.
 // @Tags events
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param events body dtos.RecordEventsRequest true "Batch of events"
-// @Success 201 {object} dtos.RecordEventsResponse
+// @Success 200 {object} dtos.RecordEventsResponse "No event was accepted"
+// @Success 202 {object} dtos.RecordEventsResponse
 // @Failure 400 {object} dtos.ErrorResponse
 // @Failure 401 {object} dtos.ErrorResponse
+// @Failure 429 {object} dtos.ErrorResponse
 // @Failure 500 {object} dtos.ErrorResponse
+// @Failure 503 {object} dtos.ErrorResponse
 // @Router /events/batch [post]
 func (tc *EventController) RecordEvents(c *gin.Context) {
 	projectID, exists := c.Get("project_id")
@@ -120,7 +182,10 @@ func (tc *EventController) RecordEvents(c *gin.Context) {
 		return
 	}
 
-	var request dtos.RecordEventsRequest
+	// Events are decoded one by one so a malformed event doesn't fail the whole batch
+	var request struct {
+		Events []json.RawMessage `json:"events" binding:"required"`
+	}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		response := dtos.ErrorResponse{
 			Message: "Invalid request",
@@ -129,58 +194,138 @@ func (tc *EventController) RecordEvents(c *gin.Context) {
 		return
 	}
 
-	err := tc.DB.Transaction(func(tx *gorm.DB) error {
-		devices := make(map[string]models.Device)
+	results := make([]dtos.RecordEventResult, len(request.Events))
+	eventReqs := make([]dtos.RecordEventRequest, len(request.Events))
+	identifiers := make([]string, 0, len(request.Events))
+	var sessionIDs []uuid.UUID
+	for i, rawEvent := range request.Events {
+		results[i] = dtos.RecordEventResult{Index: i}
 
-		for _, eventReq := range request.Events {
-			if device, exists := devices[eventReq.Identifier]; !exists {
-				if err := tx.Where("project_id = ? AND identifier = ?", projectID, eventReq.Identifier).First(&device).Error; err != nil {
-					return err
-				}
-
-				device.LastSeen = time.Now()
-				if err := tx.Save(&device).Error; err != nil {
-					return err
-				}
+		if err := json.Unmarshal(rawEvent, &eventReqs[i]); err != nil {
+			rejectEvent(&results[i], dtos.EventRejectMalformed, err)
+			continue
+		}
+		results[i].EventID = eventReqs[i].EventID
 
-				devices[eventReq.Identifier] = device
+		if eventReqs[i].EventID != "" {
+			if _, err := uuid.Parse(eventReqs[i].EventID); err != nil {
+				rejectEvent(&results[i], dtos.EventRejectInvalidID, err)
+				continue
 			}
+		}
 
-			timestamp := time.Now()
-			if eventReq.Timestamp != nil {
-				timestamp = *eventReq.Timestamp
-			}
-			event := models.Event{
-				ProjectID:  projectID.(uuid.UUID),
-				DeviceID:   devices[eventReq.Identifier].ID,
-				EventType:  eventReq.EventType,
-				EventName:  eventReq.EventName,
-				Payloads:   eventReq.Payloads,
-				Timestamp:  timestamp,
-				ReceivedAt: time.Now(),
-			}
-			if err := tx.Create(&event).Error; err != nil {
-				return err
-			}
+		// The binding rules also bound fields to their column sizes, an event that can't be
+		// stored must be rejected here rather than fail its batch in the pipeline
+		if err := binding.Validator.ValidateStruct(&eventReqs[i]); err != nil {
+			rejectEvent(&results[i], dtos.EventRejectInvalid, err)
+			continue
 		}
 
-		return nil
-	})
+		identifiers = append(identifiers, eventReqs[i].Identifier)
+		if eventReqs[i].SessionID != "" {
+			sessionIDs = append(sessionIDs, uuid.MustParse(eventReqs[i].SessionID))
+		}
+	}
 
+	devices, err := tc.Ingest.ResolveDevices(projectID.(uuid.UUID), identifiers)
 	if err != nil {
 		response := dtos.ErrorResponse{
-			Message: "Failed to record events",
+			Message: "Failed to look up devices",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	sessions, err := tc.Ingest.ResolveSessions(projectID.(uuid.UUID), sessionIDs)
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to look up sessions",
 		}
 		c.JSON(http.StatusInternalServerError, response)
 		return
 	}
 
+	schemas, err := tc.Ingest.Schemas(projectID.(uuid.UUID))
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to load event schemas",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	events := make([]models.Event, 0, len(request.Events))
+	indexes := make([]int, 0, len(request.Events))
+	for i, eventReq := range eventReqs {
+		if results[i].Status == dtos.EventStatusRejected {
+			continue
+		}
+
+		deviceID, found := devices[eventReq.Identifier]
+		if !found {
+			rejectEvent(&results[i], dtos.EventRejectUnknownDevice, errors.New("device not found or doesn't belong to this project"))
+			continue
+		}
+
+		event, err := newEvent(projectID.(uuid.UUID), deviceID, eventReq)
+		if err != nil {
+			rejectEvent(&results[i], dtos.EventRejectInvalidID, err)
+			continue
+		}
+
+		if event.SessionID != nil {
+			if sessionDeviceID, found := sessions[*event.SessionID]; !found || sessionDeviceID != event.DeviceID {
+				rejectEvent(&results[i], dtos.EventRejectUnknownSession, errors.New("session not found or doesn't belong to this device"))
+				continue
+			}
+		}
+
+		violations := schemas.Validate(event)
+		if len(violations) > 0 && schemas.Mode == models.SchemaModeReject {
+			tc.Ingest.CountViolation(event.ProjectID, event.EventName, schemas.Mode)
+			rejectEvent(&results[i], dtos.EventRejectSchema, errors.New(strings.Join(violations, "; ")))
+			continue
+		}
+		results[i].Warnings = violations
+
+		events = append(events, event)
+		indexes = append(indexes, i)
+	}
+
+	duplicates, err := tc.Ingest.Enqueue(events...)
+	if err != nil {
+		respondEnqueueError(c, err)
+		return
+	}
+
 	resultResponse := dtos.RecordEventsResponse{
-		Message: "Events recorded successfully",
-		Count:   len(request.Events),
+		Results: results,
+	}
+	accepted := make([]string, 0, len(indexes))
+	for i, index := range indexes {
+		if duplicates[i] {
+			results[index].Status = dtos.EventStatusDuplicate
+			resultResponse.Duplicates++
+		} else {
+			results[index].Status = dtos.EventStatusAccepted
+			resultResponse.Count++
+			accepted = append(accepted, events[i].EventName)
+			if len(results[index].Warnings) > 0 {
+				tc.Ingest.CountViolation(events[i].ProjectID, events[i].EventName, schemas.Mode)
+			}
+		}
+	}
+	tc.Live.RecordEvents(projectID.(uuid.UUID), accepted...)
+	resultResponse.Rejected = len(results) - resultResponse.Count - resultResponse.Duplicates
+
+	if resultResponse.Count == 0 {
+		resultResponse.Message = "No events accepted"
+		c.JSON(http.StatusOK, resultResponse)
+		return
 	}
 
-	c.JSON(http.StatusCreated, resultResponse)
+	resultResponse.Message = "Events accepted"
+	c.JSON(http.StatusAccepted, resultResponse)
 }
 
 // GetEvents godoc
@@ -190,6 +335,7 @@ func (tc *EventController) RecordEvents(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param project_id query string false "Filter by project ID"
+// @Param organization_id query string false "Filter by organization ID, ignored with project_id"
 // @Param event_type query string false "Filter by event type"
 // @Param event_name query string false "Filter by event name"
 // @Param from_date query string false "Filter by start date (RFC3339)"
@@ -223,6 +369,8 @@ func (tc *EventController) GetEvents(c *gin.Context) {
 	dbQuery := tc.DB.Model(&models.Event{})
 	if request.ProjectID != "" {
 		dbQuery = dbQuery.Where("project_id = ?", request.ProjectID)
+	} else {
+		dbQuery = dbQuery.Where("project_id IN (?)", memberProjectIDs(c, tc.DB, request.OrganizationID))
 	}
 	if request.FromDate != "" {
 		dbQuery = dbQuery.Where("timestamp >= ?", request.FromDate)
@@ -257,14 +405,7 @@ func (tc *EventController) GetEvents(c *gin.Context) {
 
 	eventsResponse := make([]dtos.GetEventResponse, len(events))
 	for i, event := range events {
-		eventsResponse[i] = dtos.GetEventResponse{
-			EventID:    event.ID.String(),
-			EventType:  event.EventType,
-			EventName:  event.EventName,
-			Payloads:   event.Payloads,
-			Timestamp:  event.Timestamp.Format(time.RFC3339),
-			ReceivedAt: event.ReceivedAt.Format(time.RFC3339),
-		}
+		eventsResponse[i] = toEventResponse(event)
 	}
 
 	resultResponse := dtos.GetEventsResponse{
@@ -297,17 +438,17 @@ func (tc *EventController) GetEvent(c *gin.Context) {
 		return
 	}
 
-	var request dtos.GetEventRequest
-	if err := c.ShouldBindQuery(&request); err != nil {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
 		response := dtos.ErrorResponse{
-			Message: "Invalid request",
+			Message: "Invalid event ID",
 		}
 		c.JSON(http.StatusBadRequest, response)
 		return
 	}
 
 	var event models.Event
-	if err := tc.DB.Where("id = ?", request.EventID).First(&event).Error; err != nil {
+	if err := tc.DB.Where("id = ?", eventID).First(&event).Error; err != nil {
 		response := dtos.ErrorResponse{
 			Message: "Event not found",
 		}
@@ -315,14 +456,90 @@ func (tc *EventController) GetEvent(c *gin.Context) {
 		return
 	}
 
-	resultResponse := dtos.GetEventResponse{
-		EventID:    event.ID.String(),
-		EventType:  event.EventType,
-		EventName:  event.EventName,
-		Payloads:   event.Payloads,
-		Timestamp:  event.Timestamp.Format(time.RFC3339),
-		ReceivedAt: event.ReceivedAt.Format(time.RFC3339),
-	}
+	resultResponse := toEventResponse(event)
 
 	c.JSON(http.StatusOK, resultResponse)
 }
+
+func newEvent(projectID uuid.UUID, deviceID uuid.UUID, request dtos.RecordEventRequest) (models.Event, error) {
+	timestamp := time.Now()
+	if request.Timestamp != nil {
+		timestamp = *request.Timestamp
+	}
+
+	event := models.Event{
+		ProjectID:  projectID,
+		DeviceID:   deviceID,
+		EventType:  request.EventType,
+		EventName:  request.EventName,
+		Payloads:   request.Payloads,
+		Timestamp:  timestamp,
+		ReceivedAt: time.Now(),
+	}
+
+	if request.EventID != "" {
+		clientEventID, err := uuid.Parse(request.EventID)
+		if err != nil {
+			return event, err
+		}
+		event.ClientEventID = &clientEventID
+	}
+
+	if request.SessionID != "" {
+		sessionID, err := uuid.Parse(request.SessionID)
+		if err != nil {
+			return event, err
+		}
+		event.SessionID = &sessionID
+	}
+
+	return event, nil
+}
+
+// respondEnqueueError maps ingestion pipeline errors to HTTP responses
+func respondEnqueueError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ingest.ErrQueueFull):
+		c.Header("Retry-After", "1")
+		response := dtos.ErrorResponse{
+			Message: "Too many events, retry later",
+		}
+		c.JSON(http.StatusTooManyRequests, response)
+	case errors.Is(err, ingest.ErrStopped):
+		response := dtos.ErrorResponse{
+			Message: "Server is shutting down, retry later",
+		}
+		c.JSON(http.StatusServiceUnavailable, response)
+	default:
+		response := dtos.ErrorResponse{
+			Message: "Failed to record events",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+	}
+}
+
+func toEventResponse(event models.Event) dtos.GetEventResponse {
+	return dtos.GetEventResponse{
+		EventID:       event.ID.String(),
+		ClientEventID: optionalUUIDString(event.ClientEventID),
+		SessionID:     optionalUUIDString(event.SessionID),
+		EventType:     event.EventType,
+		EventName:     event.EventName,
+		Payloads:      event.Payloads,
+		Timestamp:     event.Timestamp.Format(time.RFC3339),
+		ReceivedAt:    event.ReceivedAt.Format(time.RFC3339),
+	}
+}
+
+func optionalUUIDString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func rejectEvent(result *dtos.RecordEventResult, reason string, err error) {
+	result.Status = dtos.EventStatusRejected
+	result.Reason = reason
+	result.Error = err.Error()
+}
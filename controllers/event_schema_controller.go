@@ -0,0 +1,478 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/atqamz/kogase-backend/dtos"
+	"github.com/atqamz/kogase-backend/ingest"
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EventSchemaController struct {
+	DB     *gorm.DB
+	Ingest *ingest.Pipeline
+}
+
+func NewEventSchemaController(db *gorm.DB, pipeline *ingest.Pipeline) *EventSchemaController {
+	return &EventSchemaController{DB: db, Ingest: pipeline}
+}
+
+// GetEventSchemas godoc
+// @Summary Get event schemas
+// @Description Retrieve the schema mode and every registered event schema of a project
+// @Tags schemas
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} dtos.GetEventSchemasResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/schemas [get]
+func (sc *EventSchemaController) GetEventSchemas(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var project models.Project
+	if err := sc.DB.Model(&models.Project{}).
+		Where("id = ?", projectID).
+		First(&project).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var schemas []models.EventSchema
+	if err := sc.DB.Model(&models.EventSchema{}).
+		Where("project_id = ?", projectID).
+		Order("event_name ASC").
+		Find(&schemas).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to retrieve event schemas",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.GetEventSchemasResponse{
+		Mode:    string(project.SchemaMode),
+		Schemas: make([]dtos.EventSchemaResponse, len(schemas)),
+	}
+	for i, schema := range schemas {
+		resultResponse.Schemas[i] = toEventSchemaResponse(schema)
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// CreateEventSchema godoc
+// @Summary Register an event schema
+// @Description Declare an event of a project and the payload properties it is expected to carry
+// @Tags schemas
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param schema body dtos.CreateEventSchemaRequest true "Event schema"
+// @Success 201 {object} dtos.EventSchemaResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 409 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/schemas [post]
+func (sc *EventSchemaController) CreateEventSchema(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var request dtos.CreateEventSchemaRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if err := request.Schema.Check(); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid schema: " + err.Error(),
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var existing models.EventSchema
+	if err := sc.DB.Model(&models.EventSchema{}).
+		Where("project_id = ? AND event_name = ?", projectID, request.EventName).
+		First(&existing).Error; err == nil {
+		response := dtos.ErrorResponse{
+			Message: "A schema for this event is already registered",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+
+	schema := models.EventSchema{
+		ProjectID:   projectID.(uuid.UUID),
+		EventName:   request.EventName,
+		EventType:   request.EventType,
+		Description: request.Description,
+		Schema:      request.Schema,
+	}
+	if err := sc.DB.Create(&schema).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to create event schema",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	sc.Ingest.InvalidateSchemas(schema.ProjectID)
+
+	c.JSON(http.StatusCreated, toEventSchemaResponse(schema))
+}
+
+// UpdateEventSchema godoc
+// @Summary Replace an event schema
+// @Description Replace the definition of a registered event schema
+// @Tags schemas
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param schema_id path string true "Event schema ID"
+// @Param schema body dtos.UpdateEventSchemaRequest true "Event schema"
+// @Success 200 {object} dtos.EventSchemaResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 409 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/schemas/{schema_id} [put]
+func (sc *EventSchemaController) UpdateEventSchema(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	schema, ok := sc.findSchema(c, projectID)
+	if !ok {
+		return
+	}
+
+	var request dtos.UpdateEventSchemaRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if err := request.Schema.Check(); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid schema: " + err.Error(),
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var existing models.EventSchema
+	if err := sc.DB.Model(&models.EventSchema{}).
+		Where("project_id = ? AND event_name = ? AND id <> ?", projectID, request.EventName, schema.ID).
+		First(&existing).Error; err == nil {
+		response := dtos.ErrorResponse{
+			Message: "A schema for this event is already registered",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+
+	schema.EventName = request.EventName
+	schema.EventType = request.EventType
+	schema.Description = request.Description
+	schema.Schema = request.Schema
+	if err := sc.DB.Save(&schema).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to update event schema",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	sc.Ingest.InvalidateSchemas(schema.ProjectID)
+
+	c.JSON(http.StatusOK, toEventSchemaResponse(schema))
+}
+
+// DeleteEventSchema godoc
+// @Summary Delete an event schema
+// @Description Remove an event from the schema registry of a project
+// @Tags schemas
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param schema_id path string true "Event schema ID"
+// @Success 200 {object} dtos.DeleteEventSchemaResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/schemas/{schema_id} [delete]
+func (sc *EventSchemaController) DeleteEventSchema(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	schema, ok := sc.findSchema(c, projectID)
+	if !ok {
+		return
+	}
+
+	if err := sc.DB.Delete(&schema).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to delete event schema",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	sc.Ingest.InvalidateSchemas(schema.ProjectID)
+
+	resultResponse := dtos.DeleteEventSchemaResponse{
+		Message: "Event schema deleted successfully",
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// UpdateSchemaMode godoc
+// @Summary Set the schema mode
+// @Description Choose how incoming events are checked against the schema registry: off, warn (record and count invalid events) or reject (drop and count invalid events)
+// @Tags schemas
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param mode body dtos.UpdateSchemaModeRequest true "Schema mode"
+// @Success 200 {object} dtos.UpdateSchemaModeResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/schemas/mode [put]
+func (sc *EventSchemaController) UpdateSchemaMode(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var request dtos.UpdateSchemaModeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	mode := models.SchemaMode(request.Mode)
+	if err := sc.DB.Model(&models.Project{}).
+		Where("id = ?", projectID).
+		Update("schema_mode", mode).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to update schema mode",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	sc.Ingest.InvalidateSchemas(projectID.(uuid.UUID))
+
+	resultResponse := dtos.UpdateSchemaModeResponse{
+		Mode: string(mode),
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// GetSchemaStats godoc
+// @Summary Get schema validation counters
+// @Description Retrieve how many events failed schema validation, per event name and per day
+// @Tags schemas
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param from_date query string false "Filter by start date (RFC3339)"
+// @Param to_date query string false "Filter by end date (RFC3339)"
+// @Success 200 {object} dtos.GetSchemaStatsResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/schemas/stats [get]
+func (sc *EventSchemaController) GetSchemaStats(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var request dtos.GetSchemaStatsRequestQuery
+	if err := c.ShouldBindQuery(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request query",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var project models.Project
+	if err := sc.DB.Model(&models.Project{}).
+		Where("id = ?", projectID).
+		First(&project).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var stats []models.EventValidationStat
+	if err := sc.DB.Model(&models.EventValidationStat{}).
+		Scopes(validationStatRange(request.FromDate, request.ToDate)).
+		Where("project_id = ?", projectID).
+		Order("day ASC, event_name ASC").
+		Find(&stats).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to retrieve schema validation counters",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.GetSchemaStatsResponse{
+		Mode:   string(project.SchemaMode),
+		Events: []dtos.SchemaEventStatResponse{},
+		Days:   []dtos.SchemaDayStatResponse{},
+	}
+	eventIndexes := make(map[string]int)
+	dayIndexes := make(map[string]int)
+	for _, stat := range stats {
+		resultResponse.Warned += stat.Warned
+		resultResponse.Rejected += stat.Rejected
+
+		i, ok := eventIndexes[stat.EventName]
+		if !ok {
+			i = len(resultResponse.Events)
+			eventIndexes[stat.EventName] = i
+			resultResponse.Events = append(resultResponse.Events, dtos.SchemaEventStatResponse{EventName: stat.EventName})
+		}
+		resultResponse.Events[i].Warned += stat.Warned
+		resultResponse.Events[i].Rejected += stat.Rejected
+
+		day := stat.Day.Format(time.DateOnly)
+		i, ok = dayIndexes[day]
+		if !ok {
+			i = len(resultResponse.Days)
+			dayIndexes[day] = i
+			resultResponse.Days = append(resultResponse.Days, dtos.SchemaDayStatResponse{Day: day})
+		}
+		resultResponse.Days[i].Warned += stat.Warned
+		resultResponse.Days[i].Rejected += stat.Rejected
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// findSchema loads the event schema named by the schema_id parameter, answering the
+// request itself when it can't be found
+func (sc *EventSchemaController) findSchema(c *gin.Context, projectID interface{}) (models.EventSchema, bool) {
+	var schema models.EventSchema
+
+	schemaID, err := uuid.Parse(c.Param("schema_id"))
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid event schema ID",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return schema, false
+	}
+
+	if err := sc.DB.Model(&models.EventSchema{}).
+		Where("id = ? AND project_id = ?", schemaID, projectID).
+		First(&schema).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Event schema not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return schema, false
+	}
+
+	return schema, true
+}
+
+// validationStatRange limits schema validation counters to the days overlapping the range
+func validationStatRange(from time.Time, to time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if !from.IsZero() {
+			db = db.Where("day >= ?", from.UTC().Truncate(24*time.Hour))
+		}
+		if !to.IsZero() {
+			db = db.Where("day <= ?", to.UTC())
+		}
+		return db
+	}
+}
+
+func toEventSchemaResponse(schema models.EventSchema) dtos.EventSchemaResponse {
+	return dtos.EventSchemaResponse{
+		SchemaID:    schema.ID.String(),
+		EventName:   schema.EventName,
+		EventType:   schema.EventType,
+		Description: schema.Description,
+		Schema:      schema.Schema,
+		CreatedAt:   schema.CreatedAt,
+		UpdatedAt:   schema.UpdatedAt,
+	}
+}
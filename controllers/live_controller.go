@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/atqamz/kogase-backend/dtos"
+	"github.com/atqamz/kogase-backend/live"
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LiveController struct {
+	DB   *gorm.DB
+	Live *live.Hub
+}
+
+func NewLiveController(db *gorm.DB, hub *live.Hub) *LiveController {
+	return &LiveController{DB: db, Live: hub}
+}
+
+// CreateStreamToken godoc
+// @Summary Create a live stream token
+// @Description Issue a single-use token opening the project's live stream within a minute, for browsers whose EventSource can't send an Authorization header. Pass it as the token query parameter of the stream.
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 201 {object} dtos.CreateStreamTokenResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/live/token [post]
+func (lc *LiveController) CreateStreamToken(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	token, expiresAt, err := models.CreateStreamToken(lc.DB, userID.(uuid.UUID), projectID.(uuid.UUID))
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to create stream token",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response := dtos.CreateStreamTokenResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// StreamLiveMetrics godoc
+// @Summary Stream live metrics
+// @Description Stream a summary of the project's ingestion every second as Server-Sent Events named "metrics": events received by name, open sessions, new devices, for the last second and the rolling last minute. Browsers authenticate with a stream token instead of the Authorization header.
+// @Tags projects
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param token query string false "Single-use stream token from POST /projects/{id}/live/token"
+// @Success 200 {object} dtos.LiveMetricsResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/live [get]
+func (lc *LiveController) StreamLiveMetrics(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	snapshots, cancel, err := lc.Live.Subscribe(projectID.(uuid.UUID))
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to subscribe to live metrics",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case snapshot, ok := <-snapshots:
+			if !ok {
+				return
+			}
+			c.SSEvent("metrics", toLiveMetricsResponse(snapshot))
+			c.Writer.Flush()
+		}
+	}
+}
+
+func toLiveMetricsResponse(snapshot live.Snapshot) dtos.LiveMetricsResponse {
+	return dtos.LiveMetricsResponse{
+		ProjectID:    snapshot.ProjectID.String(),
+		Timestamp:    snapshot.Timestamp,
+		OpenSessions: snapshot.OpenSessions,
+		LastSecond:   toLiveWindowResponse(snapshot.LastSecond),
+		LastMinute:   toLiveWindowResponse(snapshot.LastMinute),
+	}
+}
+
+func toLiveWindowResponse(window live.Window) dtos.LiveWindowResponse {
+	return dtos.LiveWindowResponse{
+		Events:          window.Events,
+		EventsByName:    window.EventsByName,
+		NewDevices:      window.NewDevices,
+		SessionsStarted: window.SessionsStarted,
+		SessionsEnded:   window.SessionsEnded,
+	}
+}
@@ -0,0 +1,389 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/atqamz/kogase-backend/dtos"
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxUsageMonths bounds the months covered by a usage summary
+const maxUsageMonths = 36
+
+var errOrganizationHasProjects = errors.New("organization still has projects")
+
+type OrganizationController struct {
+	DB *gorm.DB
+}
+
+func NewOrganizationController(db *gorm.DB) *OrganizationController {
+	return &OrganizationController{DB: db}
+}
+
+// CreateOrganization godoc
+// @Summary Create an organization
+// @Description Create an organization owned by the current user
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param organization body dtos.CreateOrganizationRequest true "Organization details"
+// @Success 201 {object} dtos.OrganizationResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /organizations [post]
+func (oc *OrganizationController) CreateOrganization(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var request dtos.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	organization, err := models.CreateOrganization(oc.DB, request.Name, userID.(uuid.UUID))
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to create organization",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toOrganizationResponse(organization, models.OrganizationRoleOwner))
+}
+
+// GetOrganizations godoc
+// @Summary Get organizations
+// @Description Retrieve the organizations the current user belongs to and their role in each
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dtos.GetOrganizationsResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /organizations [get]
+func (oc *OrganizationController) GetOrganizations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var members []models.OrganizationMember
+	if err := oc.DB.Model(&models.OrganizationMember{}).
+		InnerJoins("Organization").
+		Where("organization_members.user_id = ?", userID).
+		Order("\"Organization\".name ASC").
+		Find(&members).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to retrieve organizations",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.GetOrganizationsResponse{
+		Organizations: make([]dtos.OrganizationResponse, len(members)),
+	}
+	for i, member := range members {
+		resultResponse.Organizations[i] = toOrganizationResponse(member.Organization, member.Role)
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// GetOrganization godoc
+// @Summary Get an organization
+// @Description Retrieve an organization the current user belongs to
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} dtos.OrganizationResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Router /organizations/{id} [get]
+func (oc *OrganizationController) GetOrganization(c *gin.Context) {
+	organization, ok := oc.findOrganization(c)
+	if !ok {
+		return
+	}
+
+	role, _ := c.Get("organization_role")
+
+	c.JSON(http.StatusOK, toOrganizationResponse(organization, role.(models.OrganizationRole)))
+}
+
+// UpdateOrganization godoc
+// @Summary Update an organization
+// @Description Rename an organization
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param organization body dtos.UpdateOrganizationRequest true "Updated organization details"
+// @Success 200 {object} dtos.OrganizationResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /organizations/{id} [patch]
+func (oc *OrganizationController) UpdateOrganization(c *gin.Context) {
+	var request dtos.UpdateOrganizationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	organization, ok := oc.findOrganization(c)
+	if !ok {
+		return
+	}
+
+	if err := oc.DB.Model(&organization).Update("name", request.Name).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to update organization",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	role, _ := c.Get("organization_role")
+
+	c.JSON(http.StatusOK, toOrganizationResponse(organization, role.(models.OrganizationRole)))
+}
+
+// DeleteOrganization godoc
+// @Summary Delete an organization
+// @Description Delete an organization and its memberships. Its projects must be deleted or transferred first.
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} dtos.DeleteOrganizationResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 409 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /organizations/{id} [delete]
+func (oc *OrganizationController) DeleteOrganization(c *gin.Context) {
+	organization, ok := oc.findOrganization(c)
+	if !ok {
+		return
+	}
+
+	err := oc.DB.Transaction(func(tx *gorm.DB) error {
+		var projects int64
+		if err := tx.Model(&models.Project{}).
+			Where("organization_id = ?", organization.ID).
+			Count(&projects).Error; err != nil {
+			return err
+		}
+		if projects > 0 {
+			return errOrganizationHasProjects
+		}
+
+		if err := tx.Unscoped().
+			Where("organization_id = ?", organization.ID).
+			Delete(&models.OrganizationMember{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&organization).Error
+	})
+	if errors.Is(err, errOrganizationHasProjects) {
+		response := dtos.ErrorResponse{
+			Message: "Delete or transfer the organization's projects first",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to delete organization",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.DeleteOrganizationResponse{
+		Message: "Organization deleted successfully",
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// GetUsage godoc
+// @Summary Get organization usage
+// @Description Summarize the events ingested per month for each project of the organization. Events count towards the organization owning the project when they were received, deleted projects included.
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param from query string false "First month (YYYY-MM), 11 months before to by default"
+// @Param to query string false "Last month (YYYY-MM), the current month by default"
+// @Success 200 {object} dtos.GetOrganizationUsageResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /organizations/{id}/usage [get]
+func (oc *OrganizationController) GetUsage(c *gin.Context) {
+	organizationID, exists := c.Get("organization_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Organization not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var request dtos.GetOrganizationUsageRequestQuery
+	if err := c.ShouldBindQuery(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request query",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	to := models.UsageMonth(time.Now())
+	if request.To != "" {
+		month, err := time.Parse("2006-01", request.To)
+		if err != nil {
+			response := dtos.ErrorResponse{
+				Message: "to must be a month formatted as YYYY-MM",
+			}
+			c.JSON(http.StatusBadRequest, response)
+			return
+		}
+		to = month
+	}
+
+	from := to.AddDate(0, -11, 0)
+	if request.From != "" {
+		month, err := time.Parse("2006-01", request.From)
+		if err != nil {
+			response := dtos.ErrorResponse{
+				Message: "from must be a month formatted as YYYY-MM",
+			}
+			c.JSON(http.StatusBadRequest, response)
+			return
+		}
+		from = month
+	}
+
+	if from.After(to) || !from.AddDate(0, maxUsageMonths, 0).After(to) {
+		response := dtos.ErrorResponse{
+			Message: "from must be before to, and at most 36 months apart",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var rows []struct {
+		ProjectID      uuid.UUID
+		Name           string
+		Month          time.Time
+		EventsIngested int64
+	}
+	if err := oc.DB.Model(&models.ProjectUsage{}).
+		Select("project_usages.project_id, projects.name, project_usages.month, project_usages.events_ingested").
+		Joins("JOIN projects ON projects.id = project_usages.project_id").
+		Where("project_usages.organization_id = ? AND project_usages.month BETWEEN ? AND ?", organizationID, from, to).
+		Order("project_usages.month ASC, projects.name ASC").
+		Scan(&rows).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to retrieve usage",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	// Every month of the range is listed, including those without usage
+	resultResponse := dtos.GetOrganizationUsageResponse{
+		OrganizationID: organizationID.(uuid.UUID).String(),
+	}
+	monthIndex := make(map[string]int)
+	for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+		monthIndex[month.Format("2006-01")] = len(resultResponse.Months)
+		resultResponse.Months = append(resultResponse.Months, dtos.MonthlyUsageResponse{
+			Month:    month.Format("2006-01"),
+			Projects: []dtos.ProjectUsageResponse{},
+		})
+	}
+	for _, row := range rows {
+		usage := &resultResponse.Months[monthIndex[row.Month.UTC().Format("2006-01")]]
+		usage.EventsIngested += row.EventsIngested
+		usage.Projects = append(usage.Projects, dtos.ProjectUsageResponse{
+			ProjectID:      row.ProjectID.String(),
+			Name:           row.Name,
+			EventsIngested: row.EventsIngested,
+		})
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// findOrganization loads the organization resolved by OrganizationRoleMiddleware and
+// writes an error response when it cannot be found
+func (oc *OrganizationController) findOrganization(c *gin.Context) (models.Organization, bool) {
+	var organization models.Organization
+
+	organizationID, exists := c.Get("organization_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Organization not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return organization, false
+	}
+
+	if err := oc.DB.First(&organization, "id = ?", organizationID).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Organization not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return organization, false
+	}
+
+	return organization, true
+}
+
+func toOrganizationResponse(organization models.Organization, role models.OrganizationRole) dtos.OrganizationResponse {
+	return dtos.OrganizationResponse{
+		OrganizationID: organization.ID.String(),
+		Name:           organization.Name,
+		Role:           string(role),
+		CreatedAt:      organization.CreatedAt,
+	}
+}
@@ -0,0 +1,379 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/atqamz/kogase-backend/dtos"
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errLastOrganizationOwner = errors.New("organization must keep at least one owner")
+
+type OrganizationMemberController struct {
+	DB *gorm.DB
+}
+
+func NewOrganizationMemberController(db *gorm.DB) *OrganizationMemberController {
+	return &OrganizationMemberController{DB: db}
+}
+
+// GetMembers godoc
+// @Summary Get organization members
+// @Description Retrieve the users that belong to an organization and their roles
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} dtos.GetOrganizationMembersResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /organizations/{id}/members [get]
+func (mc *OrganizationMemberController) GetMembers(c *gin.Context) {
+	organizationID, exists := c.Get("organization_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Organization not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var members []models.OrganizationMember
+	if err := mc.DB.Model(&models.OrganizationMember{}).
+		Preload("User").
+		Where("organization_id = ?", organizationID).
+		Order("created_at ASC").
+		Find(&members).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to retrieve members",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.GetOrganizationMembersResponse{
+		Members: make([]dtos.OrganizationMemberResponse, len(members)),
+	}
+	for i, member := range members {
+		resultResponse.Members[i] = toOrganizationMemberResponse(member)
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// AddMember godoc
+// @Summary Invite an organization member
+// @Description Add an existing user to an organization with the given role
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param member body dtos.AddOrganizationMemberRequest true "Member details"
+// @Success 201 {object} dtos.OrganizationMemberResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 409 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /organizations/{id}/members [post]
+func (mc *OrganizationMemberController) AddMember(c *gin.Context) {
+	organizationID, exists := c.Get("organization_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Organization not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var request dtos.AddOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	role := models.OrganizationRole(request.Role)
+	if !canAssignOrganizationRole(c, role) {
+		response := dtos.ErrorResponse{
+			Message: "Only owners can grant the owner role",
+		}
+		c.JSON(http.StatusForbidden, response)
+		return
+	}
+
+	var user models.User
+	if err := mc.DB.Model(&models.User{}).
+		Where("email = ?", request.Email).
+		First(&user).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var existing models.OrganizationMember
+	if err := mc.DB.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", organizationID, user.ID).
+		First(&existing).Error; err == nil {
+		response := dtos.ErrorResponse{
+			Message: "User is already a member of this organization",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+
+	member := models.OrganizationMember{
+		OrganizationID: organizationID.(uuid.UUID),
+		UserID:         user.ID,
+		Role:           role,
+		User:           user,
+	}
+	if err := mc.DB.Omit("User").Create(&member).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to add member",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toOrganizationMemberResponse(member))
+}
+
+// UpdateMember godoc
+// @Summary Change an organization member's role
+// @Description Change the role of a user within an organization
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param user_id path string true "User ID"
+// @Param member body dtos.UpdateOrganizationMemberRequest true "New role"
+// @Success 200 {object} dtos.OrganizationMemberResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 409 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /organizations/{id}/members/{user_id} [patch]
+func (mc *OrganizationMemberController) UpdateMember(c *gin.Context) {
+	organizationID, exists := c.Get("organization_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Organization not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var request dtos.UpdateOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	member, ok := mc.findMember(c, organizationID)
+	if !ok {
+		return
+	}
+
+	role := models.OrganizationRole(request.Role)
+	if !canAssignOrganizationRole(c, role) || !canAssignOrganizationRole(c, member.Role) {
+		response := dtos.ErrorResponse{
+			Message: "Only owners can grant or revoke the owner role",
+		}
+		c.JSON(http.StatusForbidden, response)
+		return
+	}
+
+	err := mc.DB.Transaction(func(tx *gorm.DB) error {
+		if member.Role == models.OrganizationRoleOwner && role != models.OrganizationRoleOwner {
+			if err := ensureAnotherOrganizationOwner(tx, member); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&member).Update("role", role).Error
+	})
+	if errors.Is(err, errLastOrganizationOwner) {
+		response := dtos.ErrorResponse{
+			Message: "An organization must keep at least one owner",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to update member",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	member.Role = role
+
+	c.JSON(http.StatusOK, toOrganizationMemberResponse(member))
+}
+
+// RemoveMember godoc
+// @Summary Remove an organization member
+// @Description Revoke a user's membership of an organization. Their project memberships are kept.
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param user_id path string true "User ID"
+// @Success 200 {object} dtos.RemoveOrganizationMemberResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 409 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /organizations/{id}/members/{user_id} [delete]
+func (mc *OrganizationMemberController) RemoveMember(c *gin.Context) {
+	organizationID, exists := c.Get("organization_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Organization not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	member, ok := mc.findMember(c, organizationID)
+	if !ok {
+		return
+	}
+
+	if !canAssignOrganizationRole(c, member.Role) {
+		response := dtos.ErrorResponse{
+			Message: "Only owners can remove other owners",
+		}
+		c.JSON(http.StatusForbidden, response)
+		return
+	}
+
+	err := mc.DB.Transaction(func(tx *gorm.DB) error {
+		if member.Role == models.OrganizationRoleOwner {
+			if err := ensureAnotherOrganizationOwner(tx, member); err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Delete(&member).Error
+	})
+	if errors.Is(err, errLastOrganizationOwner) {
+		response := dtos.ErrorResponse{
+			Message: "An organization must keep at least one owner",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to remove member",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.RemoveOrganizationMemberResponse{
+		Message: "Member removed successfully",
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// findMember loads the membership addressed by the user_id path parameter and writes
+// an error response when it cannot be found
+func (mc *OrganizationMemberController) findMember(c *gin.Context, organizationID interface{}) (models.OrganizationMember, bool) {
+	var member models.OrganizationMember
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid user ID",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return member, false
+	}
+
+	if err := mc.DB.Model(&models.OrganizationMember{}).
+		Preload("User").
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		First(&member).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Member not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return member, false
+	}
+
+	return member, true
+}
+
+// canAssignOrganizationRole reports whether the requesting user may grant or revoke the
+// given organization role. Only owners may manage the owner role.
+func canAssignOrganizationRole(c *gin.Context, role models.OrganizationRole) bool {
+	if role != models.OrganizationRoleOwner {
+		return true
+	}
+
+	callerRole, _ := c.Get("organization_role")
+	return callerRole == models.OrganizationRoleOwner
+}
+
+// ensureAnotherOrganizationOwner fails with errLastOrganizationOwner when member is the
+// only owner of its organization. It locks the organization's owner rows until tx ends, so
+// owners demoting or removing each other at the same time can't leave it without an owner.
+func ensureAnotherOrganizationOwner(tx *gorm.DB, member models.OrganizationMember) error {
+	var owners []uuid.UUID
+	if err := tx.Model(&models.OrganizationMember{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND role = ?", member.OrganizationID, models.OrganizationRoleOwner).
+		Order("id").
+		Pluck("id", &owners).Error; err != nil {
+		return err
+	}
+
+	others := 0
+	for _, id := range owners {
+		if id != member.ID {
+			others++
+		}
+	}
+
+	if others == 0 {
+		return errLastOrganizationOwner
+	}
+
+	return nil
+}
+
+func toOrganizationMemberResponse(member models.OrganizationMember) dtos.OrganizationMemberResponse {
+	return dtos.OrganizationMemberResponse{
+		UserID:    member.UserID.String(),
+		Email:     member.User.Email,
+		Name:      member.User.Name,
+		Role:      string(member.Role),
+		CreatedAt: member.CreatedAt,
+	}
+}
@@ -0,0 +1,411 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/atqamz/kogase-backend/dtos"
+	"github.com/atqamz/kogase-backend/ingest"
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PrivacyController struct {
+	DB     *gorm.DB
+	Ingest *ingest.Pipeline
+}
+
+func NewPrivacyController(db *gorm.DB, pipeline *ingest.Pipeline) *PrivacyController {
+	return &PrivacyController{DB: db, Ingest: pipeline}
+}
+
+// ExportDeviceData godoc
+// @Summary Export a player's data
+// @Description Export every device, session and event stored for a device identifier, deleted ones included, to answer a data subject access request. Requires an API key with the privacy scope.
+// @Tags privacy
+// @Produce json
+// @Produce application/zip
+// @Security ApiKeyAuth
+// @Param identifier query string true "Device identifier"
+// @Param format query string false "json (default) or zip"
+// @Success 200 {object} dtos.DeviceDataExport
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /privacy/export [get]
+func (pc *PrivacyController) ExportDeviceData(c *gin.Context) {
+	pc.exportDeviceData(c)
+}
+
+// ExportProjectDeviceData godoc
+// @Summary Export a player's data
+// @Description Export every device, session and event stored for a device identifier, deleted ones included, to answer a data subject access request
+// @Tags privacy
+// @Produce json
+// @Produce application/zip
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param identifier query string true "Device identifier"
+// @Param format query string false "json (default) or zip"
+// @Success 200 {object} dtos.DeviceDataExport
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/privacy/export [get]
+func (pc *PrivacyController) ExportProjectDeviceData(c *gin.Context) {
+	pc.exportDeviceData(c)
+}
+
+// EraseDeviceData godoc
+// @Summary Erase a player's data
+// @Description Permanently delete every device, session and event stored for a device identifier and record the erasure in the project's tamper-evident erasure log. Requires an API key with the privacy scope.
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body dtos.EraseDeviceDataRequest true "Device identifier"
+// @Success 200 {object} dtos.EraseDeviceDataResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /privacy/erase [post]
+func (pc *PrivacyController) EraseDeviceData(c *gin.Context) {
+	pc.eraseDeviceData(c)
+}
+
+// EraseProjectDeviceData godoc
+// @Summary Erase a player's data
+// @Description Permanently delete every device, session and event stored for a device identifier and record the erasure in the project's tamper-evident erasure log
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param request body dtos.EraseDeviceDataRequest true "Device identifier"
+// @Success 200 {object} dtos.EraseDeviceDataResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/privacy/erase [post]
+func (pc *PrivacyController) EraseProjectDeviceData(c *gin.Context) {
+	pc.eraseDeviceData(c)
+}
+
+// GetErasureLogs godoc
+// @Summary Get the erasure log
+// @Description Retrieve the project's erasure log and verify its hash chain
+// @Tags privacy
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} dtos.GetErasureLogsResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/privacy/erasures [get]
+func (pc *PrivacyController) GetErasureLogs(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var erasures []models.ErasureLog
+	if err := pc.DB.Model(&models.ErasureLog{}).
+		Where("project_id = ?", projectID).
+		Order("sequence ASC").
+		Find(&erasures).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to get erasure log",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	brokenAt := models.VerifyErasureLogs(erasures)
+	resultResponse := dtos.GetErasureLogsResponse{
+		Erasures:   make([]dtos.ErasureLogResponse, len(erasures)),
+		ChainValid: brokenAt == 0,
+		BrokenAt:   brokenAt,
+	}
+	for i, erasure := range erasures {
+		resultResponse.Erasures[i] = toErasureLogResponse(erasure)
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+func (pc *PrivacyController) exportDeviceData(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var request dtos.ExportDeviceDataRequestQuery
+	if err := c.ShouldBindQuery(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request query",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	export := dtos.DeviceDataExport{
+		Identifier: request.Identifier,
+		ProjectID:  projectID.(uuid.UUID).String(),
+		ExportedAt: time.Now().UTC(),
+	}
+
+	if err := pc.DB.Unscoped().
+		Where("project_id = ? AND identifier = ?", projectID, request.Identifier).
+		Order("created_at").
+		Find(&export.Devices).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to export devices",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if len(export.Devices) == 0 {
+		response := dtos.ErrorResponse{
+			Message: "No data stored for this identifier",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	deviceIDs := make([]uuid.UUID, len(export.Devices))
+	for i, device := range export.Devices {
+		deviceIDs[i] = device.ID
+	}
+
+	if err := pc.DB.Unscoped().
+		Where("device_id IN ?", deviceIDs).
+		Order("begin_at").
+		Find(&export.Sessions).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to export sessions",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if err := pc.DB.Unscoped().
+		Where("device_id IN ?", deviceIDs).
+		Order("timestamp").
+		Find(&export.Events).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to export events",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if request.Format != "zip" {
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	archive, err := zipDeviceDataExport(export)
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to create export archive",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	filename := fmt.Sprintf("device-data-%s.zip", export.ExportedAt.Format("20060102T150405Z"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+func (pc *PrivacyController) eraseDeviceData(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var request dtos.EraseDeviceDataRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request body",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var deviceIDs []uuid.UUID
+	if err := pc.DB.Unscoped().Model(&models.Device{}).
+		Where("project_id = ? AND identifier = ?", projectID, request.Identifier).
+		Pluck("id", &deviceIDs).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to look up devices",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if len(deviceIDs) == 0 {
+		response := dtos.ErrorResponse{
+			Message: "No data stored for this identifier",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var project models.Project
+	if err := pc.DB.Select("id, identifier_hash_salt").
+		Where("id = ?", projectID).
+		First(&project).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to load project",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	// Events still queued for the devices would otherwise be written after the erasure
+	pc.Ingest.ForgetDevice(projectID.(uuid.UUID), request.Identifier, deviceIDs)
+
+	erasure := models.ErasureLog{
+		ProjectID:      projectID.(uuid.UUID),
+		IdentifierHash: project.HashDeviceIdentifier(request.Identifier),
+		RequestedBy:    erasureRequester(c),
+	}
+	err := pc.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("device_id IN ?", deviceIDs).Delete(&models.Event{})
+		if result.Error != nil {
+			return result.Error
+		}
+		erasure.Events = result.RowsAffected
+
+		// Events that failed to be written are erased with the stored ones
+		result = tx.Where("device_id IN ?", deviceIDs).Delete(&models.EventDeadLetter{})
+		if result.Error != nil {
+			return result.Error
+		}
+		erasure.Events += result.RowsAffected
+
+		result = tx.Unscoped().Where("device_id IN ?", deviceIDs).Delete(&models.Session{})
+		if result.Error != nil {
+			return result.Error
+		}
+		erasure.Sessions = result.RowsAffected
+
+		result = tx.Unscoped().Where("id IN ?", deviceIDs).Delete(&models.Device{})
+		if result.Error != nil {
+			return result.Error
+		}
+		erasure.Devices = result.RowsAffected
+
+		return models.AppendErasureLog(tx, &erasure)
+	})
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to erase data",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.EraseDeviceDataResponse{
+		Message: "Data erased",
+		Erasure: toErasureLogResponse(erasure),
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// erasureRequester identifies who requested an erasure, an API key or a dashboard user
+func erasureRequester(c *gin.Context) string {
+	if apiKeyID, exists := c.Get("api_key_id"); exists {
+		return fmt.Sprintf("api_key:%v", apiKeyID)
+	}
+	userID, _ := c.Get("user_id")
+	return fmt.Sprintf("user:%v", userID)
+}
+
+// zipDeviceDataExport packs an export as one JSON file per table
+func zipDeviceDataExport(export dtos.DeviceDataExport) ([]byte, error) {
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{name: "export.json", content: gin.H{
+			"identifier":  export.Identifier,
+			"project_id":  export.ProjectID,
+			"exported_at": export.ExportedAt,
+		}},
+		{name: "devices.json", content: export.Devices},
+		{name: "sessions.json", content: export.Sessions},
+		{name: "events.json", content: export.Events},
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for _, file := range files {
+		writer, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func toErasureLogResponse(erasure models.ErasureLog) dtos.ErasureLogResponse {
+	return dtos.ErasureLogResponse{
+		ErasureID:      erasure.ID.String(),
+		Sequence:       erasure.Sequence,
+		IdentifierHash: erasure.IdentifierHash,
+		Devices:        erasure.Devices,
+		Sessions:       erasure.Sessions,
+		Events:         erasure.Events,
+		RequestedBy:    erasure.RequestedBy,
+		ErasedAt:       erasure.ErasedAt,
+		PreviousHash:   erasure.PreviousHash,
+		Hash:           erasure.Hash,
+	}
+}
@@ -1,49 +1,55 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/atqamz/kogase-backend/dtos"
+	"github.com/atqamz/kogase-backend/jobs"
 	"github.com/atqamz/kogase-backend/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errProjectInOrganization = errors.New("project already belongs to the organization")
+	errNotOrganizationAdmin  = errors.New("user is not an admin of the organization")
 )
 
 type ProjectController struct {
-	DB *gorm.DB
+	DB   *gorm.DB
+	Jobs *jobs.Scheduler
 }
 
-func NewProjectController(db *gorm.DB) *ProjectController {
-	return &ProjectController{DB: db}
+func NewProjectController(db *gorm.DB, scheduler *jobs.Scheduler) *ProjectController {
+	return &ProjectController{DB: db, Jobs: scheduler}
 }
 
 // CreateProject godoc
 // @Summary Create new project
-// @Description Create a new telemetry project
+// @Description Create a new telemetry project in one of the current user's organizations. Without organization_id the project goes to the user's only organization, or to a new one when they have none.
 // @Tags projects
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param project body dtos.CreateProjectRequest true "Project details"
 // @Success 201 {object} dtos.CreateProjectResponse
 // @Failure 400 {object} dtos.ErrorResponse
 // @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
 // @Failure 500 {object} dtos.ErrorResponse
 // @Router /projects [post]
 func (pc *ProjectController) CreateProject(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		var defaultAdmin models.User
-		if err := pc.DB.Model(&models.User{}).
-			Where("email = ?", "admin@kogase.io").
-			First(&defaultAdmin).Error; err != nil {
-			response := dtos.ErrorResponse{
-				Message: "User not found",
-			}
-			c.JSON(http.StatusUnauthorized, response)
-			return
+		response := dtos.ErrorResponse{
+			Message: "User not found",
 		}
-		userID = defaultAdmin.ID
+		c.JSON(http.StatusUnauthorized, response)
+		return
 	}
 
 	var request dtos.CreateProjectRequest
@@ -55,12 +61,72 @@ func (pc *ProjectController) CreateProject(c *gin.Context) {
 		return
 	}
 
+	var user models.User
+	if err := pc.DB.First(&user, "id = ?", userID).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var organizationID uuid.UUID
+	if request.OrganizationID != "" {
+		organizationID, _ = uuid.Parse(request.OrganizationID)
+
+		var member models.OrganizationMember
+		if err := pc.DB.Where("organization_id = ? AND user_id = ?", organizationID, user.ID).
+			First(&member).Error; err != nil {
+			response := dtos.ErrorResponse{
+				Message: "You are not a member of this organization",
+			}
+			c.JSON(http.StatusForbidden, response)
+			return
+		}
+	}
+
 	project := models.Project{
-		Name:    request.Name,
-		ApiKey:  uuid.New().String(),
-		OwnerID: userID.(uuid.UUID),
+		Name:           request.Name,
+		OwnerID:        user.ID,
+		OrganizationID: organizationID,
+	}
+	apiKey := models.ApiKey{
+		Name:        "Default",
+		CreatedByID: &project.OwnerID,
+	}
+	err := pc.DB.Transaction(func(tx *gorm.DB) error {
+		if project.OrganizationID == uuid.Nil {
+			organization, err := models.DefaultOrganization(tx, user)
+			if err != nil {
+				return err
+			}
+			project.OrganizationID = organization.ID
+		}
+
+		if err := tx.Create(&project).Error; err != nil {
+			return err
+		}
+
+		member := models.ProjectMember{
+			ProjectID: project.ID,
+			UserID:    project.OwnerID,
+			Role:      models.ProjectRoleOwner,
+		}
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+
+		apiKey.ProjectID = project.ID
+		return tx.Create(&apiKey).Error
+	})
+	if errors.Is(err, models.ErrOrganizationRequired) {
+		response := dtos.ErrorResponse{
+			Message: "organization_id is required when you belong to several organizations",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
 	}
-	if err := pc.DB.Create(&project).Error; err != nil {
+	if err != nil {
 		response := dtos.ErrorResponse{
 			Message: "Failed to create project",
 		}
@@ -69,9 +135,10 @@ func (pc *ProjectController) CreateProject(c *gin.Context) {
 	}
 
 	resultResponse := dtos.CreateProjectResponse{
-		ProjectID: project.ID.String(),
-		Name:      project.Name,
-		ApiKey:    project.ApiKey,
+		ProjectID:      project.ID.String(),
+		OrganizationID: project.OrganizationID.String(),
+		Name:           project.Name,
+		ApiKey:         apiKey.Secret,
 		Owner: dtos.OwnerDto{
 			ID:    project.Owner.ID.String(),
 			Email: project.Owner.Email,
@@ -88,12 +155,14 @@ func (pc *ProjectController) CreateProject(c *gin.Context) {
 // @Tags projects
 // @Produce json
 // @Security BearerAuth
+// @Param organization_id query string false "Filter by organization ID"
 // @Success 200 {object} dtos.GetProjectsResponse
+// @Failure 400 {object} dtos.ErrorResponse
 // @Failure 401 {object} dtos.ErrorResponse
 // @Failure 500 {object} dtos.ErrorResponse
 // @Router /projects [get]
 func (pc *ProjectController) GetProjects(c *gin.Context) {
-	_, exists := c.Get("user_id")
+	userID, exists := c.Get("user_id")
 	if !exists {
 		response := dtos.ErrorResponse{
 			Message: "User not found",
@@ -102,8 +171,18 @@ func (pc *ProjectController) GetProjects(c *gin.Context) {
 		return
 	}
 
+	var request dtos.GetProjectsRequestQuery
+	if err := c.ShouldBindQuery(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request query",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
 	var projects []models.Project
 	if err := pc.DB.Model(&models.Project{}).
+		Where("id IN (?)", memberProjectIDs(c, pc.DB, request.OrganizationID)).
 		Find(&projects).Error; err != nil {
 		response := dtos.ErrorResponse{
 			Message: "Failed to retrieve projects",
@@ -112,14 +191,49 @@ func (pc *ProjectController) GetProjects(c *gin.Context) {
 		return
 	}
 
+	var members []models.ProjectMember
+	if err := pc.DB.Model(&models.ProjectMember{}).
+		Where("user_id = ?", userID).
+		Find(&members).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to retrieve projects",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	var organizationMembers []models.OrganizationMember
+	if err := pc.DB.Model(&models.OrganizationMember{}).
+		Where("user_id = ?", userID).
+		Find(&organizationMembers).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to retrieve projects",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	roles := make(map[uuid.UUID]models.ProjectRole, len(members))
+	for _, member := range members {
+		roles[member.ProjectID] = member.Role
+	}
+
+	organizationRoles := make(map[uuid.UUID]models.ProjectRole, len(organizationMembers))
+	for _, member := range organizationMembers {
+		organizationRoles[member.OrganizationID] = member.Role.ProjectRole()
+	}
+
 	resultResponse := dtos.GetProjectsResponse{
 		Projects: make([]dtos.GetProjectResponse, len(projects)),
 	}
 	for i, project := range projects {
 		resultResponse.Projects[i] = dtos.GetProjectResponse{
-			ProjectID: project.ID.String(),
-			Name:      project.Name,
-			ApiKey:    project.ApiKey,
+			ProjectID:      project.ID.String(),
+			OrganizationID: project.OrganizationID.String(),
+			Name:           project.Name,
+			Role:           string(models.MaxProjectRole(roles[project.ID], organizationRoles[project.OrganizationID])),
+			SessionTimeout: project.SessionTimeout.String(),
+			IPPrivacy:      string(project.IPPrivacy),
 			Owner: dtos.OwnerDto{
 				ID:    project.Owner.ID.String(),
 				Email: project.Owner.Email,
@@ -174,11 +288,16 @@ func (pc *ProjectController) GetProject(c *gin.Context) {
 		return
 	}
 
+	role, _ := c.Get("project_role")
+
 	resultResponse := dtos.GetProjectResponseDetail{
 		GetProjectResponse: dtos.GetProjectResponse{
-			ProjectID: project.ID.String(),
-			Name:      project.Name,
-			ApiKey:    project.ApiKey,
+			ProjectID:      project.ID.String(),
+			OrganizationID: project.OrganizationID.String(),
+			Name:           project.Name,
+			Role:           string(role.(models.ProjectRole)),
+			SessionTimeout: project.SessionTimeout.String(),
+			IPPrivacy:      string(project.IPPrivacy),
 			Owner: dtos.OwnerDto{
 				ID:    project.Owner.ID.String(),
 				Email: project.Owner.Email,
@@ -251,6 +370,40 @@ func (pc *ProjectController) UpdateProject(c *gin.Context) {
 		project.Name = updateReq.Name
 	}
 
+	if updateReq.SessionTimeout != "" {
+		sessionTimeout, err := time.ParseDuration(updateReq.SessionTimeout)
+		if err != nil || sessionTimeout < models.MinSessionTimeout || sessionTimeout > models.MaxSessionTimeout {
+			response := dtos.ErrorResponse{
+				Message: "Session timeout must be between 1m and 24h",
+			}
+			c.JSON(http.StatusBadRequest, response)
+			return
+		}
+		project.SessionTimeout = sessionTimeout
+	}
+
+	if updateReq.EventRetentionDays != nil {
+		project.EventRetentionDays = *updateReq.EventRetentionDays
+	}
+
+	if updateReq.SessionRetentionDays != nil {
+		project.SessionRetentionDays = *updateReq.SessionRetentionDays
+	}
+
+	ipPrivacyChanged := false
+	if updateReq.IPPrivacy != "" {
+		ipPrivacy := models.IPPrivacyMode(updateReq.IPPrivacy)
+		if !ipPrivacy.IsValid() {
+			response := dtos.ErrorResponse{
+				Message: "IP privacy must be one of full, truncate, hash or none",
+			}
+			c.JSON(http.StatusBadRequest, response)
+			return
+		}
+		ipPrivacyChanged = ipPrivacy != project.IPPrivacy
+		project.IPPrivacy = ipPrivacy
+	}
+
 	if err := pc.DB.Save(&project).Error; err != nil {
 		response := dtos.ErrorResponse{
 			Message: "Failed to update project",
@@ -259,10 +412,16 @@ func (pc *ProjectController) UpdateProject(c *gin.Context) {
 		return
 	}
 
+	// Addresses stored under the previous mode are rewritten in the background
+	if ipPrivacyChanged {
+		pc.Jobs.Run("device IP rewrite", jobs.RewriteDeviceIPs(pc.DB, project.ID))
+	}
+
 	resultResponse := dtos.UpdateProjectResponse{
-		ProjectID: project.ID.String(),
-		Name:      project.Name,
-		ApiKey:    project.ApiKey,
+		ProjectID:      project.ID.String(),
+		Name:           project.Name,
+		SessionTimeout: project.SessionTimeout.String(),
+		IPPrivacy:      string(project.IPPrivacy),
 		Owner: dtos.OwnerDto{
 			ID:    project.Owner.ID.String(),
 			Email: project.Owner.Email,
@@ -273,6 +432,133 @@ func (pc *ProjectController) UpdateProject(c *gin.Context) {
 	c.JSON(http.StatusOK, resultResponse)
 }
 
+// RewriteDeviceIPs godoc
+// @Summary Rewrite stored device IP addresses
+// @Description Start a background job rewriting the stored IP addresses of the project's devices to its current IP privacy mode. It runs automatically when the mode changes; addresses that were truncated, hashed or dropped can't be restored.
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 202 {object} dtos.RewriteDeviceIPsResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Router /projects/{id}/devices/anonymize [post]
+func (pc *ProjectController) RewriteDeviceIPs(c *gin.Context) {
+	_, exists := c.Get("user_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid project ID",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var project models.Project
+	if err := pc.DB.Model(&models.Project{}).
+		Where("id = ?", projectID).
+		First(&project).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	pc.Jobs.Run("device IP rewrite", jobs.RewriteDeviceIPs(pc.DB, project.ID))
+
+	resultResponse := dtos.RewriteDeviceIPsResponse{
+		Message:   "Device IP addresses are being rewritten",
+		IPPrivacy: string(project.IPPrivacy),
+	}
+
+	c.JSON(http.StatusAccepted, resultResponse)
+}
+
+// GetDataRetention godoc
+// @Summary Get data retention
+// @Description Retrieve the project's data retention settings and what the background purge deleted. Settings are changed with PATCH /projects/{id}.
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} dtos.GetDataRetentionResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/data-retention [get]
+func (pc *ProjectController) GetDataRetention(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var project models.Project
+	if err := pc.DB.Model(&models.Project{}).
+		Where("id = ?", projectID).
+		First(&project).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	resultResponse := dtos.GetDataRetentionResponse{
+		EventRetentionDays:   project.EventRetentionDays,
+		SessionRetentionDays: project.SessionRetentionDays,
+	}
+
+	var lastRun models.PurgeRun
+	err := pc.DB.Model(&models.PurgeRun{}).
+		Where("project_id = ?", projectID).
+		Order("started_at DESC").
+		First(&lastRun).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		response := dtos.ErrorResponse{
+			Message: "Failed to get purge runs",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if err == nil {
+		resultResponse.LastRun = &dtos.PurgeRunResponse{
+			StartedAt:      lastRun.StartedAt,
+			FinishedAt:     lastRun.FinishedAt,
+			EventsPurged:   lastRun.EventsPurged,
+			SessionsPurged: lastRun.SessionsPurged,
+			DevicesPurged:  lastRun.DevicesPurged,
+			Error:          lastRun.Error,
+		}
+	}
+
+	if err := pc.DB.Model(&models.PurgeRun{}).
+		Select("COALESCE(SUM(events_purged), 0) AS events, COALESCE(SUM(sessions_purged), 0) AS sessions, COALESCE(SUM(devices_purged), 0) AS devices").
+		Where("project_id = ?", projectID).
+		Scan(&resultResponse.TotalPurged).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to get purge runs",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
 // DeleteProject godoc
 // @Summary Delete a project
 // @Description Delete a project by its ID
@@ -317,7 +603,13 @@ func (pc *ProjectController) DeleteProject(c *gin.Context) {
 		return
 	}
 
-	if err := pc.DB.Delete(&project).Error; err != nil {
+	err = pc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&project).Error
+	})
+	if err != nil {
 		response := dtos.ErrorResponse{
 			Message: "Failed to delete project",
 		}
@@ -332,21 +624,137 @@ func (pc *ProjectController) DeleteProject(c *gin.Context) {
 	c.JSON(http.StatusOK, resultResponse)
 }
 
+// TransferProject godoc
+// @Summary Transfer a project
+// @Description Move a project to another organization. Requires the owner role in the project and at least the admin role in both its current and the receiving organization. Project members keep their roles; usage from now on counts towards the receiving organization.
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param transfer body dtos.TransferProjectRequest true "Receiving organization"
+// @Success 200 {object} dtos.TransferProjectResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 409 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/transfer [post]
+func (pc *ProjectController) TransferProject(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var request dtos.TransferProjectRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var project models.Project
+	var member models.OrganizationMember
+	err := pc.DB.Transaction(func(tx *gorm.DB) error {
+		// Locking the project and the memberships the transfer relies on keeps them from
+		// changing until it is done
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&project, "id = ?", projectID).Error; err != nil {
+			return err
+		}
+
+		if project.OrganizationID.String() == request.OrganizationID {
+			return errProjectInOrganization
+		}
+
+		for _, organizationID := range []interface{}{project.OrganizationID, request.OrganizationID} {
+			var membership models.OrganizationMember
+			if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+				Where("organization_id = ? AND user_id = ?", organizationID, userID).
+				First(&membership).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errNotOrganizationAdmin
+				}
+				return err
+			}
+			if !membership.Role.AtLeast(models.OrganizationRoleAdmin) {
+				return errNotOrganizationAdmin
+			}
+			member = membership
+		}
+
+		return tx.Model(&project).Update("organization_id", member.OrganizationID).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+	if errors.Is(err, errProjectInOrganization) {
+		response := dtos.ErrorResponse{
+			Message: "Project already belongs to this organization",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+	if errors.Is(err, errNotOrganizationAdmin) {
+		response := dtos.ErrorResponse{
+			Message: "You must be an admin of both the current and the receiving organization",
+		}
+		c.JSON(http.StatusForbidden, response)
+		return
+	}
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to transfer project",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.TransferProjectResponse{
+		Message:        "Project transferred successfully",
+		ProjectID:      project.ID.String(),
+		OrganizationID: member.OrganizationID.String(),
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
 // RegenerateApiKey godoc
 // @Summary Regenerate API key
-// @Description Generate a new API key for a project
+// @Description Create a new API key for a project and retire the current keys after a grace period
 // @Tags projects
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Project ID"
-// @Success 200 {object} dtos.GetProjectResponse
+// @Param grace_period query string false "How long the current keys keep working (default 168h)"
+// @Success 200 {object} dtos.RegenerateApiKeyResponse
 // @Failure 400 {object} dtos.ErrorResponse
 // @Failure 401 {object} dtos.ErrorResponse
 // @Failure 404 {object} dtos.ErrorResponse
 // @Failure 500 {object} dtos.ErrorResponse
 // @Router /projects/{id}/apikey [post]
 func (pc *ProjectController) RegenerateApiKey(c *gin.Context) {
-	_, exists := c.Get("user_id")
+	userID, exists := c.Get("user_id")
 	if !exists {
 		response := dtos.ErrorResponse{
 			Message: "User not found",
@@ -365,6 +773,24 @@ func (pc *ProjectController) RegenerateApiKey(c *gin.Context) {
 		return
 	}
 
+	var query dtos.RegenerateApiKeyRequestQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request query",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	gracePeriod, err := time.ParseDuration(query.GracePeriod)
+	if err != nil || gracePeriod < 0 {
+		response := dtos.ErrorResponse{
+			Message: "Invalid grace period",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
 	var project models.Project
 	if err := pc.DB.Model(&models.Project{}).
 		Where("id = ?", projectID).
@@ -376,9 +802,29 @@ func (pc *ProjectController) RegenerateApiKey(c *gin.Context) {
 		return
 	}
 
-	project.ApiKey = uuid.New().String()
+	createdBy := userID.(uuid.UUID)
+	retiresAt := time.Now().Add(gracePeriod)
+	apiKey := models.ApiKey{
+		ProjectID:   project.ID,
+		Name:        "Rotated " + time.Now().Format("2006-01-02"),
+		CreatedByID: &createdBy,
+	}
 
-	if err := pc.DB.Save(&project).Error; err != nil {
+	var retired int64
+	err = pc.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ApiKey{}).
+			Scopes(models.ActiveApiKeys).
+			Where("project_id = ?", project.ID).
+			Where("(expires_at IS NULL OR expires_at > ?)", retiresAt).
+			Update("expires_at", retiresAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		retired = result.RowsAffected
+
+		return tx.Create(&apiKey).Error
+	})
+	if err != nil {
 		response := dtos.ErrorResponse{
 			Message: "Failed to regenerate API key",
 		}
@@ -386,15 +832,10 @@ func (pc *ProjectController) RegenerateApiKey(c *gin.Context) {
 		return
 	}
 
-	resultResponse := dtos.GetProjectResponse{
-		ProjectID: project.ID.String(),
-		Name:      project.Name,
-		ApiKey:    project.ApiKey,
-		Owner: dtos.OwnerDto{
-			ID:    project.Owner.ID.String(),
-			Email: project.Owner.Email,
-			Name:  project.Owner.Name,
-		},
+	resultResponse := dtos.RegenerateApiKeyResponse{
+		ApiKey:      toApiKeyResponse(apiKey),
+		RetiredKeys: int(retired),
+		RetiresAt:   retiresAt,
 	}
 
 	c.JSON(http.StatusOK, resultResponse)
@@ -433,9 +874,11 @@ func (pc *ProjectController) GetProjectWithApiKey(c *gin.Context) {
 
 	resultResponse := dtos.GetProjectResponseDetail{
 		GetProjectResponse: dtos.GetProjectResponse{
-			ProjectID: project.ID.String(),
-			Name:      project.Name,
-			ApiKey:    project.ApiKey,
+			ProjectID:      project.ID.String(),
+			OrganizationID: project.OrganizationID.String(),
+			Name:           project.Name,
+			SessionTimeout: project.SessionTimeout.String(),
+			IPPrivacy:      string(project.IPPrivacy),
 			Owner: dtos.OwnerDto{
 				ID:    project.Owner.ID.String(),
 				Email: project.Owner.Email,
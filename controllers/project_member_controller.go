@@ -0,0 +1,379 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/atqamz/kogase-backend/dtos"
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errLastOwner = errors.New("project must keep at least one owner")
+
+type ProjectMemberController struct {
+	DB *gorm.DB
+}
+
+func NewProjectMemberController(db *gorm.DB) *ProjectMemberController {
+	return &ProjectMemberController{DB: db}
+}
+
+// GetMembers godoc
+// @Summary Get project members
+// @Description Retrieve the users that belong to a project and their roles
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} dtos.GetProjectMembersResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/members [get]
+func (mc *ProjectMemberController) GetMembers(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var members []models.ProjectMember
+	if err := mc.DB.Model(&models.ProjectMember{}).
+		Preload("User").
+		Where("project_id = ?", projectID).
+		Order("created_at ASC").
+		Find(&members).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to retrieve members",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.GetProjectMembersResponse{
+		Members: make([]dtos.ProjectMemberResponse, len(members)),
+	}
+	for i, member := range members {
+		resultResponse.Members[i] = toProjectMemberResponse(member)
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// AddMember godoc
+// @Summary Invite a project member
+// @Description Add an existing user to a project with the given role
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param member body dtos.AddProjectMemberRequest true "Member details"
+// @Success 201 {object} dtos.ProjectMemberResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 409 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/members [post]
+func (mc *ProjectMemberController) AddMember(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var request dtos.AddProjectMemberRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	role := models.ProjectRole(request.Role)
+	if !canAssignRole(c, role) {
+		response := dtos.ErrorResponse{
+			Message: "Only owners can grant the owner role",
+		}
+		c.JSON(http.StatusForbidden, response)
+		return
+	}
+
+	var user models.User
+	if err := mc.DB.Model(&models.User{}).
+		Where("email = ?", request.Email).
+		First(&user).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var existing models.ProjectMember
+	if err := mc.DB.Model(&models.ProjectMember{}).
+		Where("project_id = ? AND user_id = ?", projectID, user.ID).
+		First(&existing).Error; err == nil {
+		response := dtos.ErrorResponse{
+			Message: "User is already a member of this project",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+
+	member := models.ProjectMember{
+		ProjectID: projectID.(uuid.UUID),
+		UserID:    user.ID,
+		Role:      role,
+		User:      user,
+	}
+	if err := mc.DB.Omit("User").Create(&member).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to add member",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toProjectMemberResponse(member))
+}
+
+// UpdateMember godoc
+// @Summary Change a member's role
+// @Description Change the role of a user within a project
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param user_id path string true "User ID"
+// @Param member body dtos.UpdateProjectMemberRequest true "New role"
+// @Success 200 {object} dtos.ProjectMemberResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 409 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/members/{user_id} [patch]
+func (mc *ProjectMemberController) UpdateMember(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	var request dtos.UpdateProjectMemberRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	member, ok := mc.findMember(c, projectID)
+	if !ok {
+		return
+	}
+
+	role := models.ProjectRole(request.Role)
+	if !canAssignRole(c, role) || !canAssignRole(c, member.Role) {
+		response := dtos.ErrorResponse{
+			Message: "Only owners can grant or revoke the owner role",
+		}
+		c.JSON(http.StatusForbidden, response)
+		return
+	}
+
+	err := mc.DB.Transaction(func(tx *gorm.DB) error {
+		if member.Role == models.ProjectRoleOwner && role != models.ProjectRoleOwner {
+			if err := ensureAnotherOwner(tx, member); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&member).Update("role", role).Error
+	})
+	if errors.Is(err, errLastOwner) {
+		response := dtos.ErrorResponse{
+			Message: "A project must keep at least one owner",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to update member",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	member.Role = role
+
+	c.JSON(http.StatusOK, toProjectMemberResponse(member))
+}
+
+// RemoveMember godoc
+// @Summary Remove a project member
+// @Description Revoke a user's access to a project
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param user_id path string true "User ID"
+// @Success 200 {object} dtos.RemoveProjectMemberResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 409 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /projects/{id}/members/{user_id} [delete]
+func (mc *ProjectMemberController) RemoveMember(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	member, ok := mc.findMember(c, projectID)
+	if !ok {
+		return
+	}
+
+	if !canAssignRole(c, member.Role) {
+		response := dtos.ErrorResponse{
+			Message: "Only owners can remove other owners",
+		}
+		c.JSON(http.StatusForbidden, response)
+		return
+	}
+
+	err := mc.DB.Transaction(func(tx *gorm.DB) error {
+		if member.Role == models.ProjectRoleOwner {
+			if err := ensureAnotherOwner(tx, member); err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Delete(&member).Error
+	})
+	if errors.Is(err, errLastOwner) {
+		response := dtos.ErrorResponse{
+			Message: "A project must keep at least one owner",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to remove member",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.RemoveProjectMemberResponse{
+		Message: "Member removed successfully",
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// findMember loads the membership addressed by the user_id path parameter and writes
+// an error response when it cannot be found
+func (mc *ProjectMemberController) findMember(c *gin.Context, projectID interface{}) (models.ProjectMember, bool) {
+	var member models.ProjectMember
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid user ID",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return member, false
+	}
+
+	if err := mc.DB.Model(&models.ProjectMember{}).
+		Preload("User").
+		Where("project_id = ? AND user_id = ?", projectID, userID).
+		First(&member).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Member not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return member, false
+	}
+
+	return member, true
+}
+
+// canAssignRole reports whether the requesting user may grant or revoke the given role.
+// Only owners may manage the owner role.
+func canAssignRole(c *gin.Context, role models.ProjectRole) bool {
+	if role != models.ProjectRoleOwner {
+		return true
+	}
+
+	callerRole, _ := c.Get("project_role")
+	return callerRole == models.ProjectRoleOwner
+}
+
+// ensureAnotherOwner fails with errLastOwner when member is the only owner of its project.
+// It locks the project's owner rows until tx ends, so owners demoting or removing each
+// other at the same time are serialized and can't leave the project without an owner.
+func ensureAnotherOwner(tx *gorm.DB, member models.ProjectMember) error {
+	var owners []uuid.UUID
+	if err := tx.Model(&models.ProjectMember{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("project_id = ? AND role = ?", member.ProjectID, models.ProjectRoleOwner).
+		Order("id").
+		Pluck("id", &owners).Error; err != nil {
+		return err
+	}
+
+	others := 0
+	for _, id := range owners {
+		if id != member.ID {
+			others++
+		}
+	}
+
+	if others == 0 {
+		return errLastOwner
+	}
+
+	return nil
+}
+
+func toProjectMemberResponse(member models.ProjectMember) dtos.ProjectMemberResponse {
+	return dtos.ProjectMemberResponse{
+		UserID:    member.UserID.String(),
+		Email:     member.User.Email,
+		Name:      member.User.Name,
+		Role:      string(member.Role),
+		CreatedAt: member.CreatedAt,
+	}
+}
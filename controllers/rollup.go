@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+	"gorm.io/gorm"
+)
+
+// rollupDays returns the whole UTC days within [from, to] that the daily rollups of the
+// projects cover, as a half-open range. A zero from or to leaves that side open. Raw rows
+// only need to be read outside of the returned range.
+func rollupDays(db *gorm.DB, projectIDs interface{}, from time.Time, to time.Time) (time.Time, time.Time, bool) {
+	start, end, ok, err := models.RollupCoverage(db, projectIDs)
+	if err != nil || !ok {
+		return time.Time{}, time.Time{}, false
+	}
+
+	if !from.IsZero() {
+		day := from.UTC().Truncate(24 * time.Hour)
+		if day.Before(from) {
+			day = day.AddDate(0, 0, 1)
+		}
+		if day.After(start) {
+			start = day
+		}
+	}
+	if !to.IsZero() {
+		// Timestamps are stored to the microsecond, a day lies within to when its last microsecond does
+		day := to.Add(time.Microsecond).UTC().Truncate(24 * time.Hour)
+		if day.Before(end) {
+			end = day
+		}
+	}
+
+	return start, end, start.Before(end)
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memberProjectIDs returns a subquery selecting the projects the authenticated user may
+// access with the minimum role required by the current route. Unless organizationID is
+// empty, only the projects of that organization are selected.
+func memberProjectIDs(c *gin.Context, db *gorm.DB, organizationID string) *gorm.DB {
+	userID, _ := c.Get("user_id")
+	id, _ := userID.(uuid.UUID)
+
+	minRole := models.ProjectRoleViewer
+	if role, exists := c.Get("project_min_role"); exists {
+		minRole = role.(models.ProjectRole)
+	}
+
+	projectIDs := models.MemberProjectIDs(db, id, minRole)
+	if organizationID != "" {
+		projectIDs = projectIDs.Where("organization_id = ?", organizationID)
+	}
+	return projectIDs
+}
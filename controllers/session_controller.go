@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/atqamz/kogase-backend/dtos"
+	"github.com/atqamz/kogase-backend/live"
 	"github.com/atqamz/kogase-backend/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -12,11 +13,12 @@ import (
 )
 
 type SessionController struct {
-	DB *gorm.DB
+	DB   *gorm.DB
+	Live *live.Hub
 }
 
-func NewSessionController(db *gorm.DB) *SessionController {
-	return &SessionController{DB: db}
+func NewSessionController(db *gorm.DB, hub *live.Hub) *SessionController {
+	return &SessionController{DB: db, Live: hub}
 }
 
 // BeginSession godoc
@@ -56,7 +58,7 @@ func (sc *SessionController) BeginSession(c *gin.Context) {
 		Identifier: request.Identifier,
 	}
 	if err := sc.DB.Model(&models.Device{}).
-		Where("identifier = ?", request.Identifier).
+		Where("project_id = ? AND identifier = ?", projectID, request.Identifier).
 		First(&device).Error; err != nil {
 		response := dtos.ErrorResponse{
 			Message: "Device not found",
@@ -77,6 +79,8 @@ func (sc *SessionController) BeginSession(c *gin.Context) {
 		return
 	}
 
+	sc.Live.RecordSessionStarted(session.ProjectID)
+
 	resultResponse := dtos.BeginSessionResponse{
 		SessionID: session.ID.String(),
 	}
@@ -96,6 +100,7 @@ func (sc *SessionController) BeginSession(c *gin.Context) {
 // @Failure 400 {object} dtos.ErrorResponse
 // @Failure 401 {object} dtos.ErrorResponse
 // @Failure 404 {object} dtos.ErrorResponse
+// @Failure 409 {object} dtos.ErrorResponse "The session already ended"
 // @Failure 500 {object} dtos.ErrorResponse
 // @Router /sessions/end [post]
 func (sc *SessionController) EndSession(c *gin.Context) {
@@ -131,9 +136,17 @@ func (sc *SessionController) EndSession(c *gin.Context) {
 		return
 	}
 
-	session.EndAt = time.Now()
-	session.Duration = time.Since(session.BeginAt)
-	if err := sc.DB.Save(&session).Error; err != nil {
+	// Only an open session can end: one the sweeper ended already keeps the end it was
+	// given, at its last sign of life, instead of counting the idle time as playtime
+	now := time.Now()
+	result := sc.DB.Model(&session).
+		Scopes(models.OpenSessions).
+		Updates(map[string]interface{}{
+			"end_at":           now,
+			"last_activity_at": now,
+			"duration":         now.Sub(session.BeginAt),
+		})
+	if result.Error != nil {
 		response := dtos.ErrorResponse{
 			Message: "Failed to end session",
 		}
@@ -141,6 +154,16 @@ func (sc *SessionController) EndSession(c *gin.Context) {
 		return
 	}
 
+	if result.RowsAffected == 0 {
+		response := dtos.ErrorResponse{
+			Message: "Session already ended",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+
+	sc.Live.RecordSessionsEnded(session.ProjectID, 1)
+
 	resultResponse := dtos.EndSessionResponse{
 		Message: "Session ended",
 	}
@@ -148,6 +171,80 @@ func (sc *SessionController) EndSession(c *gin.Context) {
 	c.JSON(http.StatusOK, resultResponse)
 }
 
+// Heartbeat godoc
+// @Summary Send a session heartbeat
+// @Description Mark an open session as still active. Sessions without a heartbeat or event for longer than the project's session timeout are ended automatically.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param session body dtos.SessionHeartbeatRequest true "Session to keep alive"
+// @Success 200 {object} dtos.SessionHeartbeatResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 409 {object} dtos.ErrorResponse "The session already ended, begin a new one"
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /sessions/heartbeat [post]
+func (sc *SessionController) Heartbeat(c *gin.Context) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "Project not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var request dtos.SessionHeartbeatRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request body",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	now := time.Now()
+	result := sc.DB.Model(&models.Session{}).
+		Scopes(models.OpenSessions).
+		Where("id = ? AND project_id = ?", request.SessionID, projectID).
+		Update("last_activity_at", now)
+	if result.Error != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to record heartbeat",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		var session models.Session
+		if err := sc.DB.Model(&models.Session{}).
+			Where("id = ? AND project_id = ?", request.SessionID, projectID).
+			First(&session).Error; err != nil {
+			response := dtos.ErrorResponse{
+				Message: "Session not found",
+			}
+			c.JSON(http.StatusNotFound, response)
+			return
+		}
+
+		response := dtos.ErrorResponse{
+			Message: "Session already ended",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+
+	resultResponse := dtos.SessionHeartbeatResponse{
+		Message:        "Heartbeat recorded",
+		LastActivityAt: now,
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
 // GetSessions godoc
 // @Summary Get sessions
 // @Description Retrieve all sessions with filtering and pagination
@@ -155,6 +252,7 @@ func (sc *SessionController) EndSession(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param project_id query string false "Filter by project ID"
+// @Param organization_id query string false "Filter by organization ID, ignored with project_id"
 // @Param from_date query string false "Filter by start date (RFC3339)"
 // @Param to_date query string false "Filter by end date (RFC3339)"
 // @Param limit query int false "Limit results"
@@ -186,6 +284,8 @@ func (sc *SessionController) GetSessions(c *gin.Context) {
 	query := sc.DB.Model(&models.Session{})
 	if request.ProjectID != "" {
 		query = query.Where("project_id = ?", request.ProjectID)
+	} else {
+		query = query.Where("project_id IN (?)", memberProjectIDs(c, sc.DB, request.OrganizationID))
 	}
 	if !request.FromDate.IsZero() {
 		fromDate := time.Date(
@@ -232,14 +332,35 @@ func (sc *SessionController) GetSessions(c *gin.Context) {
 		return
 	}
 
+	sessionIDs := make([]uuid.UUID, len(sessions))
+	for i, session := range sessions {
+		sessionIDs[i] = session.ID
+	}
+
+	var eventCounts []struct {
+		SessionID uuid.UUID
+		Count     int64
+	}
+	if err := sc.DB.Model(&models.Event{}).
+		Select("session_id, COUNT(*) AS count").
+		Where("session_id IN ?", sessionIDs).
+		Group("session_id").
+		Scan(&eventCounts).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to count session events",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	countBySession := make(map[uuid.UUID]int64, len(eventCounts))
+	for _, eventCount := range eventCounts {
+		countBySession[eventCount.SessionID] = eventCount.Count
+	}
+
 	var sessionsDTO []dtos.GetSessionResponse
 	for _, session := range sessions {
-		sessionsDTO = append(sessionsDTO, dtos.GetSessionResponse{
-			SessionID: session.ID.String(),
-			BeginAt:   session.BeginAt,
-			EndAt:     session.EndAt,
-			Duration:  session.Duration.Nanoseconds(),
-		})
+		sessionsDTO = append(sessionsDTO, toSessionResponse(session, countBySession[session.ID]))
 	}
 
 	resultResponse := dtos.GetSessionsResponse{
@@ -287,12 +408,108 @@ func (sc *SessionController) GetSession(c *gin.Context) {
 		return
 	}
 
-	resultResponse := dtos.GetSessionResponse{
-		SessionID: session.ID.String(),
-		BeginAt:   session.BeginAt,
-		EndAt:     session.EndAt,
-		Duration:  session.Duration.Nanoseconds(),
+	var eventCount int64
+	if err := sc.DB.Model(&models.Event{}).
+		Where("session_id = ?", session.ID).
+		Count(&eventCount).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to count session events",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := toSessionResponse(session, eventCount)
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// GetSessionEvents godoc
+// @Summary Get session events
+// @Description Retrieve the events recorded during a session in chronological order
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Param limit query int false "Limit results"
+// @Param offset query int false "Offset results"
+// @Success 200 {object} dtos.GetEventsResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /sessions/{id}/events [get]
+func (sc *SessionController) GetSessionEvents(c *gin.Context) {
+	_, exists := c.Get("user_id")
+	if !exists {
+		response := dtos.ErrorResponse{
+			Message: "User not found",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var request dtos.GetSessionEventsRequestQuery
+	if err := c.ShouldBindQuery(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request query",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var session models.Session
+	if err := sc.DB.Model(&models.Session{}).
+		Where("id = ?", c.Param("id")).
+		First(&session).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Session not found",
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	dbQuery := sc.DB.Model(&models.Event{}).
+		Where("project_id = ? AND session_id = ?", session.ProjectID, session.ID)
+
+	var totalCount int64
+	if err := dbQuery.Count(&totalCount).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to count events",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	var events []models.Event
+	if err := dbQuery.Order("timestamp ASC").Limit(request.Limit).Offset(request.Offset).Find(&events).Error; err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to get events",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	eventsResponse := make([]dtos.GetEventResponse, len(events))
+	for i, event := range events {
+		eventsResponse[i] = toEventResponse(event)
+	}
+
+	resultResponse := dtos.GetEventsResponse{
+		Events: eventsResponse,
+		Total:  int(totalCount),
 	}
 
 	c.JSON(http.StatusOK, resultResponse)
 }
+
+func toSessionResponse(session models.Session, eventCount int64) dtos.GetSessionResponse {
+	return dtos.GetSessionResponse{
+		SessionID:      session.ID.String(),
+		BeginAt:        session.BeginAt,
+		EndAt:          session.EndAt,
+		Duration:       session.Duration.Nanoseconds(),
+		LastActivityAt: session.LastActivityAt,
+		EventCount:     eventCount,
+	}
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/atqamz/kogase-backend/dtos"
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/atqamz/kogase-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SetupController struct {
+	DB *gorm.DB
+}
+
+func NewSetupController(db *gorm.DB) *SetupController {
+	return &SetupController{DB: db}
+}
+
+// GetSetup godoc
+// @Summary Get setup status
+// @Description Report whether the installation still needs its first admin
+// @Tags setup
+// @Produce json
+// @Success 200 {object} dtos.GetSetupResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /setup [get]
+func (sc *SetupController) GetSetup(c *gin.Context) {
+	required, err := models.SetupRequired(sc.DB)
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to retrieve setup status",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.GetSetupResponse{
+		SetupRequired: required,
+	}
+
+	c.JSON(http.StatusOK, resultResponse)
+}
+
+// CompleteSetup godoc
+// @Summary Create the first admin
+// @Description Create the first user of the installation with the one-time setup token printed at startup
+// @Tags setup
+// @Accept json
+// @Produce json
+// @Param setup body dtos.CompleteSetupRequest true "Setup token and admin details"
+// @Success 201 {object} dtos.CompleteSetupResponse
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
+// @Failure 409 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /setup [post]
+func (sc *SetupController) CompleteSetup(c *gin.Context) {
+	var request dtos.CompleteSetupRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Invalid request",
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(request.Password)
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to hash password",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	admin := models.User{
+		Email:    request.Email,
+		Password: hashedPassword,
+		Name:     request.Name,
+	}
+	err = models.CompleteSetup(sc.DB, request.Token, &admin)
+	if errors.Is(err, models.ErrSetupCompleted) {
+		response := dtos.ErrorResponse{
+			Message: "Setup has already been completed",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+	if errors.Is(err, models.ErrInvalidSetupToken) {
+		response := dtos.ErrorResponse{
+			Message: "Invalid or expired setup token",
+		}
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to complete setup",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	resultResponse := dtos.CompleteSetupResponse{
+		UserID: admin.ID.String(),
+		Email:  admin.Email,
+		Name:   admin.Name,
+	}
+
+	c.JSON(http.StatusCreated, resultResponse)
+}
@@ -20,13 +20,15 @@ func NewUserController(db *gorm.DB) *UserController {
 
 // CreateUser godoc
 // @Summary Create a new user
-// @Description Register a new user account
+// @Description Create a user account. Only logged-in users can, unless ALLOW_REGISTRATION is set; users created by someone else must change their password on first login. Refused until the first admin was created through the initial setup.
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param user body dtos.CreateUserRequest true "User details"
 // @Success 201 {object} dtos.CreateUserResponse
 // @Failure 400 {object} dtos.ErrorResponse
+// @Failure 401 {object} dtos.ErrorResponse
 // @Failure 409 {object} dtos.ErrorResponse
 // @Failure 500 {object} dtos.ErrorResponse
 // @Router /users [post]
@@ -40,6 +42,23 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 		return
 	}
 
+	// The first account is the admin, it must come from the setup flow
+	setupRequired, err := models.SetupRequired(uc.DB)
+	if err != nil {
+		response := dtos.ErrorResponse{
+			Message: "Failed to create user",
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if setupRequired {
+		response := dtos.ErrorResponse{
+			Message: "Complete the initial setup first",
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+
 	var existingUser models.User
 	if err := uc.DB.Model(&models.User{}).
 		Where("email = ?", userReq.Email).
@@ -60,10 +79,13 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 		return
 	}
 
+	// The password was chosen by whoever created the account
+	_, createdByUser := c.Get("user_id")
 	user := models.User{
-		Email:    userReq.Email,
-		Password: hashedPassword,
-		Name:     userReq.Name,
+		Email:              userReq.Email,
+		Password:           hashedPassword,
+		Name:               userReq.Name,
+		MustChangePassword: createdByUser,
 	}
 	if err := uc.DB.Create(&user).Error; err != nil {
 		response := dtos.ErrorResponse{
@@ -31,7 +31,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve analytics data for a project including DAU, MAU, total duration, and total installs",
+                "description": "Retrieve analytics data for a project including DAU, MAU (distinct devices with a session in the last day and 30 days), total duration, total installs and events that failed schema validation",
                 "produces": [
                     "application/json"
                 ],
@@ -46,6 +46,12 @@ const docTemplate = `{
                         "name": "project_id",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "Filter by organization ID, ignored with project_id",
+                        "name": "organization_id",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Filter by start date (RFC3339)",
@@ -77,13 +83,24 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/auth/login": {
+        "/analytics/aggregate": {
             "post": {
-                "description": "Authenticate user with email and password",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Compute a count, unique devices, or the sum, average, minimum, maximum or a percentile of a numeric payload property over events, grouped by up to two dimensions (platform, country, region, city, app_version or payload.\u003ckey\u003e) and optionally bucketed by time",
                 "consumes": [
                     "application/json"
                 ],
@@ -91,17 +108,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "analytics"
                 ],
-                "summary": "User login",
+                "summary": "Aggregate events",
                 "parameters": [
                     {
-                        "description": "Login credentials",
-                        "name": "user",
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Aggregation definition",
+                        "name": "aggregation",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.LoginRequest"
+                            "$ref": "#/definitions/dtos.AggregateEventsRequest"
                         }
                     }
                 ],
@@ -109,7 +133,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.LoginResponse"
+                            "$ref": "#/definitions/dtos.AggregateEventsResponse"
                         }
                     },
                     "400": {
@@ -124,6 +148,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -133,26 +163,53 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/logout": {
+        "/analytics/funnel": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Invalidate the current auth token",
+                "description": "Count the devices that fire an ordered sequence of events, optionally within a conversion window measured from the first step, with conversion rates and the median time between steps",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "analytics"
+                ],
+                "summary": "Get funnel conversion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Funnel definition",
+                        "name": "funnel",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetFunnelRequest"
+                        }
+                    }
                 ],
-                "summary": "User logout",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.LogoutResponse"
+                            "$ref": "#/definitions/dtos.GetFunnelResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
                     "401": {
@@ -160,30 +217,99 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/auth/me": {
+        "/analytics/retention": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns information about the currently authenticated user",
+                "description": "Group devices into daily cohorts by their first seen day or install event, and report the share of each cohort that starts a session on each following day (D1/D7/D30 retention)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "analytics"
+                ],
+                "summary": "Get retention cohorts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "First cohort day (RFC3339), defaults to 30 days before to_date",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last cohort day (RFC3339), defaults to today",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cohort devices by first_seen (default) or install",
+                        "name": "cohort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last day after the cohort day to report (1-90, default 30)",
+                        "name": "max_day",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by device platform",
+                        "name": "platform",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by device country",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by app version",
+                        "name": "app_version",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get current user info",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.MeResponse"
+                            "$ref": "#/definitions/dtos.GetRetentionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
                     "401": {
@@ -191,42 +317,66 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/devices": {
+        "/analytics/timeseries": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve a list of all devices with pagination",
+                "description": "Retrieve distinct active devices, new devices, sessions and average session length per hour, day, week or month",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "analytics"
                 ],
-                "summary": "Get all devices",
+                "summary": "Get activity time series",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Filter by platform",
-                        "name": "platform",
+                        "description": "Filter by project ID",
+                        "name": "project_id",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Limit results (default 20, max 100)",
-                        "name": "limit",
+                        "type": "string",
+                        "description": "Filter by organization ID, ignored with project_id",
+                        "name": "organization_id",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Offset results (default 0)",
-                        "name": "offset",
+                        "type": "string",
+                        "description": "Filter by start date (RFC3339), defaults to 30 days before to_date",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by end date (RFC3339), defaults to now",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket size: hour, day (default), week or month",
+                        "name": "granularity",
                         "in": "query"
                     }
                 ],
@@ -234,7 +384,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.GetDevicesResponse"
+                            "$ref": "#/definitions/dtos.GetTimeseriesResponse"
                         }
                     },
                     "400": {
@@ -249,6 +399,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -256,14 +412,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/auth/login": {
             "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Create a new device or update an existing one",
+                "description": "Authenticate user with email and password",
                 "consumes": [
                     "application/json"
                 ],
@@ -271,17 +424,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "auth"
                 ],
-                "summary": "Create or update device",
+                "summary": "User login",
                 "parameters": [
                     {
-                        "description": "Device details",
-                        "name": "device",
+                        "description": "Login credentials",
+                        "name": "user",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.CreateOrUpdateDeviceRequest"
+                            "$ref": "#/definitions/dtos.LoginRequest"
                         }
                     }
                 ],
@@ -289,13 +442,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.CreateOrUpdateDeviceResponse"
-                        }
-                    },
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/dtos.CreateOrUpdateDeviceResponse"
+                            "$ref": "#/definitions/dtos.LoginResponse"
                         }
                     },
                     "400": {
@@ -310,6 +457,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "The account is locked",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -319,41 +472,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/devices/{id}": {
-            "get": {
+        "/auth/logout": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve a specific device by its ID",
+                "description": "Invalidate the current access token and its refresh token",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
-                ],
-                "summary": "Get a device by ID",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Device ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "auth"
                 ],
+                "summary": "User logout",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.GetDeviceResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/dtos.ErrorResponse"
+                            "$ref": "#/definitions/dtos.LogoutResponse"
                         }
                     },
                     "401": {
@@ -361,49 +499,30 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/dtos.ErrorResponse"
-                        }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/auth/me": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a device by its ID",
+                "description": "Returns information about the currently authenticated user",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
-                ],
-                "summary": "Delete a device",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Device ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "auth"
                 ],
+                "summary": "Get current user info",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.DeleteDeviceResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/dtos.ErrorResponse"
+                            "$ref": "#/definitions/dtos.MeResponse"
                         }
                     },
                     "401": {
@@ -411,86 +530,44 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/dtos.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/dtos.ErrorResponse"
-                        }
                     }
                 }
             }
         },
-        "/events": {
-            "get": {
+        "/auth/password": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve events with filtering and pagination",
+                "description": "Replace the current user's password and sign out their other logins. Users created with a password they didn't choose must call it before any other route.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "auth"
                 ],
-                "summary": "Get events",
+                "summary": "Change password",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Filter by project ID",
-                        "name": "project_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by event type",
-                        "name": "event_type",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by event name",
-                        "name": "event_name",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by start date (RFC3339)",
-                        "name": "from_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by end date (RFC3339)",
-                        "name": "to_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Limit results",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Offset results",
-                        "name": "offset",
-                        "in": "query"
+                        "description": "Current and new password",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ChangePasswordRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.GetEventsResponse"
+                            "$ref": "#/definitions/dtos.ChangePasswordResponse"
                         }
                     },
                     "400": {
@@ -512,14 +589,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/auth/refresh": {
             "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Record a new telemetry event from a device",
+                "description": "Exchange a refresh token for a new access and refresh token pair. Reusing a refresh token that was already exchanged revokes every token issued from the same login.",
                 "consumes": [
                     "application/json"
                 ],
@@ -527,25 +601,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "auth"
                 ],
-                "summary": "Record a single event",
+                "summary": "Refresh access token",
                 "parameters": [
                     {
-                        "description": "Event details",
-                        "name": "event",
+                        "description": "Refresh token",
+                        "name": "refresh",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.RecordEventRequest"
+                            "$ref": "#/definitions/dtos.RefreshRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.RecordEventResponse"
+                            "$ref": "#/definitions/dtos.RefreshResponse"
                         }
                     },
                     "400": {
@@ -569,46 +643,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/events/batch": {
-            "post": {
+        "/auth/sessions": {
+            "get": {
                 "security": [
                     {
-                        "ApiKeyAuth": []
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Record a batch of telemetry events from a device",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List the current user's active logins with the device and IP address they were issued to",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
-                ],
-                "summary": "Record multiple events",
-                "parameters": [
-                    {
-                        "description": "Batch of events",
-                        "name": "events",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dtos.RecordEventsRequest"
-                        }
-                    }
+                    "auth"
                 ],
+                "summary": "Get login sessions",
                 "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/dtos.RecordEventsResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.ErrorResponse"
+                            "$ref": "#/definitions/dtos.GetAuthSessionsResponse"
                         }
                     },
                     "401": {
@@ -624,37 +678,46 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/events/{id}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve a specific event by its ID",
+                "description": "Revoke the current user's other logins, optionally only those from a given IP address or user agent",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "auth"
                 ],
-                "summary": "Get event by ID",
+                "summary": "Revoke login sessions",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Event ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Only revoke sessions issued to this IP address",
+                        "name": "ip_address",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only revoke sessions issued to this user agent",
+                        "name": "user_agent",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also revoke the session making this request",
+                        "name": "include_current",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.GetEventResponse"
+                            "$ref": "#/definitions/dtos.RevokeAuthSessionsResponse"
                         }
                     },
                     "400": {
@@ -669,8 +732,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
@@ -678,46 +741,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
-            "get": {
-                "description": "Check if the API is running",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "health"
-                ],
-                "summary": "Health check endpoint",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/dtos.HealthResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/health/apikey": {
-            "get": {
+        "/auth/sessions/{id}": {
+            "delete": {
                 "security": [
                     {
-                        "ApiKeyAuth": []
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Check if the API is running and verify API key",
+                "description": "Revoke every token issued from one of the current user's logins",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "auth"
+                ],
+                "summary": "Revoke a login session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Health check endpoint with API key authentication",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.HealthResponse"
+                            "$ref": "#/definitions/dtos.RevokeAuthSessionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
                     "401": {
@@ -725,30 +783,80 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/projects": {
+        "/devices": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve a list of all projects",
+                "description": "Retrieve a list of all devices with pagination",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "devices"
+                ],
+                "summary": "Get all devices",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by project ID",
+                        "name": "project_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by organization ID, ignored with project_id",
+                        "name": "organization_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by platform",
+                        "name": "platform",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit results (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset results (default 0)",
+                        "name": "offset",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all projects",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.GetProjectsResponse"
+                            "$ref": "#/definitions/dtos.GetDevicesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
                     "401": {
@@ -766,33 +874,44 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a new telemetry project",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a new device or update an existing one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "devices"
                 ],
-                "summary": "Create new project",
+                "summary": "Create or update device",
                 "parameters": [
                     {
-                        "description": "Project details",
-                        "name": "project",
+                        "description": "Device details",
+                        "name": "device",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.CreateProjectRequest"
+                            "$ref": "#/definitions/dtos.CreateOrUpdateDeviceRequest"
                         }
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CreateOrUpdateDeviceResponse"
+                        }
+                    },
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/dtos.CreateProjectResponse"
+                            "$ref": "#/definitions/dtos.CreateOrUpdateDeviceResponse"
                         }
                     },
                     "400": {
@@ -816,26 +935,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/projects/apikey": {
+        "/devices/{id}": {
             "get": {
                 "security": [
                     {
-                        "ApiKeyAuth": []
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Get project details using an API key for authentication",
+                "description": "Retrieve a specific device by its ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "devices"
+                ],
+                "summary": "Get a device by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get project with API key",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.GetProjectResponseDetail"
+                            "$ref": "#/definitions/dtos.GetDeviceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
                     "401": {
@@ -851,27 +985,25 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/projects/{id}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve a specific project by its ID",
+                "description": "Delete a device by its ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "devices"
                 ],
-                "summary": "Get a project by ID",
+                "summary": "Delete a device",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Project ID",
+                        "description": "Device ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -881,7 +1013,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.GetProjectResponseDetail"
+                            "$ref": "#/definitions/dtos.DeleteDeviceResponse"
                         }
                     },
                     "400": {
@@ -901,37 +1033,86 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/events": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a project by its ID",
+                "description": "Retrieve events with filtering and pagination",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "events"
                 ],
-                "summary": "Delete a project",
+                "summary": "Get events",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Project ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Filter by project ID",
+                        "name": "project_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by organization ID, ignored with project_id",
+                        "name": "organization_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by event type",
+                        "name": "event_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by event name",
+                        "name": "event_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by start date (RFC3339)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by end date (RFC3339)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit results",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset results",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.DeleteProjectResponse"
+                            "$ref": "#/definitions/dtos.GetEventsResponse"
                         }
                     },
                     "400": {
@@ -946,12 +1127,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/dtos.ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -960,13 +1135,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "patch": {
+            "post": {
                 "security": [
                     {
-                        "BearerAuth": []
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update a project's details",
+                "description": "Accept a new telemetry event from a device. The event is written asynchronously. Events with an event_id that was already received are not recorded again. Events that don't match the project's schema registry are rejected or reported as warnings, depending on its schema mode.",
                 "consumes": [
                     "application/json"
                 ],
@@ -974,32 +1149,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "events"
                 ],
-                "summary": "Update a project",
+                "summary": "Record a single event",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Project ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Updated project details",
-                        "name": "project",
+                        "description": "Event details",
+                        "name": "event",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.UpdateProjectRequest"
+                            "$ref": "#/definitions/dtos.RecordEventRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Duplicate event, already recorded",
                         "schema": {
-                            "$ref": "#/definitions/dtos.UpdateProjectResponse"
+                            "$ref": "#/definitions/dtos.RecordEventResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RecordEventResponse"
                         }
                     },
                     "400": {
@@ -1014,8 +1188,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "422": {
+                        "description": "Event rejected by the project's schema registry",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
@@ -1025,39 +1205,56 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/projects/{id}/apikey": {
+        "/events/batch": {
             "post": {
                 "security": [
                     {
-                        "BearerAuth": []
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Generate a new API key for a project",
+                "description": "Accept a batch of telemetry events from a device. Every event is validated on its own: valid events are written asynchronously while invalid ones are rejected with a reason code, see the per-index results. Events with an event_id that was already received are skipped and reported as duplicates. Events that don't match the project's schema registry are rejected or reported as warnings, depending on its schema mode.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "events"
                 ],
-                "summary": "Regenerate API key",
+                "summary": "Record multiple events",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Project ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Batch of events",
+                        "name": "events",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RecordEventsRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "No event was accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RecordEventsResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/dtos.GetProjectResponse"
+                            "$ref": "#/definitions/dtos.RecordEventsResponse"
                         }
                     },
                     "400": {
@@ -1072,8 +1269,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "429": {
+                        "description": "Too Many Requests",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
@@ -1083,69 +1280,140 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/sessions": {
+        "/events/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve all sessions with filtering and pagination",
+                "description": "Retrieve a specific event by its ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "sessions"
+                    "events"
                 ],
-                "summary": "Get sessions",
+                "summary": "Get event by ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Filter by project ID",
-                        "name": "project_id",
-                        "in": "query"
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetEventResponse"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Filter by start date (RFC3339)",
-                        "name": "from_date",
-                        "in": "query"
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Filter by end date (RFC3339)",
-                        "name": "to_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Limit results",
-                        "name": "limit",
-                        "in": "query"
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Check if the API is running",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/apikey": {
+            "get": {
+                "security": [
                     {
-                        "type": "integer",
-                        "description": "Offset results",
-                        "name": "offset",
-                        "in": "query"
+                        "ApiKeyAuth": []
                     }
                 ],
+                "description": "Check if the API is running and verify API key",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check endpoint with API key authentication",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.GetSessionsResponse"
+                            "$ref": "#/definitions/dtos.HealthResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
+                    }
+                }
+            }
+        },
+        "/organizations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the organizations the current user belongs to and their role in each",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Get organizations",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetOrganizationsResponse"
+                        }
                     },
                     "401": {
                         "description": "Unauthorized",
@@ -1160,16 +1428,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/sessions/begin": {
+            },
             "post": {
                 "security": [
                     {
-                        "ApiKeyAuth": []
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Start a new game session for a device",
+                "description": "Create an organization owned by the current user",
                 "consumes": [
                     "application/json"
                 ],
@@ -1177,17 +1443,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "sessions"
+                    "organizations"
                 ],
-                "summary": "Begin a new session",
+                "summary": "Create an organization",
                 "parameters": [
                     {
-                        "description": "Session details",
-                        "name": "session",
+                        "description": "Organization details",
+                        "name": "organization",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.BeginSessionRequest"
+                            "$ref": "#/definitions/dtos.CreateOrganizationRequest"
                         }
                     }
                 ],
@@ -1195,7 +1461,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/dtos.BeginSessionResponse"
+                            "$ref": "#/definitions/dtos.OrganizationResponse"
                         }
                     },
                     "400": {
@@ -1210,12 +1476,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/dtos.ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1225,40 +1485,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/sessions/end": {
-            "post": {
+        "/organizations/{id}": {
+            "get": {
                 "security": [
                     {
-                        "ApiKeyAuth": []
+                        "BearerAuth": []
                     }
                 ],
-                "description": "End an existing game session",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Retrieve an organization the current user belongs to",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "sessions"
+                    "organizations"
                 ],
-                "summary": "End a session",
+                "summary": "Get an organization",
                 "parameters": [
                     {
-                        "description": "Session to end",
-                        "name": "session",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dtos.EndSessionRequest"
-                        }
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.EndSessionResponse"
+                            "$ref": "#/definitions/dtos.OrganizationResponse"
                         }
                     },
                     "400": {
@@ -1273,40 +1528,38 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/sessions/{id}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve a specific session by its ID",
+                "description": "Delete an organization and its memberships. Its projects must be deleted or transferred first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "sessions"
+                    "organizations"
                 ],
-                "summary": "Get session by ID",
+                "summary": "Delete an organization",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
+                        "description": "Organization ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1316,7 +1569,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.GetSessionResponse"
+                            "$ref": "#/definitions/dtos.DeleteOrganizationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
                     "401": {
@@ -1325,39 +1584,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/users": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Retrieve a list of all users",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "users"
-                ],
-                "summary": "Get all users",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/dtos.GetUsersResponse"
+                            "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
@@ -1370,8 +1610,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
-                "description": "Register a new user account",
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rename an organization",
                 "consumes": [
                     "application/json"
                 ],
@@ -1379,25 +1624,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "organizations"
                 ],
-                "summary": "Create a new user",
+                "summary": "Update an organization",
                 "parameters": [
                     {
-                        "description": "User details",
-                        "name": "user",
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated organization details",
+                        "name": "organization",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.CreateUserRequest"
+                            "$ref": "#/definitions/dtos.UpdateOrganizationRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.CreateUserResponse"
+                            "$ref": "#/definitions/dtos.OrganizationResponse"
                         }
                     },
                     "400": {
@@ -1406,8 +1658,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
@@ -1421,26 +1685,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}": {
+        "/organizations/{id}/members": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve detailed information about the current user",
+                "description": "Retrieve the users that belong to an organization and their roles",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "organizations"
+                ],
+                "summary": "Get organization members",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get user by ID",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.GetUserResponseDetail"
+                            "$ref": "#/definitions/dtos.GetOrganizationMembersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
                     "401": {
@@ -1449,33 +1728,143 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     }
                 }
             },
-            "delete": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete the current user account",
+                "description": "Add an existing user to an organization with the given role",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "organizations"
                 ],
-                "summary": "Delete user",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/dtos.DeleteUserResponse"
+                "summary": "Invite an organization member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Member details",
+                        "name": "member",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.AddOrganizationMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.OrganizationMemberResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{id}/members/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke a user's membership of an organization. Their project memberships are kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Remove an organization member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RemoveOrganizationMemberResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
                     "401": {
@@ -1484,12 +1873,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1504,7 +1905,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update the current user's information",
+                "description": "Change the role of a user within an organization",
                 "consumes": [
                     "application/json"
                 ],
@@ -1512,17 +1913,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "organizations"
                 ],
-                "summary": "Update user",
+                "summary": "Change an organization member's role",
                 "parameters": [
                     {
-                        "description": "Updated user details",
-                        "name": "user",
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New role",
+                        "name": "member",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.UpdateUserRequest"
+                            "$ref": "#/definitions/dtos.UpdateOrganizationMemberRequest"
                         }
                     }
                 ],
@@ -1530,7 +1945,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.UpdateUserResponse"
+                            "$ref": "#/definitions/dtos.OrganizationMemberResponse"
                         }
                     },
                     "400": {
@@ -1545,12 +1960,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1559,154 +1986,4223 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dtos.BeginSessionRequest": {
-            "type": "object",
-            "required": [
-                "identifier"
-            ],
-            "properties": {
-                "identifier": {
-                    "type": "string"
+        },
+        "/organizations/{id}/usage": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Summarize the events ingested per month for each project of the organization. Events count towards the organization owning the project when they were received, deleted projects included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Get organization usage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "First month (YYYY-MM), 11 months before to by default",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month (YYYY-MM), the current month by default",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetOrganizationUsageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/privacy/erase": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Permanently delete every device, session and event stored for a device identifier and record the erasure in the project's tamper-evident erasure log. Requires an API key with the privacy scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "privacy"
+                ],
+                "summary": "Erase a player's data",
+                "parameters": [
+                    {
+                        "description": "Device identifier",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.EraseDeviceDataRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.EraseDeviceDataResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/privacy/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Export every device, session and event stored for a device identifier, deleted ones included, to answer a data subject access request. Requires an API key with the privacy scope.",
+                "produces": [
+                    "application/json",
+                    "application/zip"
+                ],
+                "tags": [
+                    "privacy"
+                ],
+                "summary": "Export a player's data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device identifier",
+                        "name": "identifier",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default) or zip",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.DeviceDataExport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a list of all projects",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get all projects",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by organization ID",
+                        "name": "organization_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetProjectsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new telemetry project in one of the current user's organizations. Without organization_id the project goes to the user's only organization, or to a new one when they have none.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Create new project",
+                "parameters": [
+                    {
+                        "description": "Project details",
+                        "name": "project",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CreateProjectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CreateProjectResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/apikey": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get project details using an API key for authentication",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get project with API key",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetProjectResponseDetail"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a specific project by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get a project by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetProjectResponseDetail"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a project by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Delete a project",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.DeleteProjectResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a project's details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Update a project",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated project details",
+                        "name": "project",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UpdateProjectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UpdateProjectResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/apikey": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new API key for a project and retire the current keys after a grace period",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Regenerate API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "How long the current keys keep working (default 168h)",
+                        "name": "grace_period",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RegenerateApiKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/apikeys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all API keys of a project, including retired and revoked ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get project API keys",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetApiKeysResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new named API key with the given scopes for a project",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Create an API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "API key details",
+                        "name": "apikey",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CreateApiKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ApiKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/apikeys/{key_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke an API key immediately, or retire it after a grace period so shipped builds keep working while new ones roll out",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "key_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Keep the key working for this long (e.g. 72h)",
+                        "name": "grace_period",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RevokeApiKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/data-retention": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the project's data retention settings and what the background purge deleted. Settings are changed with PATCH /projects/{id}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get data retention",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetDataRetentionResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/devices/anonymize": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Start a background job rewriting the stored IP addresses of the project's devices to its current IP privacy mode. It runs automatically when the mode changes; addresses that were truncated, hashed or dropped can't be restored.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Rewrite stored device IP addresses",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RewriteDeviceIPsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/live": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stream a summary of the project's ingestion every second as Server-Sent Events named \"metrics\": events received by name, open sessions, new devices, for the last second and the rolling last minute. Browsers authenticate with a stream token instead of the Authorization header.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Stream live metrics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Single-use stream token from POST /projects/{id}/live/token",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.LiveMetricsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/live/token": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issue a single-use token opening the project's live stream within a minute, for browsers whose EventSource can't send an Authorization header. Pass it as the token query parameter of the stream.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Create a live stream token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CreateStreamTokenResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/members": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the users that belong to a project and their roles",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get project members",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetProjectMembersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add an existing user to a project with the given role",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Invite a project member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Member details",
+                        "name": "member",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.AddProjectMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ProjectMemberResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/members/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke a user's access to a project",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Remove a project member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RemoveProjectMemberResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the role of a user within a project",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Change a member's role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New role",
+                        "name": "member",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UpdateProjectMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ProjectMemberResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/privacy/erase": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Permanently delete every device, session and event stored for a device identifier and record the erasure in the project's tamper-evident erasure log",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "privacy"
+                ],
+                "summary": "Erase a player's data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Device identifier",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.EraseDeviceDataRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.EraseDeviceDataResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/privacy/erasures": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the project's erasure log and verify its hash chain",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "privacy"
+                ],
+                "summary": "Get the erasure log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetErasureLogsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/privacy/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Export every device, session and event stored for a device identifier, deleted ones included, to answer a data subject access request",
+                "produces": [
+                    "application/json",
+                    "application/zip"
+                ],
+                "tags": [
+                    "privacy"
+                ],
+                "summary": "Export a player's data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device identifier",
+                        "name": "identifier",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default) or zip",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.DeviceDataExport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/schemas": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the schema mode and every registered event schema of a project",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schemas"
+                ],
+                "summary": "Get event schemas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetEventSchemasResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Declare an event of a project and the payload properties it is expected to carry",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schemas"
+                ],
+                "summary": "Register an event schema",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Event schema",
+                        "name": "schema",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CreateEventSchemaRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.EventSchemaResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/schemas/mode": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Choose how incoming events are checked against the schema registry: off, warn (record and count invalid events) or reject (drop and count invalid events)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schemas"
+                ],
+                "summary": "Set the schema mode",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Schema mode",
+                        "name": "mode",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UpdateSchemaModeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UpdateSchemaModeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/schemas/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve how many events failed schema validation, per event name and per day",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schemas"
+                ],
+                "summary": "Get schema validation counters",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by start date (RFC3339)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by end date (RFC3339)",
+                        "name": "to_date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetSchemaStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/schemas/{schema_id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the definition of a registered event schema",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schemas"
+                ],
+                "summary": "Replace an event schema",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Event schema ID",
+                        "name": "schema_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Event schema",
+                        "name": "schema",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UpdateEventSchemaRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.EventSchemaResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove an event from the schema registry of a project",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schemas"
+                ],
+                "summary": "Delete an event schema",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Event schema ID",
+                        "name": "schema_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.DeleteEventSchemaResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/transfer": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a project to another organization. Requires the owner role in the project and at least the admin role in both its current and the receiving organization. Project members keep their roles; usage from now on counts towards the receiving organization.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Transfer a project",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Receiving organization",
+                        "name": "transfer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.TransferProjectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.TransferProjectResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all sessions with filtering and pagination",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Get sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by project ID",
+                        "name": "project_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by organization ID, ignored with project_id",
+                        "name": "organization_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by start date (RFC3339)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by end date (RFC3339)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit results",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset results",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetSessionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/begin": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Start a new game session for a device",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Begin a new session",
+                "parameters": [
+                    {
+                        "description": "Session details",
+                        "name": "session",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.BeginSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.BeginSessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/end": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "End an existing game session",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "End a session",
+                "parameters": [
+                    {
+                        "description": "Session to end",
+                        "name": "session",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.EndSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.EndSessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The session already ended",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/heartbeat": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Mark an open session as still active. Sessions without a heartbeat or event for longer than the project's session timeout are ended automatically.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Send a session heartbeat",
+                "parameters": [
+                    {
+                        "description": "Session to keep alive",
+                        "name": "session",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.SessionHeartbeatRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.SessionHeartbeatResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The session already ended, begin a new one",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a specific session by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Get session by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetSessionResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/{id}/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the events recorded during a session in chronological order",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Get session events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit results",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset results",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetEventsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/setup": {
+            "get": {
+                "description": "Report whether the installation still needs its first admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "setup"
+                ],
+                "summary": "Get setup status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetSetupResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create the first user of the installation with the one-time setup token printed at startup",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "setup"
+                ],
+                "summary": "Create the first admin",
+                "parameters": [
+                    {
+                        "description": "Setup token and admin details",
+                        "name": "setup",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CompleteSetupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CompleteSetupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a list of all users",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get all users",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetUsersResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a user account. Only logged-in users can, unless ALLOW_REGISTRATION is set; users created by someone else must change their password on first login. Refused until the first admin was created through the initial setup.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User details",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CreateUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve detailed information about the current user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by ID",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GetUserResponseDetail"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete the current user account",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.DeleteUserResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update the current user's information",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update user",
+                "parameters": [
+                    {
+                        "description": "Updated user details",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UpdateUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dtos.AddOrganizationMemberRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "role"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "admin",
+                        "member"
+                    ]
+                }
+            }
+        },
+        "dtos.AddProjectMemberRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "role"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "admin",
+                        "analyst",
+                        "viewer"
+                    ]
+                }
+            }
+        },
+        "dtos.AggregateEventsRequest": {
+            "type": "object",
+            "required": [
+                "metric"
+            ],
+            "properties": {
+                "event_name": {
+                    "description": "Only aggregate events with this name",
+                    "type": "string"
+                },
+                "event_type": {
+                    "description": "Only aggregate events with this type",
+                    "type": "string"
+                },
+                "filters": {
+                    "description": "Payload properties the events must have, compared for equality",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "from_date": {
+                    "description": "Defaults to 30 days before to_date",
+                    "type": "string"
+                },
+                "granularity": {
+                    "description": "Bucket the results by time",
+                    "type": "string",
+                    "enum": [
+                        "hour",
+                        "day",
+                        "week",
+                        "month"
+                    ]
+                },
+                "group_by": {
+                    "description": "Up to two of platform, country, region, city, app_version or payload.\u003ckey\u003e",
+                    "type": "array",
+                    "maxItems": 2,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "limit": {
+                    "description": "Maximum number of rows, defaults to 1000",
+                    "type": "integer",
+                    "maximum": 10000,
+                    "minimum": 1
+                },
+                "metric": {
+                    "description": "Aggregate to compute",
+                    "type": "string",
+                    "enum": [
+                        "count",
+                        "unique_devices",
+                        "sum",
+                        "avg",
+                        "min",
+                        "max",
+                        "percentile"
+                    ]
+                },
+                "percentile": {
+                    "description": "Percentile to compute, e.g. 0.95",
+                    "type": "number"
+                },
+                "property": {
+                    "description": "Numeric payload key, required by sum, avg, min, max and percentile",
+                    "type": "string"
+                },
+                "to_date": {
+                    "description": "Defaults to now",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.AggregateEventsResponse": {
+            "type": "object",
+            "properties": {
+                "group_by": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "property": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.AggregateEventsRow"
+                    }
+                },
+                "truncated": {
+                    "description": "More rows matched than the limit",
+                    "type": "boolean"
+                }
+            }
+        },
+        "dtos.AggregateEventsRow": {
+            "type": "object",
+            "properties": {
+                "dimensions": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "timestamp": {
+                    "description": "Start of the time bucket, UTC",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Null when no event had a numeric value for the property",
+                    "type": "number"
+                }
+            }
+        },
+        "dtos.ApiKeyResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "api_key": {
+                    "description": "Only returned once, when the key is created",
+                    "type": "string"
+                },
+                "api_key_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dtos.AuthSessionResponse": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "description": "Refresh token expiry",
+                    "type": "string"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "last_refreshed_at": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "description": "Token family ID",
+                    "type": "string"
+                },
+                "signed_in_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.BeginSessionRequest": {
+            "type": "object",
+            "required": [
+                "identifier"
+            ],
+            "properties": {
+                "identifier": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.BeginSessionResponse": {
+            "type": "object",
+            "properties": {
+                "session_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string",
+                    "minLength": 6
+                }
+            }
+        },
+        "dtos.ChangePasswordResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "revoked": {
+                    "description": "Other logins that were signed out",
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.CompleteSetupRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "name",
+                "password",
+                "token"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                },
+                "token": {
+                    "description": "One-time setup token printed at startup",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.CompleteSetupResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.CreateApiKeyRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dtos.CreateEventSchemaRequest": {
+            "type": "object",
+            "required": [
+                "event_name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "event_name": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "description": "Expected event type, any type is allowed when empty",
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "schema": {
+                    "description": "JSON Schema subset describing the payloads",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PayloadSchema"
+                        }
+                    ]
+                }
+            }
+        },
+        "dtos.CreateOrUpdateDeviceRequest": {
+            "type": "object",
+            "required": [
+                "app_version",
+                "identifier",
+                "platform",
+                "platform_version"
+            ],
+            "properties": {
+                "app_version": {
+                    "type": "string"
+                },
+                "identifier": {
+                    "type": "string"
+                },
+                "platform": {
+                    "type": "string"
+                },
+                "platform_version": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.CreateOrUpdateDeviceResponse": {
+            "type": "object",
+            "properties": {
+                "app_version": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "first_seen": {
+                    "type": "string"
+                },
+                "identifier": {
+                    "type": "string"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "last_seen": {
+                    "type": "string"
+                },
+                "platform": {
+                    "type": "string"
+                },
+                "platform_version": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.CreateOrganizationRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.CreateProjectRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "description": "Organization of the project, optional when the user belongs to at most one",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.CreateProjectResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "description": "Only returned once, store it securely",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "string"
+                },
+                "owner": {
+                    "$ref": "#/definitions/dtos.OwnerDto"
+                },
+                "project_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.CreateStreamTokenResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Single-use, pass it as the token query parameter of the stream",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "name",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                }
+            }
+        },
+        "dtos.CreateUserResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.DataRetentionTotals": {
+            "type": "object",
+            "properties": {
+                "devices": {
+                    "type": "integer"
+                },
+                "events": {
+                    "type": "integer"
+                },
+                "sessions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.DeleteDeviceResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.DeleteEventSchemaResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.DeleteOrganizationResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.DeleteProjectResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.DeleteUserResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.DeviceDataExport": {
+            "type": "object",
+            "properties": {
+                "devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Device"
+                    }
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Event"
+                    }
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "identifier": {
+                    "type": "string"
+                },
+                "project_id": {
+                    "type": "string"
+                },
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Session"
+                    }
+                }
+            }
+        },
+        "dtos.EndSessionRequest": {
+            "type": "object",
+            "required": [
+                "session_id"
+            ],
+            "properties": {
+                "session_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.EndSessionResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.EraseDeviceDataRequest": {
+            "type": "object",
+            "required": [
+                "identifier"
+            ],
+            "properties": {
+                "identifier": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.EraseDeviceDataResponse": {
+            "type": "object",
+            "properties": {
+                "erasure": {
+                    "$ref": "#/definitions/dtos.ErasureLogResponse"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.ErasureLogResponse": {
+            "type": "object",
+            "properties": {
+                "devices": {
+                    "type": "integer"
+                },
+                "erased_at": {
+                    "type": "string"
+                },
+                "erasure_id": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "integer"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "identifier_hash": {
+                    "description": "HMAC-SHA256 of the erased identifier, keyed per project",
+                    "type": "string"
+                },
+                "previous_hash": {
+                    "type": "string"
+                },
+                "requested_by": {
+                    "type": "string"
+                },
+                "sequence": {
+                    "type": "integer"
+                },
+                "sessions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.EventSchemaResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "event_name": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "schema": {
+                    "$ref": "#/definitions/models.PayloadSchema"
+                },
+                "schema_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.FunnelStepRequest": {
+            "type": "object",
+            "required": [
+                "event_name"
+            ],
+            "properties": {
+                "event_name": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "description": "Any type matches when empty",
+                    "type": "string"
+                },
+                "filters": {
+                    "description": "Payload properties the event must have, compared for equality",
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "dtos.FunnelStepResponse": {
+            "type": "object",
+            "properties": {
+                "conversion_rate": {
+                    "description": "Devices divided by the devices of the first step",
+                    "type": "number"
+                },
+                "devices": {
+                    "description": "Devices that reached the step",
+                    "type": "integer"
+                },
+                "event_name": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "median_seconds_from_prior": {
+                    "description": "Median time between the previous step and this one, in seconds",
+                    "type": "number"
+                },
+                "step": {
+                    "description": "1-based position of the step",
+                    "type": "integer"
+                },
+                "step_conversion_rate": {
+                    "description": "Devices divided by the devices of the previous step",
+                    "type": "number"
+                }
+            }
+        },
+        "dtos.GetAnalyticsResponse": {
+            "type": "object",
+            "required": [
+                "dau",
+                "mau",
+                "total_duration",
+                "total_installs"
+            ],
+            "properties": {
+                "dau": {
+                    "type": "integer"
+                },
+                "mau": {
+                    "type": "integer"
+                },
+                "rejected_events": {
+                    "description": "Events dropped for failing schema validation",
+                    "type": "integer"
+                },
+                "total_duration": {
+                    "type": "integer"
+                },
+                "total_installs": {
+                    "type": "integer"
+                },
+                "warned_events": {
+                    "description": "Events recorded despite failing schema validation",
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.GetApiKeysResponse": {
+            "type": "object",
+            "properties": {
+                "api_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ApiKeyResponse"
+                    }
+                }
+            }
+        },
+        "dtos.GetAuthSessionsResponse": {
+            "type": "object",
+            "properties": {
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.AuthSessionResponse"
+                    }
+                }
+            }
+        },
+        "dtos.GetDataRetentionResponse": {
+            "type": "object",
+            "properties": {
+                "event_retention_days": {
+                    "type": "integer"
+                },
+                "last_run": {
+                    "description": "Null until the purge job ran for the project",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dtos.PurgeRunResponse"
+                        }
+                    ]
+                },
+                "session_retention_days": {
+                    "type": "integer"
+                },
+                "total_purged": {
+                    "description": "Rows purged by the runs still in the history (90 days)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dtos.DataRetentionTotals"
+                        }
+                    ]
+                }
+            }
+        },
+        "dtos.GetDeviceResponse": {
+            "type": "object",
+            "properties": {
+                "app_version": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "first_seen": {
+                    "type": "string"
+                },
+                "identifier": {
+                    "type": "string"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "last_seen": {
+                    "type": "string"
+                },
+                "platform": {
+                    "type": "string"
+                },
+                "platform_version": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.GetDevicesResponse": {
+            "type": "object",
+            "properties": {
+                "devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.GetDeviceResponse"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.GetErasureLogsResponse": {
+            "type": "object",
+            "properties": {
+                "broken_at": {
+                    "description": "Sequence of the first entry that was tampered with",
+                    "type": "integer"
+                },
+                "chain_valid": {
+                    "type": "boolean"
+                },
+                "erasures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ErasureLogResponse"
+                    }
+                }
+            }
+        },
+        "dtos.GetEventResponse": {
+            "type": "object",
+            "properties": {
+                "client_event_id": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "event_name": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "payloads": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "received_at": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.GetEventSchemasResponse": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "description": "off, warn or reject",
+                    "type": "string"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.EventSchemaResponse"
+                    }
+                }
+            }
+        },
+        "dtos.GetEventsResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.GetEventResponse"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.GetFunnelRequest": {
+            "type": "object",
+            "required": [
+                "steps"
+            ],
+            "properties": {
+                "from_date": {
+                    "description": "Earliest first step, defaults to 30 days before to_date",
+                    "type": "string"
+                },
+                "steps": {
+                    "description": "Ordered steps",
+                    "type": "array",
+                    "maxItems": 10,
+                    "minItems": 2,
+                    "items": {
+                        "$ref": "#/definitions/dtos.FunnelStepRequest"
+                    }
+                },
+                "to_date": {
+                    "description": "Latest first step, defaults to now",
+                    "type": "string"
+                },
+                "window": {
+                    "description": "Time allowed from the first to the last step (e.g. 24h), defaults to until to_date",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.GetFunnelResponse": {
+            "type": "object",
+            "properties": {
+                "from_date": {
+                    "type": "string"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.FunnelStepResponse"
+                    }
+                },
+                "to_date": {
+                    "type": "string"
+                },
+                "window": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.GetOrganizationMembersResponse": {
+            "type": "object",
+            "properties": {
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.OrganizationMemberResponse"
+                    }
+                }
+            }
+        },
+        "dtos.GetOrganizationUsageResponse": {
+            "type": "object",
+            "properties": {
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.MonthlyUsageResponse"
+                    }
+                },
+                "organization_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.GetOrganizationsResponse": {
+            "type": "object",
+            "properties": {
+                "organizations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.OrganizationResponse"
+                    }
+                }
+            }
+        },
+        "dtos.GetProjectMembersResponse": {
+            "type": "object",
+            "properties": {
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ProjectMemberResponse"
+                    }
+                }
+            }
+        },
+        "dtos.GetProjectResponse": {
+            "type": "object",
+            "properties": {
+                "ip_privacy": {
+                    "description": "How device IP addresses are stored: full, truncate, hash or none",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "string"
+                },
+                "owner": {
+                    "$ref": "#/definitions/dtos.OwnerDto"
+                },
+                "project_id": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "Role of the requesting user in the project",
+                    "type": "string"
+                },
+                "session_timeout": {
+                    "description": "Idle time after which open sessions are ended",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.GetProjectResponseDetail": {
+            "type": "object",
+            "properties": {
+                "devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Device"
+                    }
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Event"
+                    }
+                },
+                "ip_privacy": {
+                    "description": "How device IP addresses are stored: full, truncate, hash or none",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "string"
+                },
+                "owner": {
+                    "$ref": "#/definitions/dtos.OwnerDto"
+                },
+                "project_id": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "Role of the requesting user in the project",
+                    "type": "string"
+                },
+                "session_timeout": {
+                    "description": "Idle time after which open sessions are ended",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.GetProjectsResponse": {
+            "type": "object",
+            "properties": {
+                "projects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.GetProjectResponse"
+                    }
+                }
+            }
+        },
+        "dtos.GetRetentionResponse": {
+            "type": "object",
+            "properties": {
+                "cohort_by": {
+                    "type": "string"
+                },
+                "cohorts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.RetentionCohortResponse"
+                    }
+                },
+                "d1": {
+                    "description": "Share of devices retained on day 1, over the cohorts that reached it",
+                    "type": "number"
+                },
+                "d30": {
+                    "description": "Share of devices retained on day 30, over the cohorts that reached it",
+                    "type": "number"
+                },
+                "d7": {
+                    "description": "Share of devices retained on day 7, over the cohorts that reached it",
+                    "type": "number"
+                },
+                "max_day": {
+                    "type": "integer"
                 }
             }
         },
-        "dtos.BeginSessionResponse": {
+        "dtos.GetSchemaStatsResponse": {
             "type": "object",
             "properties": {
-                "session_id": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.SchemaDayStatResponse"
+                    }
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.SchemaEventStatResponse"
+                    }
+                },
+                "mode": {
                     "type": "string"
+                },
+                "rejected": {
+                    "description": "Invalid events dropped in reject mode",
+                    "type": "integer"
+                },
+                "warned": {
+                    "description": "Invalid events recorded in warn mode",
+                    "type": "integer"
                 }
             }
         },
-        "dtos.CreateOrUpdateDeviceRequest": {
+        "dtos.GetSessionResponse": {
             "type": "object",
-            "required": [
-                "app_version",
-                "identifier",
-                "platform",
-                "platform_version"
-            ],
             "properties": {
-                "app_version": {
+                "begin_at": {
                     "type": "string"
                 },
-                "identifier": {
+                "duration": {
+                    "description": "Duration in nanoseconds",
+                    "type": "integer"
+                },
+                "end_at": {
                     "type": "string"
                 },
-                "platform": {
+                "event_count": {
+                    "description": "Events recorded during the session",
+                    "type": "integer"
+                },
+                "last_activity_at": {
                     "type": "string"
                 },
-                "platform_version": {
+                "session_id": {
                     "type": "string"
                 }
             }
         },
-        "dtos.CreateOrUpdateDeviceResponse": {
+        "dtos.GetSessionsResponse": {
             "type": "object",
             "properties": {
-                "app_version": {
-                    "type": "string"
+                "limit": {
+                    "type": "integer"
                 },
-                "country": {
-                    "type": "string"
+                "offset": {
+                    "type": "integer"
                 },
-                "device_id": {
-                    "type": "string"
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.GetSessionResponse"
+                    }
                 },
-                "first_seen": {
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.GetSetupResponse": {
+            "type": "object",
+            "properties": {
+                "setup_required": {
+                    "description": "No users exist yet",
+                    "type": "boolean"
+                }
+            }
+        },
+        "dtos.GetTimeseriesResponse": {
+            "type": "object",
+            "properties": {
+                "from_date": {
                     "type": "string"
                 },
-                "identifier": {
+                "granularity": {
                     "type": "string"
                 },
-                "ip_address": {
-                    "type": "string"
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.TimeseriesPointResponse"
+                    }
                 },
-                "last_seen": {
+                "to_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.GetUserResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
                     "type": "string"
                 },
-                "platform": {
+                "name": {
                     "type": "string"
                 },
-                "platform_version": {
+                "user_id": {
                     "type": "string"
                 }
             }
         },
-        "dtos.CreateProjectRequest": {
+        "dtos.GetUserResponseDetail": {
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
+                "email": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
+                },
+                "projects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Project"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "dtos.CreateProjectResponse": {
+        "dtos.GetUsersResponse": {
             "type": "object",
             "properties": {
-                "api_key": {
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.GetUserResponse"
+                    }
+                }
+            }
+        },
+        "dtos.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
                     "type": "string"
+                }
+            }
+        },
+        "dtos.LiveMetricsResponse": {
+            "type": "object",
+            "properties": {
+                "last_minute": {
+                    "description": "Rolling sum of the last 60 seconds",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dtos.LiveWindowResponse"
+                        }
+                    ]
                 },
-                "name": {
-                    "type": "string"
+                "last_second": {
+                    "$ref": "#/definitions/dtos.LiveWindowResponse"
                 },
-                "owner": {
-                    "$ref": "#/definitions/dtos.OwnerDto"
+                "open_sessions": {
+                    "description": "Sessions started and not ended yet",
+                    "type": "integer"
                 },
                 "project_id": {
                     "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
                 }
             }
         },
-        "dtos.CreateUserRequest": {
+        "dtos.LiveWindowResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "integer"
+                },
+                "events_by_name": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "new_devices": {
+                    "type": "integer"
+                },
+                "sessions_ended": {
+                    "type": "integer"
+                },
+                "sessions_started": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.LoginRequest": {
             "type": "object",
             "required": [
                 "email",
-                "name",
                 "password"
             ],
             "properties": {
                 "email": {
                     "type": "string"
                 },
-                "name": {
-                    "type": "string"
-                },
                 "password": {
-                    "type": "string",
-                    "minLength": 6
+                    "type": "string"
                 }
             }
         },
-        "dtos.CreateUserResponse": {
+        "dtos.LoginResponse": {
             "type": "object",
             "properties": {
-                "email": {
+                "expires_at": {
                     "type": "string"
                 },
-                "name": {
+                "must_change_password": {
+                    "description": "Other routes are refused until POST /auth/password",
+                    "type": "boolean"
+                },
+                "refresh_expires_at": {
                     "type": "string"
                 },
-                "user_id": {
+                "refresh_token": {
                     "type": "string"
-                }
-            }
-        },
-        "dtos.DeleteDeviceResponse": {
-            "type": "object",
-            "properties": {
-                "message": {
+                },
+                "token": {
                     "type": "string"
                 }
             }
         },
-        "dtos.DeleteProjectResponse": {
+        "dtos.LogoutResponse": {
             "type": "object",
             "properties": {
                 "message": {
@@ -1714,133 +6210,185 @@ const docTemplate = `{
                 }
             }
         },
-        "dtos.DeleteUserResponse": {
+        "dtos.MeResponse": {
             "type": "object",
             "properties": {
-                "message": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "must_change_password": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "dtos.EndSessionRequest": {
+        "dtos.MonthlyUsageResponse": {
             "type": "object",
-            "required": [
-                "session_id"
-            ],
             "properties": {
-                "session_id": {
+                "events_ingested": {
+                    "description": "Events of every project of the month",
+                    "type": "integer"
+                },
+                "month": {
+                    "description": "YYYY-MM, in UTC",
                     "type": "string"
+                },
+                "projects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ProjectUsageResponse"
+                    }
                 }
             }
         },
-        "dtos.EndSessionResponse": {
+        "dtos.OrganizationMemberResponse": {
             "type": "object",
             "properties": {
-                "message": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "user_id": {
                     "type": "string"
                 }
             }
         },
-        "dtos.ErrorResponse": {
+        "dtos.OrganizationResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "Role of the requesting user in the organization",
                     "type": "string"
                 }
             }
         },
-        "dtos.GetAnalyticsResponse": {
+        "dtos.OwnerDto": {
             "type": "object",
-            "required": [
-                "dau",
-                "mau",
-                "total_duration",
-                "total_installs"
-            ],
             "properties": {
-                "dau": {
-                    "type": "integer"
-                },
-                "mau": {
-                    "type": "integer"
+                "email": {
+                    "type": "string"
                 },
-                "total_duration": {
-                    "type": "integer"
+                "id": {
+                    "type": "string"
                 },
-                "total_installs": {
-                    "type": "integer"
+                "name": {
+                    "type": "string"
                 }
             }
         },
-        "dtos.GetDeviceResponse": {
+        "dtos.ProjectMemberResponse": {
             "type": "object",
             "properties": {
-                "app_version": {
-                    "type": "string"
-                },
-                "country": {
-                    "type": "string"
-                },
-                "device_id": {
+                "created_at": {
                     "type": "string"
                 },
-                "first_seen": {
+                "email": {
                     "type": "string"
                 },
-                "identifier": {
+                "name": {
                     "type": "string"
                 },
-                "ip_address": {
+                "role": {
                     "type": "string"
                 },
-                "last_seen": {
+                "user_id": {
                     "type": "string"
+                }
+            }
+        },
+        "dtos.ProjectUsageResponse": {
+            "type": "object",
+            "properties": {
+                "events_ingested": {
+                    "type": "integer"
                 },
-                "platform": {
+                "name": {
                     "type": "string"
                 },
-                "platform_version": {
+                "project_id": {
                     "type": "string"
                 }
             }
         },
-        "dtos.GetDevicesResponse": {
+        "dtos.PurgeRunResponse": {
             "type": "object",
             "properties": {
-                "devices": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.GetDeviceResponse"
-                    }
-                },
-                "limit": {
+                "devices_purged": {
                     "type": "integer"
                 },
-                "offset": {
+                "error": {
+                    "type": "string"
+                },
+                "events_purged": {
                     "type": "integer"
                 },
-                "total_count": {
+                "finished_at": {
+                    "type": "string"
+                },
+                "sessions_purged": {
                     "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
                 }
             }
         },
-        "dtos.GetEventResponse": {
+        "dtos.RecordEventRequest": {
             "type": "object",
+            "required": [
+                "event_name",
+                "event_type",
+                "identifier"
+            ],
             "properties": {
                 "event_id": {
+                    "description": "Optional client-generated UUID used to deduplicate retries",
                     "type": "string"
                 },
                 "event_name": {
                     "type": "string"
                 },
                 "event_type": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "identifier": {
                     "type": "string"
                 },
                 "payloads": {
                     "type": "object",
                     "additionalProperties": true
                 },
-                "received_at": {
+                "session_id": {
+                    "description": "Session the event happened in, defaults to the device's open session",
                     "type": "string"
                 },
                 "timestamp": {
@@ -1848,307 +6396,415 @@ const docTemplate = `{
                 }
             }
         },
-        "dtos.GetEventsResponse": {
+        "dtos.RecordEventResponse": {
             "type": "object",
             "properties": {
-                "events": {
+                "duplicate": {
+                    "description": "The event ID was already received, nothing was recorded",
+                    "type": "boolean"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "description": "Schema violations of an event accepted in warn mode",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dtos.GetEventResponse"
+                        "type": "string"
                     }
-                },
-                "total": {
-                    "type": "integer"
                 }
             }
         },
-        "dtos.GetProjectResponse": {
+        "dtos.RecordEventResult": {
             "type": "object",
             "properties": {
-                "api_key": {
+                "error": {
+                    "description": "Human readable detail of the rejection",
                     "type": "string"
                 },
-                "name": {
+                "event_id": {
+                    "description": "Client event ID, when one was sent",
                     "type": "string"
                 },
-                "owner": {
-                    "$ref": "#/definitions/dtos.OwnerDto"
+                "index": {
+                    "description": "Position of the event in the request",
+                    "type": "integer"
                 },
-                "project_id": {
+                "reason": {
+                    "description": "Reason code of a rejected event",
                     "type": "string"
-                }
-            }
-        },
-        "dtos.GetProjectResponseDetail": {
-            "type": "object",
-            "properties": {
-                "api_key": {
+                },
+                "status": {
+                    "description": "accepted, duplicate or rejected",
                     "type": "string"
                 },
-                "devices": {
+                "warnings": {
+                    "description": "Schema violations of an event accepted in warn mode",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Device"
+                        "type": "string"
                     }
-                },
+                }
+            }
+        },
+        "dtos.RecordEventsRequest": {
+            "type": "object",
+            "required": [
+                "events"
+            ],
+            "properties": {
                 "events": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Event"
+                        "$ref": "#/definitions/dtos.RecordEventRequest"
                     }
+                }
+            }
+        },
+        "dtos.RecordEventsResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Number of new events accepted",
+                    "type": "integer"
                 },
-                "name": {
+                "duplicates": {
+                    "description": "Number of events skipped because their ID was already received",
+                    "type": "integer"
+                },
+                "message": {
                     "type": "string"
                 },
-                "owner": {
-                    "$ref": "#/definitions/dtos.OwnerDto"
+                "rejected": {
+                    "description": "Number of events that failed validation",
+                    "type": "integer"
                 },
-                "project_id": {
-                    "type": "string"
+                "results": {
+                    "description": "One result per event, in request order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.RecordEventResult"
+                    }
                 }
             }
         },
-        "dtos.GetProjectsResponse": {
+        "dtos.RefreshRequest": {
             "type": "object",
+            "required": [
+                "refresh_token"
+            ],
             "properties": {
-                "projects": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.GetProjectResponse"
-                    }
+                "refresh_token": {
+                    "type": "string"
                 }
             }
         },
-        "dtos.GetSessionResponse": {
+        "dtos.RefreshResponse": {
             "type": "object",
             "properties": {
-                "begin_at": {
+                "expires_at": {
                     "type": "string"
                 },
-                "duration": {
-                    "description": "Duration in nanoseconds",
-                    "type": "integer"
+                "refresh_expires_at": {
+                    "type": "string"
                 },
-                "end_at": {
+                "refresh_token": {
                     "type": "string"
                 },
-                "session_id": {
+                "token": {
                     "type": "string"
                 }
             }
         },
-        "dtos.GetSessionsResponse": {
+        "dtos.RegenerateApiKeyResponse": {
             "type": "object",
             "properties": {
-                "limit": {
-                    "type": "integer"
+                "api_key": {
+                    "$ref": "#/definitions/dtos.ApiKeyResponse"
                 },
-                "offset": {
+                "retired_keys": {
                     "type": "integer"
                 },
-                "sessions": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.GetSessionResponse"
-                    }
-                },
-                "total": {
-                    "type": "integer"
+                "retires_at": {
+                    "type": "string"
                 }
             }
         },
-        "dtos.GetUserResponse": {
+        "dtos.RemoveOrganizationMemberResponse": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string"
-                },
-                "name": {
+                "message": {
                     "type": "string"
-                },
-                "user_id": {
+                }
+            }
+        },
+        "dtos.RemoveProjectMemberResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
                     "type": "string"
                 }
             }
         },
-        "dtos.GetUserResponseDetail": {
+        "dtos.RetentionCohortResponse": {
             "type": "object",
             "properties": {
-                "email": {
+                "cohort_day": {
+                    "description": "YYYY-MM-DD, UTC",
                     "type": "string"
                 },
-                "name": {
-                    "type": "string"
+                "rates": {
+                    "description": "Retained divided by size, index N",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
                 },
-                "projects": {
+                "retained": {
+                    "description": "Devices with a session N days after the cohort day, index N; days still in the future are left out",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Project"
+                        "type": "integer"
                     }
                 },
-                "user_id": {
-                    "type": "string"
+                "size": {
+                    "description": "Devices in the cohort",
+                    "type": "integer"
                 }
             }
         },
-        "dtos.GetUsersResponse": {
+        "dtos.RevokeApiKeyResponse": {
             "type": "object",
             "properties": {
-                "users": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.GetUserResponse"
-                    }
+                "message": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
                 }
             }
         },
-        "dtos.HealthResponse": {
+        "dtos.RevokeAuthSessionsResponse": {
             "type": "object",
             "properties": {
-                "status": {
+                "message": {
                     "type": "string"
+                },
+                "revoked": {
+                    "type": "integer"
                 }
             }
         },
-        "dtos.LoginRequest": {
+        "dtos.RewriteDeviceIPsResponse": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
+                "ip_privacy": {
                     "type": "string"
                 },
-                "password": {
+                "message": {
                     "type": "string"
                 }
             }
         },
-        "dtos.LoginResponse": {
+        "dtos.SchemaDayStatResponse": {
             "type": "object",
             "properties": {
-                "expires_at": {
+                "day": {
+                    "description": "YYYY-MM-DD, UTC",
                     "type": "string"
                 },
-                "token": {
+                "rejected": {
+                    "type": "integer"
+                },
+                "warned": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.SchemaEventStatResponse": {
+            "type": "object",
+            "properties": {
+                "event_name": {
                     "type": "string"
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "warned": {
+                    "type": "integer"
                 }
             }
         },
-        "dtos.LogoutResponse": {
+        "dtos.SessionHeartbeatRequest": {
             "type": "object",
+            "required": [
+                "session_id"
+            ],
             "properties": {
-                "message": {
+                "session_id": {
                     "type": "string"
                 }
             }
         },
-        "dtos.MeResponse": {
+        "dtos.SessionHeartbeatResponse": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "last_activity_at": {
                     "type": "string"
                 },
-                "email": {
+                "message": {
                     "type": "string"
+                }
+            }
+        },
+        "dtos.TimeseriesPointResponse": {
+            "type": "object",
+            "properties": {
+                "active_devices": {
+                    "description": "Distinct devices that started a session",
+                    "type": "integer"
                 },
-                "id": {
-                    "type": "string"
+                "avg_session_length": {
+                    "description": "Average length of the ended sessions, in seconds",
+                    "type": "number"
                 },
-                "name": {
-                    "type": "string"
+                "new_devices": {
+                    "description": "Devices first seen",
+                    "type": "integer"
                 },
-                "updated_at": {
+                "sessions": {
+                    "description": "Sessions started",
+                    "type": "integer"
+                },
+                "timestamp": {
+                    "description": "Start of the bucket, UTC",
                     "type": "string"
                 }
             }
         },
-        "dtos.OwnerDto": {
+        "dtos.TransferProjectRequest": {
             "type": "object",
+            "required": [
+                "organization_id"
+            ],
             "properties": {
-                "email": {
+                "organization_id": {
+                    "description": "Organization receiving the project",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.TransferProjectResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
                     "type": "string"
                 },
-                "id": {
+                "organization_id": {
                     "type": "string"
                 },
-                "name": {
+                "project_id": {
                     "type": "string"
                 }
             }
         },
-        "dtos.RecordEventRequest": {
+        "dtos.UpdateEventSchemaRequest": {
             "type": "object",
             "required": [
-                "event_name",
-                "event_type",
-                "identifier"
+                "event_name"
             ],
             "properties": {
-                "event_name": {
+                "description": {
                     "type": "string"
                 },
-                "event_type": {
-                    "type": "string"
-                },
-                "identifier": {
+                "event_name": {
                     "type": "string"
                 },
-                "payloads": {
-                    "type": "object",
-                    "additionalProperties": true
+                "event_type": {
+                    "type": "string",
+                    "maxLength": 50
                 },
-                "timestamp": {
-                    "type": "string"
+                "schema": {
+                    "$ref": "#/definitions/models.PayloadSchema"
                 }
             }
         },
-        "dtos.RecordEventResponse": {
+        "dtos.UpdateOrganizationMemberRequest": {
             "type": "object",
+            "required": [
+                "role"
+            ],
             "properties": {
-                "message": {
-                    "type": "string"
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "admin",
+                        "member"
+                    ]
                 }
             }
         },
-        "dtos.RecordEventsRequest": {
+        "dtos.UpdateOrganizationRequest": {
             "type": "object",
             "required": [
-                "events"
+                "name"
             ],
             "properties": {
-                "events": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.RecordEventRequest"
-                    }
+                "name": {
+                    "type": "string"
                 }
             }
         },
-        "dtos.RecordEventsResponse": {
+        "dtos.UpdateProjectMemberRequest": {
             "type": "object",
+            "required": [
+                "role"
+            ],
             "properties": {
-                "count": {
-                    "type": "integer"
-                },
-                "message": {
-                    "type": "string"
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "admin",
+                        "analyst",
+                        "viewer"
+                    ]
                 }
             }
         },
         "dtos.UpdateProjectRequest": {
             "type": "object",
             "properties": {
+                "event_retention_days": {
+                    "description": "Days events are kept, 0 keeps them forever",
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 0
+                },
+                "ip_privacy": {
+                    "description": "How device IP addresses are stored: full, truncate (/24 or /48), hash or none",
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
+                },
+                "session_retention_days": {
+                    "description": "Days sessions are kept, 0 keeps them forever",
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 0
+                },
+                "session_timeout": {
+                    "description": "Idle time after which open sessions are ended (e.g. 30m), between 1m and 24h",
+                    "type": "string"
                 }
             }
         },
         "dtos.UpdateProjectResponse": {
             "type": "object",
             "properties": {
-                "api_key": {
+                "ip_privacy": {
                     "type": "string"
                 },
                 "name": {
@@ -2159,6 +6815,33 @@ const docTemplate = `{
                 },
                 "project_id": {
                     "type": "string"
+                },
+                "session_timeout": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.UpdateSchemaModeRequest": {
+            "type": "object",
+            "required": [
+                "mode"
+            ],
+            "properties": {
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "off",
+                        "warn",
+                        "reject"
+                    ]
+                }
+            }
+        },
+        "dtos.UpdateSchemaModeResponse": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "type": "string"
                 }
             }
         },
@@ -2187,6 +6870,50 @@ const docTemplate = `{
                 }
             }
         },
+        "models.ApiKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by_id": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "Key stops working after this time (used for grace periods)",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "First characters of the key, for display",
+                    "type": "string"
+                },
+                "project_id": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "description": "Key was revoked immediately",
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Device": {
             "type": "object",
             "properties": {
@@ -2194,6 +6921,10 @@ const docTemplate = `{
                     "description": "App version",
                     "type": "string"
                 },
+                "city": {
+                    "description": "City based on IP (optional)",
+                    "type": "string"
+                },
                 "country": {
                     "description": "Country based on IP (optional)",
                     "type": "string"
@@ -2219,7 +6950,7 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "ip_address": {
-                    "description": "Hashed/anonymized IP address",
+                    "description": "Stored according to the project's IP privacy mode",
                     "type": "string"
                 },
                 "last_seen": {
@@ -2237,6 +6968,10 @@ const docTemplate = `{
                 "project_id": {
                     "type": "string"
                 },
+                "region": {
+                    "description": "Region based on IP (optional)",
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
                 }
@@ -2245,6 +6980,10 @@ const docTemplate = `{
         "models.Event": {
             "type": "object",
             "properties": {
+                "client_event_id": {
+                    "description": "SDK-generated ID used to drop retried events",
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
@@ -2276,6 +7015,10 @@ const docTemplate = `{
                     "description": "When event was received by server",
                     "type": "string"
                 },
+                "session_id": {
+                    "description": "Session the event happened in, if any",
+                    "type": "string"
+                },
                 "timestamp": {
                     "description": "When event occurred (client-side)",
                     "type": "string"
@@ -2285,6 +7028,78 @@ const docTemplate = `{
                 }
             }
         },
+        "models.EventSchema": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "event_name": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "description": "Expected event type, any type is allowed when empty",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "project_id": {
+                    "type": "string"
+                },
+                "schema": {
+                    "$ref": "#/definitions/models.PayloadSchema"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.IPPrivacyMode": {
+            "type": "string",
+            "enum": [
+                "full",
+                "truncate",
+                "hash",
+                "none"
+            ],
+            "x-enum-comments": {
+                "IPPrivacyFull": "The address is stored as received",
+                "IPPrivacyHash": "A salted hash of the address is stored",
+                "IPPrivacyNone": "No address is stored",
+                "IPPrivacyTruncate": "IPv4 addresses are truncated to /24 and IPv6 addresses to /48"
+            },
+            "x-enum-varnames": [
+                "IPPrivacyFull",
+                "IPPrivacyTruncate",
+                "IPPrivacyHash",
+                "IPPrivacyNone"
+            ]
+        },
+        "models.PayloadSchema": {
+            "type": "object",
+            "properties": {
+                "additionalProperties": {
+                    "description": "Undeclared properties are allowed unless false",
+                    "type": "boolean"
+                },
+                "properties": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.PropertySchema"
+                    }
+                },
+                "required": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "models.Payloads": {
             "type": "object",
             "additionalProperties": true
@@ -2292,8 +7107,11 @@ const docTemplate = `{
         "models.Project": {
             "type": "object",
             "properties": {
-                "api_key": {
-                    "type": "string"
+                "api_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ApiKey"
+                    }
                 },
                 "created_at": {
                     "type": "string"
@@ -2304,6 +7122,16 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Device"
                     }
                 },
+                "event_retention_days": {
+                    "description": "Events older than this are purged, 0 keeps them forever",
+                    "type": "integer"
+                },
+                "event_schemas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EventSchema"
+                    }
+                },
                 "events": {
                     "type": "array",
                     "items": {
@@ -2313,15 +7141,171 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "ip_privacy": {
+                    "description": "How device IP addresses are stored",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.IPPrivacyMode"
+                        }
+                    ]
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProjectMember"
+                    }
+                },
                 "name": {
                     "type": "string"
                 },
+                "organization_id": {
+                    "description": "NOT NULL is set by the migration assigning existing projects an organization",
+                    "type": "string"
+                },
                 "owner": {
                     "$ref": "#/definitions/models.User"
                 },
                 "owner_id": {
                     "type": "string"
                 },
+                "schema_mode": {
+                    "description": "How events are checked against the schema registry",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SchemaMode"
+                        }
+                    ]
+                },
+                "session_retention_days": {
+                    "description": "Sessions that began before this are purged, 0 keeps them forever",
+                    "type": "integer"
+                },
+                "session_timeout": {
+                    "description": "Idle time after which an open session is ended, 30 minutes by default",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProjectMember": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "project_id": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/models.ProjectRole"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProjectRole": {
+            "type": "string",
+            "enum": [
+                "owner",
+                "admin",
+                "analyst",
+                "viewer"
+            ],
+            "x-enum-comments": {
+                "ProjectRoleAdmin": "Manage project settings, API keys, devices and members",
+                "ProjectRoleAnalyst": "Read raw telemetry (events, sessions, devices) and analytics",
+                "ProjectRoleOwner": "Full control, including deleting the project and managing owners",
+                "ProjectRoleViewer": "Read project details and aggregated analytics"
+            },
+            "x-enum-varnames": [
+                "ProjectRoleOwner",
+                "ProjectRoleAdmin",
+                "ProjectRoleAnalyst",
+                "ProjectRoleViewer"
+            ]
+        },
+        "models.PropertySchema": {
+            "type": "object",
+            "properties": {
+                "enum": {
+                    "type": "array",
+                    "items": {}
+                },
+                "maxLength": {
+                    "type": "integer"
+                },
+                "maximum": {
+                    "type": "number"
+                },
+                "minLength": {
+                    "type": "integer"
+                },
+                "minimum": {
+                    "type": "number"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SchemaMode": {
+            "type": "string",
+            "enum": [
+                "off",
+                "warn",
+                "reject"
+            ],
+            "x-enum-comments": {
+                "SchemaModeOff": "Events are not validated",
+                "SchemaModeReject": "Invalid events are dropped and counted",
+                "SchemaModeWarn": "Invalid events are recorded and counted"
+            },
+            "x-enum-varnames": [
+                "SchemaModeOff",
+                "SchemaModeWarn",
+                "SchemaModeReject"
+            ]
+        },
+        "models.Session": {
+            "type": "object",
+            "properties": {
+                "begin_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "duration": {
+                    "type": "integer"
+                },
+                "end_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_activity_at": {
+                    "description": "Last heartbeat, used to time out abandoned sessions",
+                    "type": "string"
+                },
+                "project_id": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
                 }
@@ -2339,6 +7323,14 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "locked_at": {
+                    "description": "Locked users can't log in until an operator resets their password",
+                    "type": "string"
+                },
+                "must_change_password": {
+                    "description": "Set for passwords the user didn't choose",
+                    "type": "boolean"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -5,14 +5,133 @@ import (
 )
 
 type GetAnalyticsRequestQuery struct {
-	ProjectID string    `form:"project_id" json:"project_id,omitempty"`
-	FromDate  time.Time `form:"from_date" json:"from_date,omitempty"`
-	ToDate    time.Time `form:"to_date" json:"to_date,omitempty"`
+	ProjectID      string    `form:"project_id" json:"project_id,omitempty"`
+	OrganizationID string    `form:"organization_id" json:"organization_id,omitempty" binding:"omitempty,uuid"` // Only projects of this organization, ignored with project_id
+	FromDate       time.Time `form:"from_date" json:"from_date,omitempty"`
+	ToDate         time.Time `form:"to_date" json:"to_date,omitempty"`
 }
 
 type GetAnalyticsResponse struct {
-	DAU           int   `json:"dau" binding:"required"`
-	MAU           int   `json:"mau" binding:"required"`
-	TotalDuration int64 `json:"total_duration" binding:"required"`
-	TotalInstalls int   `json:"total_installs" binding:"required"`
+	DAU            int   `json:"dau" binding:"required"`
+	MAU            int   `json:"mau" binding:"required"`
+	TotalDuration  int64 `json:"total_duration" binding:"required"`
+	TotalInstalls  int   `json:"total_installs" binding:"required"`
+	WarnedEvents   int64 `json:"warned_events"`   // Events recorded despite failing schema validation
+	RejectedEvents int64 `json:"rejected_events"` // Events dropped for failing schema validation
+}
+
+type GetRetentionRequestQuery struct {
+	ProjectID  string    `form:"project_id" json:"project_id" binding:"required,uuid"`
+	FromDate   time.Time `form:"from_date" json:"from_date,omitempty"`                                              // First cohort day, defaults to 30 days before to_date
+	ToDate     time.Time `form:"to_date" json:"to_date,omitempty"`                                                  // Last cohort day, defaults to today
+	CohortBy   string    `form:"cohort_by" json:"cohort_by,omitempty" binding:"omitempty,oneof=first_seen install"` // Start devices on their first seen day (default) or install event day
+	MaxDay     int       `form:"max_day" json:"max_day,omitempty" binding:"omitempty,min=1,max=90"`                 // Last day after the cohort day to report, defaults to 30
+	Platform   string    `form:"platform" json:"platform,omitempty"`
+	Country    string    `form:"country" json:"country,omitempty"`
+	AppVersion string    `form:"app_version" json:"app_version,omitempty"`
+}
+
+type RetentionCohortResponse struct {
+	CohortDay string    `json:"cohort_day"` // YYYY-MM-DD, UTC
+	Size      int64     `json:"size"`       // Devices in the cohort
+	Retained  []int64   `json:"retained"`   // Devices with a session N days after the cohort day, index N; days still in the future are left out
+	Rates     []float64 `json:"rates"`      // Retained divided by size, index N
+}
+
+type GetRetentionResponse struct {
+	CohortBy string                    `json:"cohort_by"`
+	MaxDay   int                       `json:"max_day"`
+	D1       *float64                  `json:"d1"`  // Share of devices retained on day 1, over the cohorts that reached it
+	D7       *float64                  `json:"d7"`  // Share of devices retained on day 7, over the cohorts that reached it
+	D30      *float64                  `json:"d30"` // Share of devices retained on day 30, over the cohorts that reached it
+	Cohorts  []RetentionCohortResponse `json:"cohorts"`
+}
+
+type GetTimeseriesRequestQuery struct {
+	ProjectID      string    `form:"project_id" json:"project_id,omitempty"`
+	OrganizationID string    `form:"organization_id" json:"organization_id,omitempty" binding:"omitempty,uuid"`              // Only projects of this organization, ignored with project_id
+	FromDate       time.Time `form:"from_date" json:"from_date,omitempty"`                                                   // Defaults to 30 days before to_date
+	ToDate         time.Time `form:"to_date" json:"to_date,omitempty"`                                                       // Defaults to now
+	Granularity    string    `form:"granularity" json:"granularity,omitempty" binding:"omitempty,oneof=hour day week month"` // Defaults to day
+}
+
+type TimeseriesPointResponse struct {
+	Timestamp        time.Time `json:"timestamp"`          // Start of the bucket, UTC
+	ActiveDevices    int64     `json:"active_devices"`     // Distinct devices that started a session
+	NewDevices       int64     `json:"new_devices"`        // Devices first seen
+	Sessions         int64     `json:"sessions"`           // Sessions started
+	AvgSessionLength float64   `json:"avg_session_length"` // Average length of the ended sessions, in seconds
+}
+
+type GetTimeseriesResponse struct {
+	Granularity string                    `json:"granularity"`
+	FromDate    time.Time                 `json:"from_date"`
+	ToDate      time.Time                 `json:"to_date"`
+	Points      []TimeseriesPointResponse `json:"points"`
+}
+
+type GetFunnelRequestQuery struct {
+	ProjectID string `form:"project_id" json:"project_id" binding:"required,uuid"`
+}
+
+type FunnelStepRequest struct {
+	EventName string                 `json:"event_name" binding:"required"`
+	EventType string                 `json:"event_type"` // Any type matches when empty
+	Filters   map[string]interface{} `json:"filters"`    // Payload properties the event must have, compared for equality
+}
+
+type GetFunnelRequest struct {
+	FromDate time.Time           `json:"from_date"`                                  // Earliest first step, defaults to 30 days before to_date
+	ToDate   time.Time           `json:"to_date"`                                    // Latest first step, defaults to now
+	Window   string              `json:"window"`                                     // Time allowed from the first to the last step (e.g. 24h), defaults to until to_date
+	Steps    []FunnelStepRequest `json:"steps" binding:"required,min=2,max=10,dive"` // Ordered steps
+}
+
+type FunnelStepResponse struct {
+	Step                   int      `json:"step"` // 1-based position of the step
+	EventName              string   `json:"event_name"`
+	EventType              string   `json:"event_type,omitempty"`
+	Devices                int64    `json:"devices"`                   // Devices that reached the step
+	ConversionRate         float64  `json:"conversion_rate"`           // Devices divided by the devices of the first step
+	StepConversionRate     float64  `json:"step_conversion_rate"`      // Devices divided by the devices of the previous step
+	MedianSecondsFromPrior *float64 `json:"median_seconds_from_prior"` // Median time between the previous step and this one, in seconds
+}
+
+type GetFunnelResponse struct {
+	FromDate time.Time            `json:"from_date"`
+	ToDate   time.Time            `json:"to_date"`
+	Window   string               `json:"window,omitempty"`
+	Steps    []FunnelStepResponse `json:"steps"`
+}
+
+type AggregateEventsRequestQuery struct {
+	ProjectID string `form:"project_id" json:"project_id" binding:"required,uuid"`
+}
+
+type AggregateEventsRequest struct {
+	EventName   string                 `json:"event_name"`                                                                      // Only aggregate events with this name
+	EventType   string                 `json:"event_type"`                                                                      // Only aggregate events with this type
+	Filters     map[string]interface{} `json:"filters"`                                                                         // Payload properties the events must have, compared for equality
+	FromDate    time.Time              `json:"from_date"`                                                                       // Defaults to 30 days before to_date
+	ToDate      time.Time              `json:"to_date"`                                                                         // Defaults to now
+	Metric      string                 `json:"metric" binding:"required,oneof=count unique_devices sum avg min max percentile"` // Aggregate to compute
+	Property    string                 `json:"property"`                                                                        // Numeric payload key, required by sum, avg, min, max and percentile
+	Percentile  float64                `json:"percentile" binding:"omitempty,gt=0,lt=1"`                                        // Percentile to compute, e.g. 0.95
+	GroupBy     []string               `json:"group_by" binding:"omitempty,max=2"`                                              // Up to two of platform, country, region, city, app_version or payload.<key>
+	Granularity string                 `json:"granularity" binding:"omitempty,oneof=hour day week month"`                       // Bucket the results by time
+	Limit       int                    `json:"limit" binding:"omitempty,min=1,max=10000"`                                       // Maximum number of rows, defaults to 1000
+}
+
+type AggregateEventsRow struct {
+	Timestamp  *time.Time         `json:"timestamp,omitempty"` // Start of the time bucket, UTC
+	Dimensions map[string]*string `json:"dimensions,omitempty"`
+	Value      *float64           `json:"value"` // Null when no event had a numeric value for the property
+}
+
+type AggregateEventsResponse struct {
+	Metric    string               `json:"metric"`
+	Property  string               `json:"property,omitempty"`
+	GroupBy   []string             `json:"group_by,omitempty"`
+	Truncated bool                 `json:"truncated"` // More rows matched than the limit
+	Rows      []AggregateEventsRow `json:"rows"`
 }
@@ -0,0 +1,48 @@
+package dtos
+
+import (
+	"time"
+)
+
+type CreateApiKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"omitempty,dive,oneof=ingest read-config privacy"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type RevokeApiKeyRequestQuery struct {
+	GracePeriod string `form:"grace_period" json:"grace_period,omitempty"` // e.g. "72h"; revoke immediately when empty
+}
+
+type RegenerateApiKeyRequestQuery struct {
+	GracePeriod string `form:"grace_period,default=168h" json:"grace_period,omitempty"` // How long the current keys keep working
+}
+
+type ApiKeyResponse struct {
+	ApiKeyID   string     `json:"api_key_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	ApiKey     string     `json:"api_key,omitempty"` // Only returned once, when the key is created
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Active     bool       `json:"active"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type GetApiKeysResponse struct {
+	ApiKeys []ApiKeyResponse `json:"api_keys"`
+}
+
+type RegenerateApiKeyResponse struct {
+	ApiKey      ApiKeyResponse `json:"api_key"`
+	RetiredKeys int            `json:"retired_keys"`
+	RetiresAt   time.Time      `json:"retires_at"`
+}
+
+type RevokeApiKeyResponse struct {
+	Message   string    `json:"message"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
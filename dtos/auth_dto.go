@@ -10,18 +10,69 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
+	Token              string    `json:"token"`
+	ExpiresAt          time.Time `json:"expires_at"`
+	RefreshToken       string    `json:"refresh_token"`
+	RefreshExpiresAt   time.Time `json:"refresh_expires_at"`
+	MustChangePassword bool      `json:"must_change_password"` // Other routes are refused until POST /auth/password
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type RefreshResponse struct {
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
 }
 
 type MeResponse struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                 string    `json:"id"`
+	Email              string    `json:"email"`
+	Name               string    `json:"name"`
+	MustChangePassword bool      `json:"must_change_password"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type ChangePasswordResponse struct {
+	Message string `json:"message"`
+	Revoked int64  `json:"revoked"` // Other logins that were signed out
 }
 
 type LogoutResponse struct {
 	Message string `json:"message"`
 }
+
+type AuthSessionResponse struct {
+	SessionID       string    `json:"session_id"` // Token family ID
+	UserAgent       string    `json:"user_agent"`
+	IpAddress       string    `json:"ip_address"`
+	SignedInAt      time.Time `json:"signed_in_at"`
+	LastRefreshedAt time.Time `json:"last_refreshed_at"`
+	LastUsedAt      time.Time `json:"last_used_at"`
+	ExpiresAt       time.Time `json:"expires_at"` // Refresh token expiry
+	Current         bool      `json:"current"`
+}
+
+type GetAuthSessionsResponse struct {
+	Sessions []AuthSessionResponse `json:"sessions"`
+}
+
+type RevokeAuthSessionsRequestQuery struct {
+	IpAddress      string `form:"ip_address" json:"ip_address,omitempty"`
+	UserAgent      string `form:"user_agent" json:"user_agent,omitempty"`
+	IncludeCurrent bool   `form:"include_current" json:"include_current,omitempty"`
+}
+
+type RevokeAuthSessionsResponse struct {
+	Message string `json:"message"`
+	Revoked int    `json:"revoked"`
+}
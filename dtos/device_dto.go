@@ -23,12 +23,16 @@ type CreateOrUpdateDeviceResponse struct {
 	LastSeen        time.Time `json:"last_seen"`
 	IpAddress       string    `json:"ip_address"`
 	Country         string    `json:"country"`
+	Region          string    `json:"region"`
+	City            string    `json:"city"`
 }
 
 type GetDevicesRequestQuery struct {
-	Platform string `form:"platform" json:"platform,omitempty"`
-	Limit    int    `form:"limit,default=20" json:"limit,omitempty"`
-	Offset   int    `form:"offset,default=0" json:"offset,omitempty"`
+	ProjectID      string `form:"project_id" json:"project_id,omitempty"`
+	OrganizationID string `form:"organization_id" json:"organization_id,omitempty" binding:"omitempty,uuid"` // Only projects of this organization, ignored with project_id
+	Platform       string `form:"platform" json:"platform,omitempty"`
+	Limit          int    `form:"limit,default=20" json:"limit,omitempty"`
+	Offset         int    `form:"offset,default=0" json:"offset,omitempty"`
 }
 
 type GetDevicesResponse struct {
@@ -48,6 +52,8 @@ type GetDeviceResponse struct {
 	LastSeen        time.Time `json:"last_seen"`
 	IpAddress       string    `json:"ip_address"`
 	Country         string    `json:"country"`
+	Region          string    `json:"region"`
+	City            string    `json:"city"`
 }
 
 type GetDeviceResponseDetail struct {
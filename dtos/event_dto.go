@@ -5,34 +5,69 @@ import (
 )
 
 type RecordEventRequest struct {
+	EventID    string                 `json:"event_id" binding:"omitempty,uuid"` // Optional client-generated UUID used to deduplicate retries
 	Identifier string                 `json:"identifier" binding:"required"`
-	EventType  string                 `json:"event_type" binding:"required"`
+	SessionID  string                 `json:"session_id" binding:"omitempty,uuid"` // Session the event happened in, defaults to the device's open session
+	EventType  string                 `json:"event_type" binding:"required,max=50"`
 	EventName  string                 `json:"event_name" binding:"required"`
 	Payloads   map[string]interface{} `json:"payloads"`
 	Timestamp  *time.Time             `json:"timestamp"`
 }
 
 type RecordEventResponse struct {
-	Message string `json:"message"`
+	Message   string   `json:"message"`
+	Duplicate bool     `json:"duplicate"`          // The event ID was already received, nothing was recorded
+	Warnings  []string `json:"warnings,omitempty"` // Schema violations of an event accepted in warn mode
 }
 
 type RecordEventsRequest struct {
 	Events []RecordEventRequest `json:"events" binding:"required"`
 }
 
+// Status of a single event in a batch
+const (
+	EventStatusAccepted  = "accepted"
+	EventStatusDuplicate = "duplicate"
+	EventStatusRejected  = "rejected"
+)
+
+// Reason codes for rejected events. Rejected events will never be accepted as sent,
+// SDKs should drop them instead of retrying.
+const (
+	EventRejectMalformed      = "malformed" // The event is not a valid JSON object
+	EventRejectInvalid        = "invalid"   // A required field is missing or has an invalid or too long value
+	EventRejectInvalidID      = "invalid_event_id"
+	EventRejectUnknownDevice  = "unknown_device"   // The identifier is not a registered device of the project
+	EventRejectSchema         = "schema_violation" // The event doesn't match the project's schema registry
+	EventRejectUnknownSession = "unknown_session"  // The session doesn't exist or belongs to another device
+)
+
+type RecordEventResult struct {
+	Index    int      `json:"index"`              // Position of the event in the request
+	EventID  string   `json:"event_id,omitempty"` // Client event ID, when one was sent
+	Status   string   `json:"status"`             // accepted, duplicate or rejected
+	Reason   string   `json:"reason,omitempty"`   // Reason code of a rejected event
+	Error    string   `json:"error,omitempty"`    // Human readable detail of the rejection
+	Warnings []string `json:"warnings,omitempty"` // Schema violations of an event accepted in warn mode
+}
+
 type RecordEventsResponse struct {
-	Message string `json:"message"`
-	Count   int    `json:"count"`
+	Message    string              `json:"message"`
+	Count      int                 `json:"count"`      // Number of new events accepted
+	Duplicates int                 `json:"duplicates"` // Number of events skipped because their ID was already received
+	Rejected   int                 `json:"rejected"`   // Number of events that failed validation
+	Results    []RecordEventResult `json:"results"`    // One result per event, in request order
 }
 
 type GetEventsRequestQuery struct {
-	ProjectID string `form:"project_id" json:"project_id,omitempty"`
-	FromDate  string `form:"from_date" json:"from_date,omitempty"`
-	ToDate    string `form:"to_date" json:"to_date,omitempty"`
-	EventType string `form:"event_type" json:"event_type,omitempty"`
-	EventName string `form:"event_name" json:"event_name,omitempty"`
-	Limit     int    `form:"limit" json:"limit,omitempty"`
-	Offset    int    `form:"offset" json:"offset,omitempty"`
+	ProjectID      string `form:"project_id" json:"project_id,omitempty"`
+	OrganizationID string `form:"organization_id" json:"organization_id,omitempty" binding:"omitempty,uuid"` // Only projects of this organization, ignored with project_id
+	FromDate       string `form:"from_date" json:"from_date,omitempty"`
+	ToDate         string `form:"to_date" json:"to_date,omitempty"`
+	EventType      string `form:"event_type" json:"event_type,omitempty"`
+	EventName      string `form:"event_name" json:"event_name,omitempty"`
+	Limit          int    `form:"limit" json:"limit,omitempty"`
+	Offset         int    `form:"offset" json:"offset,omitempty"`
 }
 
 type GetEventsResponse struct {
@@ -40,15 +75,13 @@ type GetEventsResponse struct {
 	Total  int                `json:"total"`
 }
 
-type GetEventRequest struct {
-	EventID string `form:"event_id" json:"event_id,omitempty"`
-}
-
 type GetEventResponse struct {
-	EventID    string                 `json:"event_id"`
-	EventType  string                 `json:"event_type"`
-	EventName  string                 `json:"event_name"`
-	Payloads   map[string]interface{} `json:"payloads"`
-	Timestamp  string                 `json:"timestamp"`
-	ReceivedAt string                 `json:"received_at"`
+	EventID       string                 `json:"event_id"`
+	ClientEventID string                 `json:"client_event_id,omitempty"`
+	SessionID     string                 `json:"session_id,omitempty"`
+	EventType     string                 `json:"event_type"`
+	EventName     string                 `json:"event_name"`
+	Payloads      map[string]interface{} `json:"payloads"`
+	Timestamp     string                 `json:"timestamp"`
+	ReceivedAt    string                 `json:"received_at"`
 }
@@ -0,0 +1,73 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+)
+
+type CreateEventSchemaRequest struct {
+	EventName   string               `json:"event_name" binding:"required"`
+	EventType   string               `json:"event_type" binding:"omitempty,max=50"` // Expected event type, any type is allowed when empty
+	Description string               `json:"description"`
+	Schema      models.PayloadSchema `json:"schema"` // JSON Schema subset describing the payloads
+}
+
+type UpdateEventSchemaRequest struct {
+	EventName   string               `json:"event_name" binding:"required"`
+	EventType   string               `json:"event_type" binding:"omitempty,max=50"`
+	Description string               `json:"description"`
+	Schema      models.PayloadSchema `json:"schema"`
+}
+
+type EventSchemaResponse struct {
+	SchemaID    string               `json:"schema_id"`
+	EventName   string               `json:"event_name"`
+	EventType   string               `json:"event_type,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Schema      models.PayloadSchema `json:"schema"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+type GetEventSchemasResponse struct {
+	Mode    string                `json:"mode"` // off, warn or reject
+	Schemas []EventSchemaResponse `json:"schemas"`
+}
+
+type DeleteEventSchemaResponse struct {
+	Message string `json:"message"`
+}
+
+type UpdateSchemaModeRequest struct {
+	Mode string `json:"mode" binding:"required,oneof=off warn reject"`
+}
+
+type UpdateSchemaModeResponse struct {
+	Mode string `json:"mode"`
+}
+
+type GetSchemaStatsRequestQuery struct {
+	FromDate time.Time `form:"from_date" json:"from_date,omitempty"`
+	ToDate   time.Time `form:"to_date" json:"to_date,omitempty"`
+}
+
+type SchemaEventStatResponse struct {
+	EventName string `json:"event_name"`
+	Warned    int64  `json:"warned"`
+	Rejected  int64  `json:"rejected"`
+}
+
+type SchemaDayStatResponse struct {
+	Day      string `json:"day"` // YYYY-MM-DD, UTC
+	Warned   int64  `json:"warned"`
+	Rejected int64  `json:"rejected"`
+}
+
+type GetSchemaStatsResponse struct {
+	Mode     string                    `json:"mode"`
+	Warned   int64                     `json:"warned"`   // Invalid events recorded in warn mode
+	Rejected int64                     `json:"rejected"` // Invalid events dropped in reject mode
+	Events   []SchemaEventStatResponse `json:"events"`
+	Days     []SchemaDayStatResponse   `json:"days"`
+}
@@ -0,0 +1,26 @@
+package dtos
+
+import (
+	"time"
+)
+
+type LiveWindowResponse struct {
+	Events          int64            `json:"events"`
+	EventsByName    map[string]int64 `json:"events_by_name"`
+	NewDevices      int64            `json:"new_devices"`
+	SessionsStarted int64            `json:"sessions_started"`
+	SessionsEnded   int64            `json:"sessions_ended"`
+}
+
+type LiveMetricsResponse struct {
+	ProjectID    string             `json:"project_id"`
+	Timestamp    time.Time          `json:"timestamp"`
+	OpenSessions int64              `json:"open_sessions"` // Sessions started and not ended yet
+	LastSecond   LiveWindowResponse `json:"last_second"`
+	LastMinute   LiveWindowResponse `json:"last_minute"` // Rolling sum of the last 60 seconds
+}
+
+type CreateStreamTokenResponse struct {
+	Token     string    `json:"token"` // Single-use, pass it as the token query parameter of the stream
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,50 @@
+package dtos
+
+import (
+	"time"
+)
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type UpdateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type OrganizationResponse struct {
+	OrganizationID string    `json:"organization_id"`
+	Name           string    `json:"name"`
+	Role           string    `json:"role"` // Role of the requesting user in the organization
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type GetOrganizationsResponse struct {
+	Organizations []OrganizationResponse `json:"organizations"`
+}
+
+type DeleteOrganizationResponse struct {
+	Message string `json:"message"`
+}
+
+type GetOrganizationUsageRequestQuery struct {
+	From string `form:"from" json:"from,omitempty"` // First month, YYYY-MM, 11 months before To by default
+	To   string `form:"to" json:"to,omitempty"`     // Last month, YYYY-MM, the current month by default
+}
+
+type GetOrganizationUsageResponse struct {
+	OrganizationID string                 `json:"organization_id"`
+	Months         []MonthlyUsageResponse `json:"months"`
+}
+
+type MonthlyUsageResponse struct {
+	Month          string                 `json:"month"`           // YYYY-MM, in UTC
+	EventsIngested int64                  `json:"events_ingested"` // Events of every project of the month
+	Projects       []ProjectUsageResponse `json:"projects"`
+}
+
+type ProjectUsageResponse struct {
+	ProjectID      string `json:"project_id"`
+	Name           string `json:"name"`
+	EventsIngested int64  `json:"events_ingested"`
+}
@@ -0,0 +1,30 @@
+package dtos
+
+import (
+	"time"
+)
+
+type AddOrganizationMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=owner admin member"`
+}
+
+type UpdateOrganizationMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner admin member"`
+}
+
+type OrganizationMemberResponse struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetOrganizationMembersResponse struct {
+	Members []OrganizationMemberResponse `json:"members"`
+}
+
+type RemoveOrganizationMemberResponse struct {
+	Message string `json:"message"`
+}
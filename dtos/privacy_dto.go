@@ -0,0 +1,50 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+)
+
+type ExportDeviceDataRequestQuery struct {
+	Identifier string `form:"identifier" json:"identifier" binding:"required"`
+	Format     string `form:"format,default=json" json:"format" binding:"omitempty,oneof=json zip"`
+}
+
+// DeviceDataExport holds every stored row about a device identifier, deleted ones included
+type DeviceDataExport struct {
+	Identifier string           `json:"identifier"`
+	ProjectID  string           `json:"project_id"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Devices    []models.Device  `json:"devices"`
+	Sessions   []models.Session `json:"sessions"`
+	Events     []models.Event   `json:"events"`
+}
+
+type EraseDeviceDataRequest struct {
+	Identifier string `json:"identifier" binding:"required"`
+}
+
+type EraseDeviceDataResponse struct {
+	Message string             `json:"message"`
+	Erasure ErasureLogResponse `json:"erasure"`
+}
+
+type ErasureLogResponse struct {
+	ErasureID      string    `json:"erasure_id"`
+	Sequence       int64     `json:"sequence"`
+	IdentifierHash string    `json:"identifier_hash"` // HMAC-SHA256 of the erased identifier, keyed per project
+	Devices        int64     `json:"devices"`
+	Sessions       int64     `json:"sessions"`
+	Events         int64     `json:"events"`
+	RequestedBy    string    `json:"requested_by"`
+	ErasedAt       time.Time `json:"erased_at"`
+	PreviousHash   string    `json:"previous_hash"`
+	Hash           string    `json:"hash"`
+}
+
+type GetErasureLogsResponse struct {
+	Erasures   []ErasureLogResponse `json:"erasures"`
+	ChainValid bool                 `json:"chain_valid"`
+	BrokenAt   int64                `json:"broken_at,omitempty"` // Sequence of the first entry that was tampered with
+}
@@ -1,18 +1,26 @@
 package dtos
 
 import (
+	"time"
+
 	"github.com/atqamz/kogase-backend/models"
 )
 
 type CreateProjectRequest struct {
-	Name string `json:"name" binding:"required"`
+	Name           string `json:"name" binding:"required"`
+	OrganizationID string `json:"organization_id" binding:"omitempty,uuid"` // Organization of the project, optional when the user belongs to at most one
 }
 
 type CreateProjectResponse struct {
-	ProjectID string   `json:"project_id"`
-	Name      string   `json:"name"`
-	ApiKey    string   `json:"api_key"`
-	Owner     OwnerDto `json:"owner"`
+	ProjectID      string   `json:"project_id"`
+	OrganizationID string   `json:"organization_id"`
+	Name           string   `json:"name"`
+	ApiKey         string   `json:"api_key"` // Only returned once, store it securely
+	Owner          OwnerDto `json:"owner"`
+}
+
+type GetProjectsRequestQuery struct {
+	OrganizationID string `form:"organization_id" json:"organization_id,omitempty" binding:"omitempty,uuid"`
 }
 
 type GetProjectsResponse struct {
@@ -20,10 +28,13 @@ type GetProjectsResponse struct {
 }
 
 type GetProjectResponse struct {
-	ProjectID string   `json:"project_id"`
-	Name      string   `json:"name"`
-	ApiKey    string   `json:"api_key"`
-	Owner     OwnerDto `json:"owner"`
+	ProjectID      string   `json:"project_id"`
+	OrganizationID string   `json:"organization_id"`
+	Name           string   `json:"name"`
+	Role           string   `json:"role,omitempty"`  // Role of the requesting user in the project
+	SessionTimeout string   `json:"session_timeout"` // Idle time after which open sessions are ended
+	IPPrivacy      string   `json:"ip_privacy"`      // How device IP addresses are stored: full, truncate, hash or none
+	Owner          OwnerDto `json:"owner"`
 }
 
 type GetProjectResponseDetail struct {
@@ -33,22 +44,65 @@ type GetProjectResponseDetail struct {
 }
 
 type UpdateProjectRequest struct {
-	Name string `json:"name" binding:"omitempty"`
+	Name           string `json:"name" binding:"omitempty"`
+	SessionTimeout string `json:"session_timeout" binding:"omitempty"` // Idle time after which open sessions are ended (e.g. 30m), between 1m and 24h
+	IPPrivacy      string `json:"ip_privacy" binding:"omitempty"`      // How device IP addresses are stored: full, truncate (/24 or /48), hash or none
+
+	EventRetentionDays   *int `json:"event_retention_days" binding:"omitempty,min=0,max=3650"`   // Days events are kept, 0 keeps them forever
+	SessionRetentionDays *int `json:"session_retention_days" binding:"omitempty,min=0,max=3650"` // Days sessions are kept, 0 keeps them forever
 }
 
 type UpdateProjectResponse struct {
-	ProjectID string   `json:"project_id"`
-	Name      string   `json:"name"`
-	ApiKey    string   `json:"api_key"`
-	Owner     OwnerDto `json:"owner"`
+	ProjectID      string   `json:"project_id"`
+	Name           string   `json:"name"`
+	SessionTimeout string   `json:"session_timeout"`
+	IPPrivacy      string   `json:"ip_privacy"`
+	Owner          OwnerDto `json:"owner"`
+}
+
+type RewriteDeviceIPsResponse struct {
+	Message   string `json:"message"`
+	IPPrivacy string `json:"ip_privacy"`
 }
 
 type DeleteProjectResponse struct {
 	Message string `json:"message"`
 }
 
+type TransferProjectRequest struct {
+	OrganizationID string `json:"organization_id" binding:"required,uuid"` // Organization receiving the project
+}
+
+type TransferProjectResponse struct {
+	Message        string `json:"message"`
+	ProjectID      string `json:"project_id"`
+	OrganizationID string `json:"organization_id"`
+}
+
 type OwnerDto struct {
 	ID    string `json:"id"`
 	Email string `json:"email"`
 	Name  string `json:"name"`
 }
+
+type GetDataRetentionResponse struct {
+	EventRetentionDays   int                 `json:"event_retention_days"`
+	SessionRetentionDays int                 `json:"session_retention_days"`
+	LastRun              *PurgeRunResponse   `json:"last_run"`     // Null until the purge job ran for the project
+	TotalPurged          DataRetentionTotals `json:"total_purged"` // Rows purged by the runs still in the history (90 days)
+}
+
+type PurgeRunResponse struct {
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+	EventsPurged   int64     `json:"events_purged"`
+	SessionsPurged int64     `json:"sessions_purged"`
+	DevicesPurged  int64     `json:"devices_purged"`
+	Error          string    `json:"error,omitempty"`
+}
+
+type DataRetentionTotals struct {
+	Events   int64 `json:"events"`
+	Sessions int64 `json:"sessions"`
+	Devices  int64 `json:"devices"`
+}
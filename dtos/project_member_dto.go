@@ -0,0 +1,30 @@
+package dtos
+
+import (
+	"time"
+)
+
+type AddProjectMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=owner admin analyst viewer"`
+}
+
+type UpdateProjectMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner admin analyst viewer"`
+}
+
+type ProjectMemberResponse struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetProjectMembersResponse struct {
+	Members []ProjectMemberResponse `json:"members"`
+}
+
+type RemoveProjectMemberResponse struct {
+	Message string `json:"message"`
+}
@@ -13,19 +13,29 @@ type BeginSessionResponse struct {
 }
 
 type EndSessionRequest struct {
-	SessionID string `json:"session_id" binding:"required"`
+	SessionID string `json:"session_id" binding:"required,uuid"`
 }
 
 type EndSessionResponse struct {
 	Message string `json:"message"`
 }
 
+type SessionHeartbeatRequest struct {
+	SessionID string `json:"session_id" binding:"required,uuid"`
+}
+
+type SessionHeartbeatResponse struct {
+	Message        string    `json:"message"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+}
+
 type GetSessionsRequestQuery struct {
-	ProjectID string    `form:"project_id" json:"project_id,omitempty"`
-	FromDate  time.Time `form:"from_date" json:"from_date,omitempty"`
-	ToDate    time.Time `form:"to_date" json:"to_date,omitempty"`
-	Limit     int       `form:"limit,default=20" json:"limit,omitempty"`
-	Offset    int       `form:"offset,default=0" json:"offset,omitempty"`
+	ProjectID      string    `form:"project_id" json:"project_id,omitempty"`
+	OrganizationID string    `form:"organization_id" json:"organization_id,omitempty" binding:"omitempty,uuid"` // Only projects of this organization, ignored with project_id
+	FromDate       time.Time `form:"from_date" json:"from_date,omitempty"`
+	ToDate         time.Time `form:"to_date" json:"to_date,omitempty"`
+	Limit          int       `form:"limit,default=20" json:"limit,omitempty"`
+	Offset         int       `form:"offset,default=0" json:"offset,omitempty"`
 }
 
 type GetSessionsResponse struct {
@@ -40,8 +50,15 @@ type GetSessionRequest struct {
 }
 
 type GetSessionResponse struct {
-	SessionID string    `json:"session_id"`
-	BeginAt   time.Time `json:"begin_at"`
-	EndAt     time.Time `json:"end_at"`
-	Duration  int64     `json:"duration"` // Duration in nanoseconds
+	SessionID      string    `json:"session_id"`
+	BeginAt        time.Time `json:"begin_at"`
+	EndAt          time.Time `json:"end_at"`
+	Duration       int64     `json:"duration"` // Duration in nanoseconds
+	LastActivityAt time.Time `json:"last_activity_at"`
+	EventCount     int64     `json:"event_count"` // Events recorded during the session
+}
+
+type GetSessionEventsRequestQuery struct {
+	Limit  int `form:"limit,default=20" json:"limit,omitempty"`
+	Offset int `form:"offset,default=0" json:"offset,omitempty"`
 }
@@ -0,0 +1,18 @@
+package dtos
+
+type GetSetupResponse struct {
+	SetupRequired bool `json:"setup_required"` // No users exist yet
+}
+
+type CompleteSetupRequest struct {
+	Token    string `json:"token" binding:"required"` // One-time setup token printed at startup
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+	Name     string `json:"name" binding:"required"`
+}
+
+type CompleteSetupResponse struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Name   string `json:"name"`
+}
@@ -0,0 +1,188 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// deadLetterReplayBatchSize bounds the number of dead letters written back at once
+const deadLetterReplayBatchSize = 500
+
+// deadLetterMaxAttempts is the number of times a dead letter is written back before it is
+// left for an operator to look at
+const deadLetterMaxAttempts = 10
+
+// deadLetter keeps events that could not be written. When even that fails the events are
+// logged, the log is the last place they can be recovered from.
+func (p *Pipeline) deadLetter(events []models.Event, cause error) {
+	letters := make([]models.EventDeadLetter, 0, len(events))
+	for _, event := range events {
+		letter, err := models.NewEventDeadLetter(event, cause)
+		if err != nil {
+			log.Printf("Failed to encode event %s as a dead letter: %v", event.ID, err)
+			continue
+		}
+		letters = append(letters, letter)
+	}
+
+	err := retry(func() error {
+		return p.db.CreateInBatches(letters, p.config.BatchSize).Error
+	})
+	if err != nil {
+		log.Printf("Failed to keep %d events as dead letters: %v", len(letters), err)
+		for _, letter := range letters {
+			log.Printf("Lost event: %s", letter.Event)
+		}
+	}
+}
+
+// ReplayDeadLetters writes dead-lettered events back and returns how many were written.
+// Letters whose device was erased in the meantime are dropped.
+func (p *Pipeline) ReplayDeadLetters(ctx context.Context) (int, error) {
+	db := p.db.WithContext(ctx)
+
+	var replayed int
+	for {
+		var letters []models.EventDeadLetter
+		if err := db.Where("attempts < ?", deadLetterMaxAttempts).
+			Order("created_at").
+			Limit(deadLetterReplayBatchSize).
+			Find(&letters).Error; err != nil {
+			return replayed, err
+		}
+		if len(letters) == 0 {
+			return replayed, nil
+		}
+
+		written, failed, err := p.replayDeadLetters(db, letters)
+		replayed += written
+		if err != nil {
+			return replayed, err
+		}
+		// Letters that failed again are picked up by the next run
+		if failed > 0 || len(letters) < deadLetterReplayBatchSize {
+			return replayed, nil
+		}
+	}
+}
+
+// replayDeadLetters writes a batch of dead letters back. When the batch fails as a whole
+// each letter is tried on its own, so one bad event doesn't hold back the others. It
+// returns how many letters were written and how many failed again.
+func (p *Pipeline) replayDeadLetters(db *gorm.DB, letters []models.EventDeadLetter) (int, int, error) {
+	events, dropped, err := p.decodeDeadLetters(db, letters)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(dropped) > 0 {
+		if err := db.Delete(&models.EventDeadLetter{}, "id IN ?", dropped).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+	if len(events) == 0 {
+		return 0, 0, nil
+	}
+
+	if inserted, err := p.writeDeadLetters(db, events); err == nil {
+		p.afterInsert(inserted)
+		return len(events), 0, nil
+	}
+
+	var written, inserted []models.Event
+	var failed int
+	for letterID, event := range events {
+		single := map[uuid.UUID]models.Event{letterID: event}
+		insertedEvents, err := p.writeDeadLetters(db, single)
+		if err != nil {
+			if updateErr := db.Model(&models.EventDeadLetter{}).
+				Where("id = ?", letterID).
+				Updates(map[string]interface{}{
+					"attempts": gorm.Expr("attempts + 1"),
+					"error":    err.Error(),
+				}).Error; updateErr != nil {
+				return len(written), failed, updateErr
+			}
+			failed++
+			continue
+		}
+		written = append(written, event)
+		inserted = append(inserted, insertedEvents...)
+	}
+
+	p.afterInsert(inserted)
+	return len(written), failed, nil
+}
+
+// decodeDeadLetters returns the events of the letters by letter ID, and the IDs of the
+// letters to drop because their event can't be decoded or its device no longer exists
+func (p *Pipeline) decodeDeadLetters(db *gorm.DB, letters []models.EventDeadLetter) (map[uuid.UUID]models.Event, []uuid.UUID, error) {
+	events := make(map[uuid.UUID]models.Event, len(letters))
+	var dropped []uuid.UUID
+	deviceIDs := make([]uuid.UUID, 0, len(letters))
+	for _, letter := range letters {
+		event, err := letter.DecodeEvent()
+		if err != nil {
+			log.Printf("Dropping dead letter %s, its event can't be decoded: %v", letter.ID, err)
+			dropped = append(dropped, letter.ID)
+			continue
+		}
+		// The write time must be the replay's, rollups look for rows written since their last run
+		event.CreatedAt = time.Time{}
+		event.UpdatedAt = time.Time{}
+		events[letter.ID] = event
+		deviceIDs = append(deviceIDs, event.DeviceID)
+	}
+
+	var existing []uuid.UUID
+	if err := db.Model(&models.Device{}).
+		Where("id IN ?", deviceIDs).
+		Pluck("id", &existing).Error; err != nil {
+		return nil, nil, err
+	}
+
+	devices := make(map[uuid.UUID]bool, len(existing))
+	for _, id := range existing {
+		devices[id] = true
+	}
+	for letterID, event := range events {
+		if _, erased := p.erased.Get(event.DeviceID); erased || !devices[event.DeviceID] {
+			dropped = append(dropped, letterID)
+			delete(events, letterID)
+		}
+	}
+
+	return events, dropped, nil
+}
+
+// writeDeadLetters writes the events and deletes their dead letters in one transaction.
+// It returns the events written, retries of events written meanwhile are skipped.
+func (p *Pipeline) writeDeadLetters(db *gorm.DB, events map[uuid.UUID]models.Event) ([]models.Event, error) {
+	letterIDs := make([]uuid.UUID, 0, len(events))
+	for letterID := range events {
+		letterIDs = append(letterIDs, letterID)
+	}
+
+	var inserted []models.Event
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if inserted, err = p.insertEvents(tx, eventsOf(events)); err != nil {
+			return err
+		}
+		return tx.Delete(&models.EventDeadLetter{}, "id IN ?", letterIDs).Error
+	})
+	return inserted, err
+}
+
+func eventsOf(events map[uuid.UUID]models.Event) []models.Event {
+	result := make([]models.Event, 0, len(events))
+	for _, event := range events {
+		result = append(result, event)
+	}
+	return result
+}
@@ -0,0 +1,30 @@
+package ingest
+
+import (
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/google/uuid"
+)
+
+// erasedDeviceCacheSize bounds the number of erased devices remembered. Events only wait
+// in memory for a flush interval, so only the latest erasures matter.
+const erasedDeviceCacheSize = 1024
+
+// ForgetDevice drops an erased device from the caches and discards its events that are
+// still waiting to be written. It must be called before the device rows are deleted.
+func (p *Pipeline) ForgetDevice(projectID uuid.UUID, identifier string, deviceIDs []uuid.UUID) {
+	p.devices.Remove(deviceCacheKey(projectID, identifier))
+	for _, deviceID := range deviceIDs {
+		p.erased.Add(deviceID, struct{}{})
+	}
+}
+
+// dropErasedDevices filters out the events of erased devices, which could no longer be written
+func (p *Pipeline) dropErasedDevices(events []models.Event) []models.Event {
+	kept := events[:0]
+	for _, event := range events {
+		if _, erased := p.erased.Get(event.DeviceID); !erased {
+			kept = append(kept, event)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,477 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/atqamz/kogase-backend/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrQueueFull is returned when accepting the events would exceed the queue capacity
+	ErrQueueFull = errors.New("ingestion queue is full")
+
+	// ErrStopped is returned when events are submitted after the pipeline was stopped
+	ErrStopped = errors.New("ingestion pipeline is stopped")
+)
+
+// deviceCacheTTL bounds how long a resolved device ID is trusted before it is looked up again
+const deviceCacheTTL = 5 * time.Minute
+
+// flushAttempts is the number of times a batch is written before it is dead-lettered
+const flushAttempts = 5
+
+// flushBackoff is the wait before the first retry of a failed write, doubled after each
+// retry so a database failover has time to complete
+const flushBackoff = 500 * time.Millisecond
+
+// schemaCacheSize is the number of projects whose schema registry is kept in memory
+const schemaCacheSize = 1024
+
+// Config controls the size and pacing of the ingestion pipeline
+type Config struct {
+	Workers       int           // Number of goroutines writing events to the database
+	QueueSize     int           // Maximum number of events waiting to be written
+	BatchSize     int           // Maximum number of events per multi-row insert
+	FlushInterval time.Duration // Maximum time an event waits in a worker buffer
+}
+
+type cachedDevice struct {
+	id       uuid.UUID
+	cachedAt time.Time
+}
+
+// Pipeline accepts events in memory and writes them to the database in the background
+type Pipeline struct {
+	db       *gorm.DB
+	config   Config
+	queue    chan models.Event
+	pending  atomic.Int64
+	devices  *utils.LRUCache[string, cachedDevice]
+	sessions *utils.LRUCache[uuid.UUID, cachedSession]
+	erased   *utils.LRUCache[uuid.UUID, struct{}] // Devices whose data was erased
+
+	inflightMu sync.Mutex
+	inflight   map[string]struct{} // Client event IDs queued but not yet written
+
+	schemas *utils.LRUCache[uuid.UUID, *SchemaSet]
+	stats   validationStats
+
+	mu      sync.RWMutex
+	stopped bool
+	done    chan struct{} // Closed when the pipeline stops
+	wg      sync.WaitGroup
+}
+
+// NewPipeline creates a pipeline and starts its workers
+func NewPipeline(db *gorm.DB, config Config) *Pipeline {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.QueueSize < 1 {
+		config.QueueSize = 1
+	}
+	if config.BatchSize < 1 {
+		config.BatchSize = 1
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+
+	p := &Pipeline{
+		db:       db,
+		config:   config,
+		queue:    make(chan models.Event, config.QueueSize),
+		devices:  utils.NewLRUCache[string, cachedDevice](config.QueueSize),
+		sessions: utils.NewLRUCache[uuid.UUID, cachedSession](config.QueueSize),
+		inflight: make(map[string]struct{}),
+		erased:   utils.NewLRUCache[uuid.UUID, struct{}](erasedDeviceCacheSize),
+		schemas:  utils.NewLRUCache[uuid.UUID, *SchemaSet](schemaCacheSize),
+		stats:    validationStats{counts: make(map[validationStatKey]*validationCounts)},
+		done:     make(chan struct{}),
+	}
+
+	for i := 0; i < config.Workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+
+	p.wg.Add(1)
+	go p.reportStats()
+
+	return p
+}
+
+// Enqueue accepts all of the new events or none of them. Events carrying a client
+// event ID that was already received, either earlier in the batch, still queued or
+// already stored, are skipped and reported as duplicates in the returned slice.
+// Enqueue never blocks: when the queue cannot hold every new event it returns ErrQueueFull.
+func (p *Pipeline) Enqueue(events ...models.Event) ([]bool, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		return nil, ErrStopped
+	}
+
+	duplicates, claimed, err := p.claimClientEventIDs(events)
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := make([]models.Event, 0, len(events))
+	for i, event := range events {
+		if !duplicates[i] {
+			fresh = append(fresh, event)
+		}
+	}
+
+	// Reserve room for the whole batch so the channel sends below never block
+	count := int64(len(fresh))
+	if p.pending.Add(count) > int64(p.config.QueueSize) {
+		p.pending.Add(-count)
+		p.releaseClientEventIDs(claimed)
+		return nil, ErrQueueFull
+	}
+
+	now := time.Now()
+	for _, event := range fresh {
+		if event.ID == uuid.Nil {
+			event.ID = uuid.New()
+		}
+		if event.ReceivedAt.IsZero() {
+			event.ReceivedAt = now
+		}
+		p.queue <- event
+	}
+
+	return duplicates, nil
+}
+
+// claimClientEventIDs marks the client event IDs of the events as in flight and
+// reports which events repeat an ID that was already received. It returns the
+// claimed keys so they can be released if the events are not queued after all.
+func (p *Pipeline) claimClientEventIDs(events []models.Event) ([]bool, []string, error) {
+	duplicates := make([]bool, len(events))
+	claimedIndexes := make(map[string]int)
+
+	p.inflightMu.Lock()
+	for i, event := range events {
+		if event.ClientEventID == nil {
+			continue
+		}
+
+		key := clientEventKey(event.ProjectID, *event.ClientEventID)
+		if _, queued := p.inflight[key]; queued {
+			duplicates[i] = true
+			continue
+		}
+
+		p.inflight[key] = struct{}{}
+		claimedIndexes[key] = i
+	}
+	p.inflightMu.Unlock()
+
+	if len(claimedIndexes) == 0 {
+		return duplicates, nil, nil
+	}
+
+	// Look for client event IDs that were already written
+	byProject := make(map[uuid.UUID][]uuid.UUID)
+	for _, i := range claimedIndexes {
+		byProject[events[i].ProjectID] = append(byProject[events[i].ProjectID], *events[i].ClientEventID)
+	}
+
+	var stored []string
+	for projectID, clientEventIDs := range byProject {
+		var existing []uuid.UUID
+		if err := p.db.Model(&models.EventClientID{}).
+			Where("project_id = ? AND client_event_id IN ?", projectID, clientEventIDs).
+			Pluck("client_event_id", &existing).Error; err != nil {
+			p.releaseClientEventIDs(keys(claimedIndexes))
+			return nil, nil, err
+		}
+
+		for _, clientEventID := range existing {
+			key := clientEventKey(projectID, clientEventID)
+			duplicates[claimedIndexes[key]] = true
+			delete(claimedIndexes, key)
+			stored = append(stored, key)
+		}
+	}
+	p.releaseClientEventIDs(stored)
+
+	return duplicates, keys(claimedIndexes), nil
+}
+
+func (p *Pipeline) releaseClientEventIDs(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	p.inflightMu.Lock()
+	defer p.inflightMu.Unlock()
+
+	for _, key := range keys {
+		delete(p.inflight, key)
+	}
+}
+
+// Stop stops accepting events and waits until every queued event has been written
+func (p *Pipeline) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.queue)
+		close(p.done)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ResolveDevices maps device identifiers of a project to device IDs. Identifiers
+// that do not belong to the project are missing from the result.
+func (p *Pipeline) ResolveDevices(projectID uuid.UUID, identifiers []string) (map[string]uuid.UUID, error) {
+	resolved := make(map[string]uuid.UUID, len(identifiers))
+	var missing []string
+
+	now := time.Now()
+	for _, identifier := range identifiers {
+		if _, done := resolved[identifier]; done {
+			continue
+		}
+		if device, ok := p.devices.Get(deviceCacheKey(projectID, identifier)); ok && now.Sub(device.cachedAt) < deviceCacheTTL {
+			resolved[identifier] = device.id
+			continue
+		}
+		missing = append(missing, identifier)
+	}
+
+	if len(missing) == 0 {
+		return resolved, nil
+	}
+
+	var devices []models.Device
+	if err := p.db.Model(&models.Device{}).
+		Select("id, identifier").
+		Where("project_id = ? AND identifier IN ?", projectID, missing).
+		Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	for _, device := range devices {
+		resolved[device.Identifier] = device.ID
+		p.devices.Add(deviceCacheKey(projectID, device.Identifier), cachedDevice{id: device.ID, cachedAt: now})
+	}
+
+	return resolved, nil
+}
+
+func (p *Pipeline) work() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.config.FlushInterval)
+	defer ticker.Stop()
+
+	buffer := make([]models.Event, 0, p.config.BatchSize)
+	for {
+		select {
+		case event, ok := <-p.queue:
+			if !ok {
+				p.flush(buffer)
+				return
+			}
+
+			p.pending.Add(-1)
+			buffer = append(buffer, event)
+			if len(buffer) >= p.config.BatchSize {
+				p.flush(buffer)
+				buffer = buffer[:0]
+			}
+		case <-ticker.C:
+			if len(buffer) > 0 {
+				p.flush(buffer)
+				buffer = buffer[:0]
+			}
+		}
+	}
+}
+
+// flush writes a batch of events with a multi-row insert and bumps each device's
+// last seen time once per batch
+func (p *Pipeline) flush(events []models.Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	var written []string
+	for _, event := range events {
+		if event.ClientEventID != nil {
+			written = append(written, clientEventKey(event.ProjectID, *event.ClientEventID))
+		}
+	}
+	defer p.releaseClientEventIDs(written)
+
+	events = p.dropErasedDevices(events)
+	if len(events) == 0 {
+		return
+	}
+
+	p.assignOpenSessions(events)
+	p.write(events)
+}
+
+// write inserts events in one transaction. Events that still fail after flushAttempts
+// writes are kept as dead letters, the clients were already told they were accepted. A
+// batch rejected for its data is split in halves, so only the bad rows are dead-lettered.
+func (p *Pipeline) write(events []models.Event) {
+	var inserted []models.Event
+	err := retry(func() error {
+		return p.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			inserted, err = p.insertEvents(tx, events)
+			return err
+		})
+	})
+	if err != nil {
+		if permanentWriteError(err) && len(events) > 1 {
+			half := len(events) / 2
+			p.write(events[:half])
+			p.write(events[half:])
+			return
+		}
+
+		log.Printf("Failed to write %d events, keeping them as dead letters: %v", len(events), err)
+		p.deadLetter(events, err)
+		return
+	}
+
+	p.afterInsert(inserted)
+}
+
+// insertEvents writes events and counts them in their project's usage. Events whose client
+// event ID was already written, by this or another server instance, are skipped. It must
+// run in a transaction and returns the events written.
+func (p *Pipeline) insertEvents(tx *gorm.DB, events []models.Event) ([]models.Event, error) {
+	events, err := models.ClaimEventClientIDs(tx, events)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	type usageKey struct {
+		projectID uuid.UUID
+		month     time.Time
+	}
+
+	// Events are written by project and month so that the usage counts the rows actually
+	// inserted. Conflicts can only come from events written again with the same ID; keep
+	// the first copy.
+	groups := make(map[usageKey][]models.Event)
+	for _, event := range events {
+		key := usageKey{projectID: event.ProjectID, month: models.UsageMonth(event.ReceivedAt)}
+		groups[key] = append(groups[key], event)
+	}
+
+	for key, group := range groups {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			CreateInBatches(group, p.config.BatchSize)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		if err := models.AddEventUsage(tx, key.projectID, key.month, result.RowsAffected); err != nil {
+			return nil, err
+		}
+	}
+
+	return events, nil
+}
+
+// afterInsert records written events as activity of their devices and sessions
+func (p *Pipeline) afterInsert(events []models.Event) {
+	lastSeen := make(map[uuid.UUID]time.Time)
+	for _, event := range events {
+		if event.ReceivedAt.After(lastSeen[event.DeviceID]) {
+			lastSeen[event.DeviceID] = event.ReceivedAt
+		}
+	}
+
+	for deviceID, seenAt := range lastSeen {
+		if err := p.db.Model(&models.Device{}).
+			Where("id = ? AND last_seen < ?", deviceID, seenAt).
+			Update("last_seen", seenAt).Error; err != nil {
+			log.Printf("Failed to update last seen of device %s: %v", deviceID, err)
+		}
+	}
+
+	p.touchSessions(events)
+}
+
+// retry calls write until it succeeds, at most flushAttempts times, waiting longer
+// after each failure. Errors that can't go away on their own are returned at once. It
+// returns the last error.
+func retry(write func() error) error {
+	backoff := flushBackoff
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err == nil || attempt == flushAttempts || permanentWriteError(err) {
+			return err
+		}
+
+		log.Printf("Write attempt %d of %d failed, retrying in %s: %v", attempt, flushAttempts, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// permanentWriteError reports whether err is a data exception (SQLSTATE class 22) or an
+// integrity constraint violation (class 23), which fail the same rows on every retry
+func permanentWriteError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || len(pgErr.Code) < 2 {
+		return false
+	}
+
+	class := pgErr.Code[:2]
+	return class == "22" || class == "23"
+}
+
+func deviceCacheKey(projectID uuid.UUID, identifier string) string {
+	return projectID.String() + "/" + identifier
+}
+
+func clientEventKey(projectID uuid.UUID, clientEventID uuid.UUID) string {
+	return projectID.String() + "/" + clientEventID.String()
+}
+
+func keys(m map[string]int) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
@@ -0,0 +1,176 @@
+package ingest
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// schemaCacheTTL bounds how long a project's schema registry is trusted before it is loaded again.
+// Changes made through this instance are applied right away, other instances pick them up within the TTL.
+const schemaCacheTTL = 30 * time.Second
+
+// SchemaSet is the schema registry of a project as used during ingestion
+type SchemaSet struct {
+	Mode     models.SchemaMode
+	schemas  map[string]models.EventSchema
+	cachedAt time.Time
+}
+
+// Validate returns every way the event differs from the registry, or nothing when it
+// matches or validation is off. Predefined events don't need to be registered.
+func (s *SchemaSet) Validate(event models.Event) []string {
+	if s.Mode == models.SchemaModeOff {
+		return nil
+	}
+
+	schema, ok := s.schemas[event.EventName]
+	if !ok {
+		if event.EventType == models.EventTypePredefined {
+			return nil
+		}
+		return []string{fmt.Sprintf("event %q is not registered", event.EventName)}
+	}
+
+	return schema.Validate(event.EventType, event.Payloads)
+}
+
+// Schemas returns the schema registry of a project
+func (p *Pipeline) Schemas(projectID uuid.UUID) (*SchemaSet, error) {
+	if set, ok := p.schemas.Get(projectID); ok && time.Since(set.cachedAt) < schemaCacheTTL {
+		return set, nil
+	}
+
+	var project models.Project
+	if err := p.db.Model(&models.Project{}).
+		Select("id, schema_mode").
+		Where("id = ?", projectID).
+		First(&project).Error; err != nil {
+		return nil, err
+	}
+
+	set := &SchemaSet{
+		Mode:     project.SchemaMode,
+		schemas:  make(map[string]models.EventSchema),
+		cachedAt: time.Now(),
+	}
+
+	if set.Mode != models.SchemaModeOff {
+		var schemas []models.EventSchema
+		if err := p.db.Model(&models.EventSchema{}).
+			Where("project_id = ?", projectID).
+			Find(&schemas).Error; err != nil {
+			return nil, err
+		}
+		for _, schema := range schemas {
+			set.schemas[schema.EventName] = schema
+		}
+	}
+
+	p.schemas.Add(projectID, set)
+	return set, nil
+}
+
+// InvalidateSchemas drops the cached schema registry of a project after it changed
+func (p *Pipeline) InvalidateSchemas(projectID uuid.UUID) {
+	p.schemas.Remove(projectID)
+}
+
+type validationStatKey struct {
+	projectID uuid.UUID
+	day       time.Time
+	eventName string
+}
+
+type validationCounts struct {
+	warned   int64
+	rejected int64
+}
+
+// validationStats aggregates schema violations in memory until they are flushed
+type validationStats struct {
+	mu     sync.Mutex
+	counts map[validationStatKey]*validationCounts
+}
+
+// CountViolation records an event that failed schema validation under the given mode
+func (p *Pipeline) CountViolation(projectID uuid.UUID, eventName string, mode models.SchemaMode) {
+	key := validationStatKey{
+		projectID: projectID,
+		day:       time.Now().UTC().Truncate(24 * time.Hour),
+		eventName: eventName,
+	}
+
+	p.stats.mu.Lock()
+	defer p.stats.mu.Unlock()
+
+	counts, ok := p.stats.counts[key]
+	if !ok {
+		counts = &validationCounts{}
+		p.stats.counts[key] = counts
+	}
+
+	switch mode {
+	case models.SchemaModeWarn:
+		counts.warned++
+	case models.SchemaModeReject:
+		counts.rejected++
+	}
+}
+
+func (p *Pipeline) reportStats() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			p.flushStats()
+			return
+		case <-ticker.C:
+			p.flushStats()
+		}
+	}
+}
+
+// flushStats adds the counted violations to the stored daily counters
+func (p *Pipeline) flushStats() {
+	p.stats.mu.Lock()
+	counts := p.stats.counts
+	p.stats.counts = make(map[validationStatKey]*validationCounts)
+	p.stats.mu.Unlock()
+
+	if len(counts) == 0 {
+		return
+	}
+
+	stats := make([]models.EventValidationStat, 0, len(counts))
+	for key, count := range counts {
+		stats = append(stats, models.EventValidationStat{
+			ProjectID: key.projectID,
+			Day:       key.day,
+			EventName: key.eventName,
+			Warned:    count.warned,
+			Rejected:  count.rejected,
+		})
+	}
+
+	if err := p.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "project_id"}, {Name: "day"}, {Name: "event_name"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"warned":     gorm.Expr("event_validation_stats.warned + excluded.warned"),
+			"rejected":   gorm.Expr("event_validation_stats.rejected + excluded.rejected"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(&stats).Error; err != nil {
+		log.Printf("Failed to write schema validation counters for %d events: %v", len(stats), err)
+	}
+}
@@ -0,0 +1,121 @@
+package ingest
+
+import (
+	"log"
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/google/uuid"
+)
+
+type cachedSession struct {
+	projectID uuid.UUID
+	deviceID  uuid.UUID
+}
+
+// ResolveSessions maps session IDs of a project to the device each session belongs to.
+// Sessions that do not belong to the project are missing from the result.
+func (p *Pipeline) ResolveSessions(projectID uuid.UUID, sessionIDs []uuid.UUID) (map[uuid.UUID]uuid.UUID, error) {
+	resolved := make(map[uuid.UUID]uuid.UUID, len(sessionIDs))
+	var missing []uuid.UUID
+
+	for _, sessionID := range sessionIDs {
+		if _, done := resolved[sessionID]; done {
+			continue
+		}
+		// A session never moves to another device, cached entries stay valid
+		if session, ok := p.sessions.Get(sessionID); ok {
+			if session.projectID == projectID {
+				resolved[sessionID] = session.deviceID
+			}
+			continue
+		}
+		missing = append(missing, sessionID)
+	}
+
+	if len(missing) == 0 {
+		return resolved, nil
+	}
+
+	var sessions []models.Session
+	if err := p.db.Model(&models.Session{}).
+		Select("id, project_id, device_id").
+		Where("id IN ?", missing).
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		p.sessions.Add(session.ID, cachedSession{projectID: session.ProjectID, deviceID: session.DeviceID})
+		if session.ProjectID == projectID {
+			resolved[session.ID] = session.DeviceID
+		}
+	}
+
+	return resolved, nil
+}
+
+// assignOpenSessions links events sent without a session to the session their device
+// currently has open, if any
+func (p *Pipeline) assignOpenSessions(events []models.Event) {
+	var deviceIDs []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, event := range events {
+		if event.SessionID == nil && !seen[event.DeviceID] {
+			seen[event.DeviceID] = true
+			deviceIDs = append(deviceIDs, event.DeviceID)
+		}
+	}
+
+	if len(deviceIDs) == 0 {
+		return
+	}
+
+	var open []struct {
+		ID       uuid.UUID
+		DeviceID uuid.UUID
+	}
+	if err := p.db.Model(&models.Session{}).
+		Scopes(models.OpenSessions).
+		Select("DISTINCT ON (device_id) id, device_id").
+		Where("device_id IN ?", deviceIDs).
+		Order("device_id, begin_at DESC").
+		Scan(&open).Error; err != nil {
+		log.Printf("Failed to look up open sessions of %d devices: %v", len(deviceIDs), err)
+		return
+	}
+
+	openSessions := make(map[uuid.UUID]uuid.UUID, len(open))
+	for _, session := range open {
+		openSessions[session.DeviceID] = session.ID
+	}
+
+	for i := range events {
+		if events[i].SessionID != nil {
+			continue
+		}
+		if sessionID, ok := openSessions[events[i].DeviceID]; ok {
+			events[i].SessionID = &sessionID
+		}
+	}
+}
+
+// touchSessions records the events as activity of their sessions, keeping them from
+// being ended as abandoned
+func (p *Pipeline) touchSessions(events []models.Event) {
+	lastActivity := make(map[uuid.UUID]time.Time)
+	for _, event := range events {
+		if event.SessionID != nil && event.ReceivedAt.After(lastActivity[*event.SessionID]) {
+			lastActivity[*event.SessionID] = event.ReceivedAt
+		}
+	}
+
+	for sessionID, activeAt := range lastActivity {
+		if err := p.db.Model(&models.Session{}).
+			Scopes(models.OpenSessions).
+			Where("id = ? AND last_activity_at < ?", sessionID, activeAt).
+			Update("last_activity_at", activeAt).Error; err != nil {
+			log.Printf("Failed to update last activity of session %s: %v", sessionID, err)
+		}
+	}
+}
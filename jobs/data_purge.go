@@ -0,0 +1,247 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// dataPurgeBatchSize bounds the number of rows deleted by a single statement, keeping
+// locks short while ingestion goes on
+const dataPurgeBatchSize = 5000
+
+// purgeRunRetention is how long the history of purge runs is kept
+const purgeRunRetention = 90 * 24 * time.Hour
+
+// purgeExpiredEventsSQL deletes a chunk of a project's events that are past the project's
+// event retention or were soft-deleted before the grace period
+const purgeExpiredEventsSQL = `
+DELETE FROM events WHERE id IN (
+	SELECT id FROM events
+	WHERE project_id = @project AND (timestamp < @expired_before OR deleted_at < @deleted_before)
+	LIMIT @limit
+)`
+
+// purgeExpiredClientEventIDsSQL deletes a chunk of a project's claimed client event IDs
+// that are past the project's event retention. A retry arriving that late is unlikely and
+// would only find its event purged already.
+const purgeExpiredClientEventIDsSQL = `
+DELETE FROM event_client_ids WHERE (project_id, client_event_id) IN (
+	SELECT project_id, client_event_id FROM event_client_ids
+	WHERE project_id = @project AND created_at < @expired_before
+	LIMIT @limit
+)`
+
+// purgeExpiredSessionsSQL deletes a chunk of a project's sessions that began before the
+// project's session retention or were soft-deleted before the grace period
+const purgeExpiredSessionsSQL = `
+DELETE FROM sessions WHERE id IN (
+	SELECT id FROM sessions
+	WHERE project_id = @project AND (begin_at < @expired_before OR deleted_at < @deleted_before)
+	LIMIT @limit
+)`
+
+// purgeDeletedDevicesSQL deletes a chunk of a project's devices soft-deleted before the
+// grace period. Devices that still have sessions or events are kept until those are purged.
+const purgeDeletedDevicesSQL = `
+DELETE FROM devices WHERE id IN (
+	SELECT d.id FROM devices d
+	WHERE d.project_id = @project AND d.deleted_at < @deleted_before
+		AND NOT EXISTS (SELECT 1 FROM sessions s WHERE s.device_id = d.id)
+		AND NOT EXISTS (SELECT 1 FROM events e WHERE e.device_id = d.id)
+	LIMIT @limit
+)`
+
+// purgeableEventPartitionSQL reports whether every event of a partition is past its
+// project's event retention or was soft-deleted before the grace period
+const purgeableEventPartitionSQL = `
+SELECT NOT EXISTS (
+	SELECT 1 FROM %s e
+	JOIN projects p ON p.id = e.project_id
+	WHERE NOT (
+		(p.event_retention_days > 0 AND e.timestamp < @now - p.event_retention_days * INTERVAL '1 day')
+		OR COALESCE(e.deleted_at < @deleted_before, false)
+	)
+)`
+
+// PurgeExpiredData returns a job hard-deleting, for every project, the events and sessions
+// past the project's retention settings and the rows soft-deleted more than deletedGrace
+// ago. Each project's run is recorded as a PurgeRun.
+func PurgeExpiredData(db *gorm.DB, deletedGrace time.Duration) Func {
+	return func(ctx context.Context) error {
+		var projects []models.Project
+		if err := db.WithContext(ctx).Unscoped().
+			Select("id, event_retention_days, session_retention_days").
+			Find(&projects).Error; err != nil {
+			return err
+		}
+
+		// Dropping whole partitions is much cheaper than deleting their rows
+		dropped, err := dropExpiredEventPartitions(ctx, db, deletedGrace)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Failed to drop expired event partitions, their events are deleted in chunks instead: %v", err)
+		}
+
+		var failed int
+		for _, project := range projects {
+			run := purgeProject(ctx, db, project, deletedGrace)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			run.EventsPurged += dropped[project.ID]
+
+			if err := db.WithContext(ctx).Create(&run).Error; err != nil {
+				return err
+			}
+			if run.Error != "" {
+				failed++
+			}
+			if run.EventsPurged+run.SessionsPurged+run.DevicesPurged > 0 {
+				log.Printf("Purged %d events, %d sessions and %d devices of project %s",
+					run.EventsPurged, run.SessionsPurged, run.DevicesPurged, project.ID)
+			}
+		}
+
+		if err := db.WithContext(ctx).
+			Where("started_at < ?", time.Now().Add(-purgeRunRetention)).
+			Delete(&models.PurgeRun{}).Error; err != nil {
+			return err
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("purge failed for %d of %d projects", failed, len(projects))
+		}
+		return nil
+	}
+}
+
+// dropExpiredEventPartitions drops the partitions of past months whose events can all be
+// purged, and returns the number of events dropped per project
+func dropExpiredEventPartitions(ctx context.Context, db *gorm.DB, deletedGrace time.Duration) (map[uuid.UUID]int64, error) {
+	partitions, err := models.EventPartitions(db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	currentMonth := time.Date(now.UTC().Year(), now.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+	dropped := make(map[uuid.UUID]int64)
+	for month, name := range partitions {
+		if !month.Before(currentMonth) {
+			continue
+		}
+
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// Keep late events from being written to the partition while it is checked
+			if err := tx.Exec(fmt.Sprintf(`LOCK TABLE %s IN SHARE MODE`, name)).Error; err != nil {
+				return err
+			}
+
+			var purgeable bool
+			if err := tx.Raw(fmt.Sprintf(purgeableEventPartitionSQL, name), map[string]interface{}{
+				"now":            now,
+				"deleted_before": now.Add(-deletedGrace),
+			}).Scan(&purgeable).Error; err != nil {
+				return err
+			}
+			if !purgeable {
+				return nil
+			}
+
+			var counts []struct {
+				ProjectID uuid.UUID
+				Count     int64
+			}
+			if err := tx.Raw(fmt.Sprintf(`SELECT project_id, COUNT(*) AS count FROM %s GROUP BY project_id`, name)).
+				Scan(&counts).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(fmt.Sprintf(`DROP TABLE %s`, name)).Error; err != nil {
+				return err
+			}
+
+			var total int64
+			for _, count := range counts {
+				dropped[count.ProjectID] += count.Count
+				total += count.Count
+			}
+			log.Printf("Dropped events partition %s with %d events", name, total)
+			return nil
+		})
+		if err != nil {
+			return dropped, fmt.Errorf("failed to drop partition %s: %w", name, err)
+		}
+	}
+
+	return dropped, nil
+}
+
+func purgeProject(ctx context.Context, db *gorm.DB, project models.Project, deletedGrace time.Duration) models.PurgeRun {
+	now := time.Now()
+	run := models.PurgeRun{
+		ProjectID: project.ID,
+		StartedAt: now,
+	}
+
+	// Claimed client event IDs are not reported, they are not data about players
+	var clientEventIDsPurged int64
+	steps := []struct {
+		sql           string
+		retentionDays int
+		purged        *int64
+	}{
+		{sql: purgeExpiredEventsSQL, retentionDays: project.EventRetentionDays, purged: &run.EventsPurged},
+		{sql: purgeExpiredClientEventIDsSQL, retentionDays: project.EventRetentionDays, purged: &clientEventIDsPurged},
+		{sql: purgeExpiredSessionsSQL, retentionDays: project.SessionRetentionDays, purged: &run.SessionsPurged},
+		{sql: purgeDeletedDevicesSQL, purged: &run.DevicesPurged},
+	}
+
+	for _, step := range steps {
+		// Without a retention only soft-deleted rows are purged
+		expiredBefore := time.Time{}
+		if step.retentionDays > 0 {
+			expiredBefore = now.AddDate(0, 0, -step.retentionDays)
+		}
+
+		purged, err := purgeInChunks(ctx, db, step.sql, map[string]interface{}{
+			"project":        project.ID,
+			"expired_before": expiredBefore,
+			"deleted_before": now.Add(-deletedGrace),
+		})
+		*step.purged = purged
+		if err != nil {
+			run.Error = err.Error()
+			break
+		}
+	}
+
+	run.FinishedAt = time.Now()
+	return run
+}
+
+// purgeInChunks runs a chunked DELETE statement until it deletes less than a full chunk
+func purgeInChunks(ctx context.Context, db *gorm.DB, sql string, params map[string]interface{}) (int64, error) {
+	params["limit"] = dataPurgeBatchSize
+
+	var total int64
+	for {
+		result := db.WithContext(ctx).Exec(sql, params)
+		if result.Error != nil {
+			return total, result.Error
+		}
+
+		total += result.RowsAffected
+		if result.RowsAffected < dataPurgeBatchSize {
+			return total, nil
+		}
+	}
+}
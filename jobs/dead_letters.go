@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/atqamz/kogase-backend/ingest"
+)
+
+// DeadLetterReplayInterval is how often events the ingestion pipeline failed to write are
+// written back
+const DeadLetterReplayInterval = 5 * time.Minute
+
+// ReplayDeadLetters returns a job writing back the events the ingestion pipeline kept as
+// dead letters after failing to write them
+func ReplayDeadLetters(pipeline *ingest.Pipeline) Func {
+	return func(ctx context.Context) error {
+		replayed, err := pipeline.ReplayDeadLetters(ctx)
+		if replayed > 0 {
+			log.Printf("Wrote back %d dead-lettered events", replayed)
+		}
+		return err
+	}
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// deviceIPRewriteBatchSize bounds the number of devices rewritten per transaction
+const deviceIPRewriteBatchSize = 500
+
+// RewriteDeviceIPs returns a job rewriting the stored IP addresses of a project's
+// devices, deleted ones included, to the project's current IP privacy mode. Addresses
+// that were truncated, hashed or dropped can't be restored by switching back to a less
+// private mode.
+func RewriteDeviceIPs(db *gorm.DB, projectID uuid.UUID) Func {
+	return func(ctx context.Context) error {
+		var project models.Project
+		if err := db.WithContext(ctx).
+			Select("id, ip_privacy, ip_hash_salt").
+			Where("id = ?", projectID).
+			First(&project).Error; err != nil {
+			return err
+		}
+
+		var rewritten int64
+		lastID := uuid.Nil
+		for {
+			var devices []models.Device
+			if err := db.WithContext(ctx).Unscoped().
+				Select("id, ip_address").
+				Where("project_id = ? AND id > ?", projectID, lastID).
+				Order("id").
+				Limit(deviceIPRewriteBatchSize).
+				Find(&devices).Error; err != nil {
+				return err
+			}
+			if len(devices) == 0 {
+				break
+			}
+			lastID = devices[len(devices)-1].ID
+
+			err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				for _, device := range devices {
+					ipAddress := project.AnonymizeIP(device.IpAddress)
+					if ipAddress == device.IpAddress {
+						continue
+					}
+					if err := tx.Unscoped().Model(&models.Device{}).
+						Where("id = ?", device.ID).
+						UpdateColumn("ip_address", ipAddress).Error; err != nil {
+						return err
+					}
+					rewritten++
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(devices) < deviceIPRewriteBatchSize {
+				break
+			}
+		}
+
+		log.Printf("Rewrote the IP address of %d devices of project %s to mode %s", rewritten, projectID, project.IPPrivacy)
+		return nil
+	}
+}
@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+	"gorm.io/gorm"
+)
+
+// EventPartitionInterval is how often upcoming event partitions are created. Partitions
+// are prepared months ahead, so a daily check leaves plenty of room for failures.
+const EventPartitionInterval = 24 * time.Hour
+
+// CreateEventPartitions returns a job creating the monthly partitions of the events table
+// for the coming months
+func CreateEventPartitions(db *gorm.DB) Func {
+	return func(ctx context.Context) error {
+		return models.EnsureEventPartitions(db.WithContext(ctx), time.Now())
+	}
+}
@@ -0,0 +1,230 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// rollupLateDays is the number of days before the watermark aggregated again on every run,
+// picking up sessions that ended and events that arrived after their day was aggregated
+const rollupLateDays = 2
+
+// rollupDirtyMargin is subtracted from the start of the previous run when looking for rows
+// written since, covering transactions that were still open and clock differences
+const rollupDirtyMargin = 10 * time.Minute
+
+// rollupMaxDays bounds the number of new days aggregated per project in one run, so the
+// backfill of a project with a long history spreads over several runs
+const rollupMaxDays = 31
+
+// rollupBatchSize bounds the number of rollup rows per multi-row insert
+const rollupBatchSize = 500
+
+// rollupActivitySQL aggregates a project's sessions by the day they began, and its devices
+// by the day they were first seen, per device platform, country and app version
+const rollupActivitySQL = `
+SELECT day, platform, country, app_version,
+	SUM(active_devices)::bigint AS active_devices, SUM(new_devices)::bigint AS new_devices,
+	SUM(sessions)::bigint AS sessions, SUM(ended_sessions)::bigint AS ended_sessions,
+	SUM(total_duration)::bigint AS total_duration
+FROM (
+	SELECT (s.begin_at AT TIME ZONE 'UTC')::date AS day,
+		COALESCE(d.platform, '') AS platform, COALESCE(d.country, '') AS country, COALESCE(d.app_version, '') AS app_version,
+		COUNT(DISTINCT s.device_id) AS active_devices, 0 AS new_devices, COUNT(*) AS sessions,
+		COUNT(*) FILTER (WHERE s.duration > 0) AS ended_sessions,
+		COALESCE(SUM(s.duration) FILTER (WHERE s.duration > 0), 0) AS total_duration
+	FROM sessions s
+	LEFT JOIN devices d ON d.id = s.device_id
+	WHERE s.project_id = @project AND s.begin_at >= @from AND s.begin_at < @to AND s.deleted_at IS NULL
+	GROUP BY 1, 2, 3, 4
+	UNION ALL
+	SELECT (first_seen AT TIME ZONE 'UTC')::date, platform, COALESCE(country, ''), app_version,
+		0, COUNT(*), 0, 0, 0
+	FROM devices
+	WHERE project_id = @project AND first_seen >= @from AND first_seen < @to AND deleted_at IS NULL
+	GROUP BY 1, 2, 3, 4
+) activity
+GROUP BY day, platform, country, app_version`
+
+// rollupEventsSQL counts a project's events by the day they occurred, per event type and
+// name and per device platform, country and app version
+const rollupEventsSQL = `
+SELECT (e.timestamp AT TIME ZONE 'UTC')::date AS day, e.event_type, e.event_name,
+	COALESCE(d.platform, '') AS platform, COALESCE(d.country, '') AS country, COALESCE(d.app_version, '') AS app_version,
+	COUNT(*) AS events, COUNT(DISTINCT e.device_id) AS devices
+FROM events e
+LEFT JOIN devices d ON d.id = e.device_id
+WHERE e.project_id = @project AND e.timestamp >= @from AND e.timestamp < @to AND e.deleted_at IS NULL
+GROUP BY 1, 2, 3, 4, 5, 6`
+
+// rollupDirtyDaysSQL finds the aggregated days, before the ones recomputed on every run,
+// with rows written since the previous run: events that arrived late, sessions that ended
+// or were ended by the sweeper, and devices registered with an earlier first seen time
+const rollupDirtyDaysSQL = `
+SELECT day FROM (
+	SELECT (timestamp AT TIME ZONE 'UTC')::date AS day FROM events
+	WHERE project_id = @project AND created_at >= @since AND timestamp >= @from AND timestamp < @to
+	UNION
+	SELECT (begin_at AT TIME ZONE 'UTC')::date FROM sessions
+	WHERE project_id = @project AND updated_at >= @since AND begin_at >= @from AND begin_at < @to
+	UNION
+	SELECT (first_seen AT TIME ZONE 'UTC')::date FROM devices
+	WHERE project_id = @project AND created_at >= @since AND first_seen >= @from AND first_seen < @to
+) dirty
+ORDER BY day`
+
+// AggregateRollups returns a job maintaining the daily rollups of every project. Each run
+// aggregates the complete UTC days after the project's watermark, recomputes the last
+// rollupLateDays days before it and any earlier day with rows written since the previous
+// run, and moves the watermark forward.
+func AggregateRollups(db *gorm.DB) Func {
+	return func(ctx context.Context) error {
+		var projects []models.Project
+		if err := db.WithContext(ctx).Select("id, created_at").Find(&projects).Error; err != nil {
+			return err
+		}
+
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		var failed int
+		for _, project := range projects {
+			if err := aggregateProjectRollups(ctx, db, project, today); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				log.Printf("Failed to aggregate rollups of project %s: %v", project.ID, err)
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("rollups failed for %d of %d projects", failed, len(projects))
+		}
+		return nil
+	}
+}
+
+func aggregateProjectRollups(ctx context.Context, db *gorm.DB, project models.Project, today time.Time) error {
+	// Rollups start on the day the project was created, or on the first day one of its
+	// devices was seen for data imported from elsewhere
+	var firstSeen *time.Time
+	if err := db.WithContext(ctx).Model(&models.Device{}).
+		Where("project_id = ?", project.ID).
+		Select("MIN(first_seen)").
+		Scan(&firstSeen).Error; err != nil {
+		return err
+	}
+	fromDay := project.CreatedAt.UTC().Truncate(24 * time.Hour)
+	if firstSeen != nil && firstSeen.Before(fromDay) {
+		fromDay = firstSeen.UTC().Truncate(24 * time.Hour)
+	}
+
+	if err := db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RollupWatermark{ProjectID: project.ID, FromDay: fromDay, Watermark: fromDay}).Error; err != nil {
+		return err
+	}
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the watermark so concurrent runs don't aggregate the same days
+		var watermark models.RollupWatermark
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("project_id = ?", project.ID).
+			First(&watermark).Error; err != nil {
+			return err
+		}
+
+		startedAt := time.Now()
+		from := watermark.Watermark.AddDate(0, 0, -rollupLateDays)
+		if from.Before(watermark.FromDay) {
+			from = watermark.FromDay
+		}
+		to := watermark.Watermark.AddDate(0, 0, rollupMaxDays)
+		if to.After(today) {
+			to = today
+		}
+
+		// Days aggregated before the late window are only recomputed when rows were
+		// written for them since the previous run
+		if watermark.AggregatedAt != nil && watermark.FromDay.Before(from) {
+			var dirtyDays []time.Time
+			if err := tx.Raw(rollupDirtyDaysSQL, map[string]interface{}{
+				"project": project.ID,
+				"since":   watermark.AggregatedAt.Add(-rollupDirtyMargin),
+				"from":    watermark.FromDay,
+				"to":      from,
+			}).Scan(&dirtyDays).Error; err != nil {
+				return err
+			}
+
+			for _, day := range dirtyDays {
+				day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+				if err := aggregateRollupDays(tx, project, day, day.AddDate(0, 0, 1)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if from.Before(to) {
+			if err := aggregateRollupDays(tx, project, from, to); err != nil {
+				return err
+			}
+		}
+
+		if to.After(watermark.Watermark) {
+			watermark.Watermark = to
+		}
+		watermark.AggregatedAt = &startedAt
+		return tx.Save(&watermark).Error
+	})
+}
+
+// aggregateRollupDays replaces the rollups of a project for the UTC days in [from, to)
+func aggregateRollupDays(tx *gorm.DB, project models.Project, from time.Time, to time.Time) error {
+	params := map[string]interface{}{
+		"project": project.ID,
+		"from":    from,
+		"to":      to,
+	}
+
+	var activity []models.DailyActivityRollup
+	if err := tx.Raw(rollupActivitySQL, params).Scan(&activity).Error; err != nil {
+		return err
+	}
+	var events []models.DailyEventRollup
+	if err := tx.Raw(rollupEventsSQL, params).Scan(&events).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("project_id = ? AND day >= ? AND day < ?", project.ID, from, to).
+		Delete(&models.DailyActivityRollup{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("project_id = ? AND day >= ? AND day < ?", project.ID, from, to).
+		Delete(&models.DailyEventRollup{}).Error; err != nil {
+		return err
+	}
+
+	for i := range activity {
+		activity[i].ProjectID = project.ID
+	}
+	for i := range events {
+		events[i].ProjectID = project.ID
+	}
+	if len(activity) > 0 {
+		if err := tx.CreateInBatches(activity, rollupBatchSize).Error; err != nil {
+			return err
+		}
+	}
+	if len(events) > 0 {
+		if err := tx.CreateInBatches(events, rollupBatchSize).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Func is a unit of background work. It should return promptly once ctx is cancelled.
+type Func func(ctx context.Context) error
+
+// Scheduler runs background jobs at fixed intervals until it is stopped
+type Scheduler struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler without any jobs
+func NewScheduler() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{ctx: ctx, cancel: cancel}
+}
+
+// Every runs job every interval, starting one interval from now. A run that is still
+// in progress when the next one is due delays it instead of overlapping.
+func (s *Scheduler) Every(name string, interval time.Duration, job Func) {
+	if interval <= 0 {
+		log.Printf("Background job %s is disabled", name)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := job(s.ctx); err != nil && s.ctx.Err() == nil {
+					log.Printf("Background job %s failed: %v", name, err)
+				}
+			}
+		}
+	}()
+}
+
+// Run runs job once in the background. Stop cancels and waits for it like scheduled jobs.
+func (s *Scheduler) Run(name string, job Func) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		if s.ctx.Err() != nil {
+			return
+		}
+		if err := job(s.ctx); err != nil && s.ctx.Err() == nil {
+			log.Printf("Background job %s failed: %v", name, err)
+		}
+	}()
+}
+
+// Stop cancels the running jobs and waits for them to return
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/atqamz/kogase-backend/live"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sessionSweepBatchSize bounds the number of sessions ended by a single statement
+const sessionSweepBatchSize = 1000
+
+// sweepSessionsSQL ends open sessions whose last sign of life, the latest of their last
+// heartbeat and the latest event of their device since they began, is older than the
+// project's session timeout. The session ends at that last sign of life.
+const sweepSessionsSQL = `
+WITH idle AS (
+	SELECT candidates.id, candidates.ended_at
+	FROM (
+		SELECT s.id, p.session_timeout, GREATEST(
+			s.begin_at,
+			COALESCE(s.last_activity_at, s.begin_at),
+			COALESCE((
+				SELECT MAX(e.timestamp) FROM events e
+				WHERE e.device_id = s.device_id AND e.project_id = s.project_id AND e.deleted_at IS NULL
+					AND e.timestamp >= s.begin_at AND e.timestamp <= now()
+			), s.begin_at)
+		) AS ended_at
+		FROM sessions s
+		JOIN projects p ON p.id = s.project_id
+		WHERE s.deleted_at IS NULL
+			AND (s.end_at IS NULL OR s.end_at = @zero)
+			AND COALESCE(s.last_activity_at, s.begin_at) < now() - (p.session_timeout / 1000) * INTERVAL '1 microsecond'
+	) candidates
+	WHERE candidates.ended_at < now() - (candidates.session_timeout / 1000) * INTERVAL '1 microsecond'
+	LIMIT @limit
+)
+UPDATE sessions
+SET end_at = idle.ended_at,
+	duration = (EXTRACT(EPOCH FROM idle.ended_at - sessions.begin_at) * 1000000000)::bigint,
+	updated_at = now()
+FROM idle
+WHERE sessions.id = idle.id AND (sessions.end_at IS NULL OR sessions.end_at = @zero)
+RETURNING sessions.project_id`
+
+// SweepSessions returns a job ending the sessions abandoned by crashed or killed games
+func SweepSessions(db *gorm.DB, hub *live.Hub) Func {
+	return func(ctx context.Context) error {
+		var total int
+		for {
+			var ended []struct {
+				ProjectID uuid.UUID
+			}
+			if err := db.WithContext(ctx).Raw(sweepSessionsSQL, map[string]interface{}{
+				"zero":  time.Time{},
+				"limit": sessionSweepBatchSize,
+			}).Scan(&ended).Error; err != nil {
+				return err
+			}
+
+			perProject := make(map[uuid.UUID]int64)
+			for _, session := range ended {
+				perProject[session.ProjectID]++
+			}
+			for projectID, count := range perProject {
+				hub.RecordSessionsEnded(projectID, count)
+			}
+
+			total += len(ended)
+			if len(ended) < sessionSweepBatchSize {
+				break
+			}
+		}
+
+		if total > 0 {
+			log.Printf("Ended %d idle sessions", total)
+		}
+		return nil
+	}
+}
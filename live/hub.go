@@ -0,0 +1,281 @@
+package live
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrStopped is returned when subscribing after the hub was stopped
+var ErrStopped = errors.New("live metrics hub is stopped")
+
+const (
+	// historySize is the number of one-second windows kept for the rolling minute
+	historySize = 60
+
+	// maxEventNames bounds the distinct event names counted per window, the rest are
+	// counted under OtherEventsName
+	maxEventNames = 100
+
+	// subscriberBuffer is the number of snapshots queued for a slow subscriber before
+	// new ones are dropped
+	subscriberBuffer = 4
+)
+
+// OtherEventsName groups the events whose names exceeded maxEventNames in a window
+const OtherEventsName = "(other)"
+
+// Window holds what was ingested for a project during a period of time
+type Window struct {
+	Events          int64
+	EventsByName    map[string]int64
+	NewDevices      int64
+	SessionsStarted int64
+	SessionsEnded   int64
+}
+
+func newWindow() Window {
+	return Window{EventsByName: make(map[string]int64)}
+}
+
+func (w *Window) add(other Window) {
+	w.Events += other.Events
+	w.NewDevices += other.NewDevices
+	w.SessionsStarted += other.SessionsStarted
+	w.SessionsEnded += other.SessionsEnded
+	for name, count := range other.EventsByName {
+		w.countEvent(name, count)
+	}
+}
+
+func (w *Window) countEvent(name string, count int64) {
+	if _, ok := w.EventsByName[name]; !ok && len(w.EventsByName) >= maxEventNames {
+		name = OtherEventsName
+	}
+	w.EventsByName[name] += count
+}
+
+// Snapshot is the live summary of a project pushed to subscribers every second
+type Snapshot struct {
+	ProjectID    uuid.UUID
+	Timestamp    time.Time
+	OpenSessions int64  // Sessions started and not ended yet
+	LastSecond   Window // What was ingested during the last second
+	LastMinute   Window // What was ingested during the last 60 seconds
+}
+
+type projectState struct {
+	openSessions int64
+	current      Window
+	history      []Window // Completed one-second windows, oldest overwritten first
+	next         int
+	subscribers  map[chan Snapshot]struct{}
+}
+
+// Hub aggregates ingestion activity in memory and pushes a per-project summary to
+// subscribers every second. Only projects with at least one subscriber are tracked,
+// so the database is read once when a project gets its first subscriber and never polled.
+type Hub struct {
+	db *gorm.DB
+
+	mu       sync.Mutex
+	projects map[uuid.UUID]*projectState
+	stopped  bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewHub creates a hub and starts publishing snapshots
+func NewHub(db *gorm.DB) *Hub {
+	h := &Hub{
+		db:       db,
+		projects: make(map[uuid.UUID]*projectState),
+		done:     make(chan struct{}),
+	}
+
+	h.wg.Add(1)
+	go h.publish()
+
+	return h
+}
+
+// Subscribe returns a channel receiving the project's snapshots and a function that
+// cancels the subscription. The channel is closed when the hub stops.
+func (h *Hub) Subscribe(projectID uuid.UUID) (<-chan Snapshot, func(), error) {
+	h.mu.Lock()
+	_, tracked := h.projects[projectID]
+	h.mu.Unlock()
+
+	// Seed the open session count once, later changes come from the ingestion path
+	var openSessions int64
+	if !tracked {
+		if err := h.db.Model(&models.Session{}).
+			Scopes(models.OpenSessions).
+			Where("project_id = ?", projectID).
+			Count(&openSessions).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stopped {
+		return nil, nil, ErrStopped
+	}
+
+	state, ok := h.projects[projectID]
+	if !ok {
+		state = &projectState{
+			openSessions: openSessions,
+			current:      newWindow(),
+			subscribers:  make(map[chan Snapshot]struct{}),
+		}
+		h.projects[projectID] = state
+	}
+
+	ch := make(chan Snapshot, subscriberBuffer)
+	state.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			if _, ok := state.subscribers[ch]; !ok {
+				return
+			}
+			delete(state.subscribers, ch)
+			close(ch)
+			if len(state.subscribers) == 0 && h.projects[projectID] == state {
+				delete(h.projects, projectID)
+			}
+		})
+	}
+
+	return ch, cancel, nil
+}
+
+// RecordEvents counts events accepted for ingestion
+func (h *Hub) RecordEvents(projectID uuid.UUID, eventNames ...string) {
+	h.update(projectID, func(state *projectState) {
+		state.current.Events += int64(len(eventNames))
+		for _, name := range eventNames {
+			state.current.countEvent(name, 1)
+		}
+	})
+}
+
+// RecordNewDevice counts a device registered for the first time
+func (h *Hub) RecordNewDevice(projectID uuid.UUID) {
+	h.update(projectID, func(state *projectState) {
+		state.current.NewDevices++
+	})
+}
+
+// RecordSessionStarted counts a session that began
+func (h *Hub) RecordSessionStarted(projectID uuid.UUID) {
+	h.update(projectID, func(state *projectState) {
+		state.current.SessionsStarted++
+		state.openSessions++
+	})
+}
+
+// RecordSessionsEnded counts sessions that ended
+func (h *Hub) RecordSessionsEnded(projectID uuid.UUID, count int64) {
+	h.update(projectID, func(state *projectState) {
+		state.current.SessionsEnded += count
+		state.openSessions -= count
+		if state.openSessions < 0 {
+			state.openSessions = 0
+		}
+	})
+}
+
+// Stop stops publishing and closes every subscription
+func (h *Hub) Stop() {
+	h.mu.Lock()
+	if h.stopped {
+		h.mu.Unlock()
+		return
+	}
+	h.stopped = true
+	close(h.done)
+
+	for projectID, state := range h.projects {
+		for ch := range state.subscribers {
+			delete(state.subscribers, ch)
+			close(ch)
+		}
+		delete(h.projects, projectID)
+	}
+	h.mu.Unlock()
+
+	h.wg.Wait()
+}
+
+func (h *Hub) update(projectID uuid.UUID, apply func(state *projectState)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if state, ok := h.projects[projectID]; ok {
+		apply(state)
+	}
+}
+
+func (h *Hub) publish() {
+	defer h.wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.done:
+			return
+		case now := <-ticker.C:
+			h.tick(now)
+		}
+	}
+}
+
+// tick closes the current one-second window of every project and sends the snapshots
+func (h *Hub) tick(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for projectID, state := range h.projects {
+		if len(state.history) < historySize {
+			state.history = append(state.history, state.current)
+		} else {
+			state.history[state.next] = state.current
+		}
+		state.next = (state.next + 1) % historySize
+
+		snapshot := Snapshot{
+			ProjectID:    projectID,
+			Timestamp:    now.UTC(),
+			OpenSessions: state.openSessions,
+			LastSecond:   state.current,
+			LastMinute:   newWindow(),
+		}
+		for _, window := range state.history {
+			snapshot.LastMinute.add(window)
+		}
+		state.current = newWindow()
+
+		for ch := range state.subscribers {
+			select {
+			case ch <- snapshot:
+			default:
+				// The subscriber is not keeping up, it gets the next snapshot instead
+			}
+		}
+	}
+}
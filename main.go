@@ -5,8 +5,8 @@ import (
 	"log"
 	"os"
 
+	"github.com/atqamz/kogase-backend/cli"
 	_ "github.com/atqamz/kogase-backend/docs" // Import for swagger docs
-	"github.com/atqamz/kogase-backend/server"
 	"github.com/joho/godotenv"
 )
 
@@ -31,15 +31,8 @@ func main() {
 		log.Println("No .env file found, using environment variables")
 	}
 
-	s, err := server.New()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error initializing backend: %v\n", err)
-		os.Exit(1)
-	}
-
-	log.Println("Starting Kogase backend...")
-	if err := s.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error running backend: %v\n", err)
+	if err := cli.Run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
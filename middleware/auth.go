@@ -20,8 +20,19 @@ type JWTClaims struct {
 	jwt.RegisteredClaims
 }
 
-// AuthMiddleware handles JWT authentication for dashboard users
+// AuthMiddleware handles JWT authentication for dashboard users. Users who must change
+// their password are refused until they do.
 func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return authenticate(db, false)
+}
+
+// PasswordChangeAuthMiddleware authenticates dashboard users like AuthMiddleware, but lets
+// users who must change their password through. It guards the routes they need to do so.
+func PasswordChangeAuthMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return authenticate(db, true)
+}
+
+func authenticate(db *gorm.DB, allowPasswordChange bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -61,29 +72,101 @@ func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
 
 		// Check if token exists in database and is not expired
 		var authToken models.AuthToken
-		if err := db.Where("token = ?", tokenString).First(&authToken).Error; err != nil {
+		if err := db.Joins("User").
+			Where("auth_tokens.token_hash = ?", models.HashSecret(tokenString)).
+			First(&authToken).Error; err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
+		if authToken.RevokedAt != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token revoked"})
+			c.Abort()
+			return
+		}
+
 		if authToken.ExpiresAt.Before(time.Now()) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
 			c.Abort()
 			return
 		}
 
+		if !allowUser(c, authToken.User, allowPasswordChange) {
+			return
+		}
+
 		// Update last used time
 		db.Model(&authToken).Update("last_used_at", time.Now())
 
-		// Set user ID in context
+		// Set user ID and token details in context
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
+		c.Set("auth_token_id", authToken.ID)
+		c.Set("auth_token_family_id", authToken.FamilyID)
 
 		c.Next()
 	}
 }
 
+// StreamTokenMiddleware authenticates requests carrying a stream token in the token query
+// parameter, as browsers opening a live stream with EventSource do, and falls back to
+// AuthMiddleware otherwise. The token is consumed and only opens the stream of the project
+// in the given path parameter it was issued for.
+func StreamTokenMiddleware(db *gorm.DB, projectParam string) gin.HandlerFunc {
+	fallback := AuthMiddleware(db)
+
+	return func(c *gin.Context) {
+		secret := c.Query("token")
+		if secret == "" {
+			fallback(c)
+			return
+		}
+
+		token, err := models.RedeemStreamToken(db, secret)
+		if err != nil || token.ProjectID.String() != c.Param(projectParam) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired stream token"})
+			c.Abort()
+			return
+		}
+
+		// The user may have been deleted or locked since the token was issued
+		var user models.User
+		if err := db.First(&user, "id = ?", token.UserID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired stream token"})
+			c.Abort()
+			return
+		}
+
+		if !allowUser(c, user, false) {
+			return
+		}
+
+		c.Set("user_id", user.ID)
+		c.Set("user_email", user.Email)
+
+		c.Next()
+	}
+}
+
+// allowUser refuses users who are locked, or who must change their password unless
+// allowPasswordChange is set, and aborts the request for them
+func allowUser(c *gin.Context, user models.User, allowPasswordChange bool) bool {
+	if user.LockedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account locked"})
+		c.Abort()
+		return false
+	}
+
+	if user.MustChangePassword && !allowPasswordChange {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Password change required"})
+		c.Abort()
+		return false
+	}
+
+	return true
+}
+
 // ApiKeyMiddleware handles API key authentication for the SDK
 func ApiKeyMiddleware(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -95,16 +178,42 @@ func ApiKeyMiddleware(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Find the project by API key
-		var project models.Project
-		if err := db.Where("api_key = ?", apiKey).First(&project).Error; err != nil {
+		// Find an active key and make sure its project still exists
+		var key models.ApiKey
+		if err := db.Scopes(models.ActiveApiKeys).
+			Where("key_hash = ?", models.HashSecret(apiKey)).
+			Where("project_id IN (?)", db.Model(&models.Project{}).Select("id")).
+			First(&key).Error; err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
 			c.Abort()
 			return
 		}
 
-		// Set project ID in context
-		c.Set("project_id", project.ID)
+		// Update last used time, at most once per minute to keep ingestion cheap
+		now := time.Now()
+		if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > time.Minute {
+			db.Model(&key).Update("last_used_at", now)
+		}
+
+		// Set project ID and key details in context
+		c.Set("project_id", key.ProjectID)
+		c.Set("api_key_id", key.ID)
+		c.Set("api_key_scopes", key.Scopes)
+
+		c.Next()
+	}
+}
+
+// ApiKeyScopeMiddleware ensures the API key used for the request was granted the given scope.
+// It must run after ApiKeyMiddleware.
+func ApiKeyScopeMiddleware(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, exists := c.Get("api_key_scopes")
+		if !exists || !scopes.(models.ApiKeyScopes).Contains(scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API key is missing the " + scope + " scope"})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
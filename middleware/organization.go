@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrganizationRoleMiddleware ensures the authenticated user holds at least minRole in the
+// organization whose ID is in the given path parameter. It must run after AuthMiddleware.
+func OrganizationRoleMiddleware(db *gorm.DB, minRole models.OrganizationRole, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.Abort()
+			return
+		}
+
+		organizationID, err := uuid.Parse(c.Param(param))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			c.Abort()
+			return
+		}
+
+		var member models.OrganizationMember
+		if err := db.Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&member).Error; err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this organization"})
+			c.Abort()
+			return
+		}
+
+		if !member.Role.AtLeast(minRole) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient organization role"})
+			c.Abort()
+			return
+		}
+
+		// Set organization scope in context
+		c.Set("organization_id", organizationID)
+		c.Set("organization_role", member.Role)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/atqamz/kogase-backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidProjectReference is returned by a ProjectResolver when the request
+// carries a malformed project or resource ID
+var ErrInvalidProjectReference = errors.New("invalid project reference")
+
+// ProjectResolver determines which project a request targets. It returns
+// uuid.Nil when the request is not scoped to a single project.
+type ProjectResolver func(c *gin.Context, db *gorm.DB) (uuid.UUID, error)
+
+// AnyProject is used on routes that span every project the user belongs to
+func AnyProject(_ *gin.Context, _ *gorm.DB) (uuid.UUID, error) {
+	return uuid.Nil, nil
+}
+
+// ProjectFromParam resolves the project from a path parameter holding the project ID
+func ProjectFromParam(name string) ProjectResolver {
+	return func(c *gin.Context, _ *gorm.DB) (uuid.UUID, error) {
+		projectID, err := uuid.Parse(c.Param(name))
+		if err != nil {
+			return uuid.Nil, ErrInvalidProjectReference
+		}
+		return projectID, nil
+	}
+}
+
+// ProjectFromQuery resolves the project from an optional query parameter
+func ProjectFromQuery(name string) ProjectResolver {
+	return func(c *gin.Context, _ *gorm.DB) (uuid.UUID, error) {
+		value := c.Query(name)
+		if value == "" {
+			return uuid.Nil, nil
+		}
+
+		projectID, err := uuid.Parse(value)
+		if err != nil {
+			return uuid.Nil, ErrInvalidProjectReference
+		}
+		return projectID, nil
+	}
+}
+
+// ProjectFromRecord resolves the project owning the record whose ID is in the given path parameter
+func ProjectFromRecord(model interface{}, param string) ProjectResolver {
+	return func(c *gin.Context, db *gorm.DB) (uuid.UUID, error) {
+		recordID, err := uuid.Parse(c.Param(param))
+		if err != nil {
+			return uuid.Nil, ErrInvalidProjectReference
+		}
+
+		var projectIDs []uuid.UUID
+		if err := db.Model(model).
+			Where("id = ?", recordID).
+			Limit(1).
+			Pluck("project_id", &projectIDs).Error; err != nil {
+			return uuid.Nil, err
+		}
+		if len(projectIDs) == 0 {
+			return uuid.Nil, gorm.ErrRecordNotFound
+		}
+		return projectIDs[0], nil
+	}
+}
+
+// ProjectRoleMiddleware ensures the authenticated user holds at least minRole in the
+// project targeted by the request, as a project member or through the role they hold in
+// the project's organization. It must run after AuthMiddleware.
+//
+// When the resolver finds no project (e.g. a list endpoint without a project_id filter)
+// the request is let through and the minimum role is stored in the context so the
+// controller can scope its query to the user's projects.
+func ProjectRoleMiddleware(db *gorm.DB, minRole models.ProjectRole, resolve ProjectResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.Abort()
+			return
+		}
+
+		projectID, err := resolve(c, db)
+		if err != nil {
+			if errors.Is(err, ErrInvalidProjectReference) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			} else if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve project"})
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set("project_min_role", minRole)
+
+		if projectID == uuid.Nil {
+			c.Next()
+			return
+		}
+
+		role, err := models.ProjectRoleOf(db, projectID, userID.(uuid.UUID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve project role"})
+			c.Abort()
+			return
+		}
+
+		if role == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this project"})
+			c.Abort()
+			return
+		}
+
+		if !role.AtLeast(minRole) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient project role"})
+			c.Abort()
+			return
+		}
+
+		// Set project scope in context
+		c.Set("project_id", projectID)
+		c.Set("project_role", role)
+
+		c.Next()
+	}
+}
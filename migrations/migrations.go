@@ -0,0 +1,336 @@
+// Package migrations applies the versioned SQL migrations embedded in the binary
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atqamz/kogase-backend/models"
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey identifies the advisory lock held while migrations run, so replicas starting
+// at the same time apply each migration once
+const lockKey int64 = 0x6b6f67617365
+
+// baselineVersion is the migration describing the schema that AutoMigrate maintained.
+// Databases created before versioned migrations are upgraded in code and marked as at it.
+const baselineVersion = 1
+
+// codeMigrations are data changes of a migration that SQL can't express. They run after
+// the migration's up file, in the same transaction.
+var codeMigrations = map[int64]func(tx *gorm.DB) error{
+	6: models.RekeyErasureLogs,
+}
+
+const createSchemaMigrationsSQL = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version bigint PRIMARY KEY,
+	name text NOT NULL,
+	applied_at timestamptz NOT NULL
+)`
+
+// Migration is a schema change read from sql/<version>_<name>.up.sql and the matching
+// .down.sql file
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes a migration, AppliedAt is nil when it hasn't been applied
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	Known     bool // Whether this binary embeds the migration
+}
+
+type schemaMigration struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Load returns the embedded migrations ordered by version
+func Load() ([]Migration, error) {
+	names, err := fs.Glob(files, "sql/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, name := range names {
+		base := path.Base(name)
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s is neither .up.sql nor .down.sql", base)
+		}
+
+		versionPart, migrationName, found := strings.Cut(strings.TrimSuffix(base, "."+direction+".sql"), "_")
+		version, err := strconv.ParseInt(versionPart, 10, 64)
+		if !found || err != nil || version < 1 {
+			return nil, fmt.Errorf("migration %s must be named <version>_<name>.%s.sql", base, direction)
+		}
+
+		content, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: migrationName}
+			byVersion[version] = migration
+		} else if migration.Name != migrationName {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, migrationName)
+		}
+
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d %s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator applies and rolls back the embedded migrations, recording them in schema_migrations
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New creates a migrator for the embedded migrations
+func New(db *gorm.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies every pending migration in order and returns how many were applied. Each
+// migration runs in its own transaction.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	var count int
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		applied, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			legacy, err := isLegacySchema(conn)
+			if err != nil {
+				return err
+			}
+			if legacy {
+				if err := m.baselineLegacySchema(conn); err != nil {
+					return err
+				}
+				if applied, err = appliedVersions(conn); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if _, done := applied[migration.Version]; done {
+				continue
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				if code, ok := codeMigrations[migration.Version]; ok {
+					if err := code(tx); err != nil {
+						return err
+					}
+				}
+				return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d %s failed: %w", migration.Version, migration.Name, err)
+			}
+
+			log.Printf("Applied migration %04d %s", migration.Version, migration.Name)
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Down rolls back the last steps applied migrations, newest first, and returns how many
+// were rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	var count int
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		var applied []schemaMigration
+		if err := conn.Order("version DESC").Limit(steps).Find(&applied).Error; err != nil {
+			return err
+		}
+
+		for _, row := range applied {
+			migration, ok := known[row.Version]
+			if !ok {
+				return fmt.Errorf("migration %04d %s is not known to this binary and can't be rolled back", row.Version, row.Name)
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+				return tx.Delete(&schemaMigration{}, "version = ?", migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("rollback of migration %04d %s failed: %w", migration.Version, migration.Name, err)
+			}
+
+			log.Printf("Rolled back migration %04d %s", migration.Version, migration.Name)
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Status lists the embedded migrations and those recorded in the database, ordered by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	db := m.db.WithContext(ctx)
+
+	var applied []schemaMigration
+	if db.Migrator().HasTable(&schemaMigration{}) {
+		if err := db.Find(&applied).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	byVersion := make(map[int64]*Status)
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = &Status{Version: migration.Version, Name: migration.Name, Known: true}
+	}
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		status, ok := byVersion[row.Version]
+		if !ok {
+			status = &Status{Version: row.Version, Name: row.Name}
+			byVersion[row.Version] = status
+		}
+		status.AppliedAt = &appliedAt
+	}
+
+	statuses := make([]Status, 0, len(byVersion))
+	for _, status := range byVersion {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// withLock runs fn on a single connection holding the migration lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", lockKey).Error; err != nil {
+			return err
+		}
+		// The connection goes back to the pool, the lock must be released even if ctx is done
+		defer conn.WithContext(context.Background()).Exec("SELECT pg_advisory_unlock(?)", lockKey)
+
+		if err := conn.Exec(createSchemaMigrationsSQL).Error; err != nil {
+			return err
+		}
+
+		return fn(conn)
+	})
+}
+
+// baselineLegacySchema upgrades a database created by AutoMigrate to the baseline schema
+// and records every migration up to it as applied
+func (m *Migrator) baselineLegacySchema(conn *gorm.DB) error {
+	log.Println("Upgrading a database created before versioned migrations...")
+
+	if err := models.UpgradeLegacySchema(conn); err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version > baselineVersion {
+			break
+		}
+		if err := conn.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error; err != nil {
+			return err
+		}
+		log.Printf("Marked migration %04d %s as applied", migration.Version, migration.Name)
+	}
+
+	return nil
+}
+
+func appliedVersions(db *gorm.DB) (map[int64]struct{}, error) {
+	var versions []int64
+	if err := db.Model(&schemaMigration{}).Pluck("version", &versions).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]struct{}, len(versions))
+	for _, version := range versions {
+		applied[version] = struct{}{}
+	}
+	return applied, nil
+}
+
+// isLegacySchema reports whether the database holds tables created before versioned migrations
+func isLegacySchema(db *gorm.DB) (bool, error) {
+	var legacy bool
+	err := db.Raw(`SELECT to_regclass('projects') IS NOT NULL`).Scan(&legacy).Error
+	return legacy, err
+}
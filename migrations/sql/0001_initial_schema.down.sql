@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS rollup_watermarks;
+DROP TABLE IF EXISTS daily_event_rollups;
+DROP TABLE IF EXISTS daily_activity_rollups;
+DROP TABLE IF EXISTS purge_runs;
+DROP TABLE IF EXISTS erasure_logs;
+DROP TABLE IF EXISTS event_validation_stats;
+DROP TABLE IF EXISTS event_schemas;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS devices;
+DROP TABLE IF EXISTS auth_tokens;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS project_members;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS users;
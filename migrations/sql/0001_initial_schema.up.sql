@@ -0,0 +1,234 @@
+-- Schema of every table as created by AutoMigrate before versioned migrations existed.
+-- Monthly event partitions are created by the server, events outside of them go to events_default.
+
+CREATE TABLE users (
+	id uuid PRIMARY KEY,
+	email text NOT NULL CONSTRAINT uni_users_email UNIQUE,
+	password text NOT NULL,
+	name text NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE projects (
+	id uuid PRIMARY KEY,
+	name text NOT NULL,
+	owner_id uuid NOT NULL CONSTRAINT fk_projects_owner REFERENCES users (id),
+	schema_mode varchar(10) NOT NULL DEFAULT 'off',
+	session_timeout bigint NOT NULL DEFAULT 1800000000000,
+	ip_privacy varchar(10) NOT NULL DEFAULT 'truncate',
+	ip_hash_salt text NOT NULL DEFAULT '',
+	event_retention_days bigint NOT NULL DEFAULT 0,
+	session_retention_days bigint NOT NULL DEFAULT 0,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE INDEX idx_projects_deleted_at ON projects (deleted_at);
+
+CREATE TABLE project_members (
+	id uuid PRIMARY KEY,
+	project_id uuid NOT NULL CONSTRAINT fk_project_members_project REFERENCES projects (id),
+	user_id uuid NOT NULL CONSTRAINT fk_project_members_user REFERENCES users (id),
+	role varchar(20) NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE UNIQUE INDEX idx_project_members_project_user ON project_members (project_id, user_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_project_members_user_id ON project_members (user_id);
+CREATE INDEX idx_project_members_deleted_at ON project_members (deleted_at);
+
+CREATE TABLE api_keys (
+	id uuid PRIMARY KEY,
+	project_id uuid NOT NULL CONSTRAINT fk_api_keys_project REFERENCES projects (id),
+	name text NOT NULL,
+	prefix text NOT NULL,
+	key_hash text NOT NULL,
+	scopes jsonb DEFAULT '[]',
+	created_by_id uuid CONSTRAINT fk_api_keys_created_by REFERENCES users (id),
+	last_used_at timestamptz,
+	expires_at timestamptz,
+	revoked_at timestamptz,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE INDEX idx_api_keys_project_id ON api_keys (project_id);
+CREATE UNIQUE INDEX idx_api_keys_key_hash ON api_keys (key_hash);
+CREATE INDEX idx_api_keys_deleted_at ON api_keys (deleted_at);
+
+CREATE TABLE auth_tokens (
+	id uuid PRIMARY KEY,
+	user_id uuid NOT NULL CONSTRAINT fk_auth_tokens_user REFERENCES users (id),
+	family_id uuid,
+	token_hash text NOT NULL,
+	refresh_token_hash text,
+	expires_at timestamptz NOT NULL,
+	refresh_expires_at timestamptz,
+	last_used_at timestamptz NOT NULL,
+	rotated_at timestamptz,
+	revoked_at timestamptz,
+	user_agent text,
+	ip_address text,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE INDEX idx_auth_tokens_family_id ON auth_tokens (family_id);
+CREATE UNIQUE INDEX idx_auth_tokens_token_hash ON auth_tokens (token_hash);
+CREATE INDEX idx_auth_tokens_refresh_token_hash ON auth_tokens (refresh_token_hash);
+CREATE INDEX idx_auth_tokens_deleted_at ON auth_tokens (deleted_at);
+
+CREATE TABLE devices (
+	id uuid PRIMARY KEY,
+	project_id uuid NOT NULL CONSTRAINT fk_devices_project REFERENCES projects (id),
+	identifier text NOT NULL,
+	platform text NOT NULL,
+	platform_version text NOT NULL,
+	app_version text NOT NULL,
+	first_seen timestamptz NOT NULL,
+	last_seen timestamptz NOT NULL,
+	ip_address text NOT NULL,
+	country text,
+	region text,
+	city text,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE INDEX idx_devices_deleted_at ON devices (deleted_at);
+
+CREATE TABLE sessions (
+	id uuid PRIMARY KEY,
+	project_id uuid NOT NULL CONSTRAINT fk_sessions_project REFERENCES projects (id),
+	device_id uuid NOT NULL CONSTRAINT fk_sessions_device REFERENCES devices (id),
+	begin_at timestamptz NOT NULL,
+	end_at timestamptz,
+	duration bigint,
+	last_activity_at timestamptz,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE INDEX idx_sessions_deleted_at ON sessions (deleted_at);
+
+CREATE TABLE events (
+	id uuid NOT NULL,
+	project_id uuid NOT NULL REFERENCES projects (id),
+	client_event_id uuid,
+	device_id uuid NOT NULL REFERENCES devices (id),
+	session_id uuid,
+	event_type varchar(50) NOT NULL,
+	event_name text NOT NULL,
+	payloads jsonb DEFAULT '{}',
+	timestamp timestamptz NOT NULL,
+	received_at timestamptz NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id, timestamp)
+) PARTITION BY RANGE (timestamp);
+CREATE TABLE events_default PARTITION OF events DEFAULT;
+CREATE INDEX idx_events_project_timestamp ON events (project_id, timestamp);
+CREATE INDEX idx_events_project_name_timestamp ON events (project_id, event_name, timestamp);
+CREATE INDEX idx_events_device_timestamp ON events (device_id, timestamp);
+CREATE INDEX idx_events_session_id ON events (session_id);
+CREATE INDEX idx_events_deleted_at ON events (deleted_at);
+CREATE UNIQUE INDEX idx_events_project_client_event ON events (project_id, client_event_id, timestamp) WHERE client_event_id IS NOT NULL;
+
+CREATE TABLE event_schemas (
+	id uuid PRIMARY KEY,
+	project_id uuid NOT NULL CONSTRAINT fk_event_schemas_project REFERENCES projects (id),
+	event_name text NOT NULL,
+	event_type varchar(50),
+	description text,
+	schema jsonb NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE UNIQUE INDEX idx_event_schemas_project_name ON event_schemas (project_id, event_name) WHERE deleted_at IS NULL;
+CREATE INDEX idx_event_schemas_deleted_at ON event_schemas (deleted_at);
+
+CREATE TABLE event_validation_stats (
+	id uuid PRIMARY KEY,
+	project_id uuid NOT NULL CONSTRAINT fk_event_validation_stats_project REFERENCES projects (id),
+	day date NOT NULL,
+	event_name text NOT NULL,
+	warned bigint NOT NULL DEFAULT 0,
+	rejected bigint NOT NULL DEFAULT 0,
+	created_at timestamptz,
+	updated_at timestamptz
+);
+CREATE UNIQUE INDEX idx_event_validation_stats_project_day_name ON event_validation_stats (project_id, day, event_name);
+
+CREATE TABLE erasure_logs (
+	id uuid PRIMARY KEY,
+	project_id uuid NOT NULL CONSTRAINT fk_erasure_logs_project REFERENCES projects (id),
+	sequence bigint NOT NULL,
+	identifier_hash text NOT NULL,
+	devices bigint NOT NULL,
+	sessions bigint NOT NULL,
+	events bigint NOT NULL,
+	requested_by text NOT NULL,
+	erased_at timestamptz NOT NULL,
+	previous_hash text NOT NULL,
+	hash text NOT NULL,
+	created_at timestamptz
+);
+CREATE UNIQUE INDEX idx_erasure_logs_project_sequence ON erasure_logs (project_id, sequence);
+CREATE INDEX idx_erasure_logs_identifier_hash ON erasure_logs (identifier_hash);
+
+CREATE TABLE purge_runs (
+	id uuid PRIMARY KEY,
+	project_id uuid NOT NULL,
+	started_at timestamptz NOT NULL,
+	finished_at timestamptz NOT NULL,
+	events_purged bigint NOT NULL,
+	sessions_purged bigint NOT NULL,
+	devices_purged bigint NOT NULL,
+	error text,
+	created_at timestamptz
+);
+CREATE INDEX idx_purge_runs_project_started ON purge_runs (project_id, started_at);
+
+CREATE TABLE daily_activity_rollups (
+	id uuid PRIMARY KEY,
+	project_id uuid NOT NULL CONSTRAINT fk_daily_activity_rollups_project REFERENCES projects (id),
+	day date NOT NULL,
+	platform text NOT NULL,
+	country text NOT NULL,
+	app_version text NOT NULL,
+	active_devices bigint NOT NULL,
+	new_devices bigint NOT NULL,
+	sessions bigint NOT NULL,
+	ended_sessions bigint NOT NULL,
+	total_duration bigint NOT NULL,
+	created_at timestamptz
+);
+CREATE UNIQUE INDEX idx_daily_activity_rollups_project_day_dims ON daily_activity_rollups (project_id, day, platform, country, app_version);
+
+CREATE TABLE daily_event_rollups (
+	id uuid PRIMARY KEY,
+	project_id uuid NOT NULL CONSTRAINT fk_daily_event_rollups_project REFERENCES projects (id),
+	day date NOT NULL,
+	event_type varchar(50) NOT NULL,
+	event_name text NOT NULL,
+	platform text NOT NULL,
+	country text NOT NULL,
+	app_version text NOT NULL,
+	events bigint NOT NULL,
+	devices bigint NOT NULL,
+	created_at timestamptz
+);
+CREATE UNIQUE INDEX idx_daily_event_rollups_project_day_dims ON daily_event_rollups (project_id, day, event_type, event_name, platform, country, app_version);
+
+CREATE TABLE rollup_watermarks (
+	project_id uuid PRIMARY KEY CONSTRAINT fk_rollup_watermarks_project REFERENCES projects (id),
+	from_day date NOT NULL,
+	watermark date NOT NULL,
+	updated_at timestamptz
+);
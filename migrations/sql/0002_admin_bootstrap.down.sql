@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS setup_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS must_change_password;
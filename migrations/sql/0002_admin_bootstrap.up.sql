@@ -0,0 +1,10 @@
+-- Replaces the default admin created with well-known credentials by a first-run setup
+ALTER TABLE users ADD COLUMN IF NOT EXISTS must_change_password boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS setup_tokens (
+	id uuid PRIMARY KEY,
+	token_hash text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_setup_tokens_token_hash ON setup_tokens (token_hash);
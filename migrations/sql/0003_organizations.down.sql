@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS project_usages;
+ALTER TABLE projects DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
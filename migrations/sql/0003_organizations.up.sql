@@ -0,0 +1,69 @@
+-- Organizations own projects and their members. Each existing project moves to an
+-- organization owned by the project's owner, one organization per owner.
+CREATE TABLE IF NOT EXISTS organizations (
+	id uuid PRIMARY KEY,
+	name text NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_organizations_deleted_at ON organizations (deleted_at);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+	id uuid PRIMARY KEY,
+	organization_id uuid NOT NULL CONSTRAINT fk_organization_members_organization REFERENCES organizations (id),
+	user_id uuid NOT NULL CONSTRAINT fk_organization_members_user REFERENCES users (id),
+	role varchar(20) NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organization_members_organization_user ON organization_members (organization_id, user_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_organization_members_user_id ON organization_members (user_id);
+CREATE INDEX IF NOT EXISTS idx_organization_members_deleted_at ON organization_members (deleted_at);
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS organization_id uuid;
+CREATE INDEX IF NOT EXISTS idx_projects_organization_id ON projects (organization_id);
+
+-- The IDs are derived from the owner's ID, so no UUID generator is needed and the
+-- statements below agree on them
+INSERT INTO organizations (id, name, created_at, updated_at)
+SELECT md5('organization:' || u.id::text)::uuid, u.name || '''s organization', now(), now()
+FROM users u
+WHERE u.id IN (SELECT owner_id FROM projects WHERE organization_id IS NULL)
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO organization_members (id, organization_id, user_id, role, created_at, updated_at)
+SELECT md5('organization_member:' || u.id::text)::uuid, md5('organization:' || u.id::text)::uuid, u.id, 'owner', now(), now()
+FROM users u
+WHERE u.id IN (SELECT owner_id FROM projects WHERE organization_id IS NULL)
+ON CONFLICT (id) DO NOTHING;
+
+UPDATE projects SET organization_id = md5('organization:' || owner_id::text)::uuid WHERE organization_id IS NULL;
+
+ALTER TABLE projects ALTER COLUMN organization_id SET NOT NULL;
+-- Databases upgraded from AutoMigrate may already have the constraint
+ALTER TABLE projects DROP CONSTRAINT IF EXISTS fk_projects_organization;
+ALTER TABLE projects ADD CONSTRAINT fk_projects_organization FOREIGN KEY (organization_id) REFERENCES organizations (id);
+
+CREATE TABLE IF NOT EXISTS project_usages (
+	id uuid PRIMARY KEY,
+	organization_id uuid NOT NULL CONSTRAINT fk_project_usages_organization REFERENCES organizations (id),
+	project_id uuid NOT NULL CONSTRAINT fk_project_usages_project REFERENCES projects (id),
+	month date NOT NULL,
+	events_ingested bigint NOT NULL DEFAULT 0,
+	created_at timestamptz,
+	updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_project_usages_organization_project_month ON project_usages (organization_id, project_id, month);
+
+-- Usage before this migration is counted from the events still stored
+INSERT INTO project_usages (id, organization_id, project_id, month, events_ingested, created_at, updated_at)
+SELECT md5('project_usage:' || e.project_id::text || ':' || e.month::text)::uuid, p.organization_id, e.project_id, e.month, e.events, now(), now()
+FROM (
+	SELECT project_id, date_trunc('month', received_at AT TIME ZONE 'UTC')::date AS month, COUNT(*) AS events
+	FROM events
+	GROUP BY 1, 2
+) e
+JOIN projects p ON p.id = e.project_id
+ON CONFLICT (organization_id, project_id, month) DO NOTHING;
@@ -0,0 +1,13 @@
+-- Events the ingestion pipeline failed to write after retrying, written again later
+CREATE TABLE IF NOT EXISTS event_dead_letters (
+	id uuid PRIMARY KEY,
+	project_id uuid NOT NULL,
+	device_id uuid NOT NULL,
+	event jsonb NOT NULL,
+	error text,
+	attempts bigint NOT NULL DEFAULT 0,
+	created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_event_dead_letters_project_id ON event_dead_letters (project_id);
+CREATE INDEX IF NOT EXISTS idx_event_dead_letters_device_id ON event_dead_letters (device_id);
+CREATE INDEX IF NOT EXISTS idx_event_dead_letters_created_at ON event_dead_letters (created_at);
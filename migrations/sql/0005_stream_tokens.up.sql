@@ -0,0 +1,11 @@
+-- Single-use tokens letting browsers open live streams, EventSource can't send headers
+CREATE TABLE IF NOT EXISTS stream_tokens (
+	id uuid PRIMARY KEY,
+	token_hash text NOT NULL,
+	user_id uuid NOT NULL,
+	project_id uuid NOT NULL,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stream_tokens_token_hash ON stream_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_stream_tokens_expires_at ON stream_tokens (expires_at);
@@ -0,0 +1,2 @@
+-- The rekeyed digests can't be turned back into plain SHA-256 digests, they stay keyed
+ALTER TABLE projects DROP COLUMN IF EXISTS identifier_hash_salt;
@@ -0,0 +1,9 @@
+-- Erased device identifiers are logged as an HMAC keyed per project instead of a plain
+-- SHA-256, which could be reversed by trying candidate identifiers. The existing digests
+-- are rekeyed in code once the keys exist.
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS identifier_hash_salt text NOT NULL DEFAULT '';
+
+-- gen_random_uuid draws from a cryptographically secure source, two give 244 random bits
+UPDATE projects
+SET identifier_hash_salt = replace(gen_random_uuid()::text || gen_random_uuid()::text, '-', '')
+WHERE identifier_hash_salt = '';
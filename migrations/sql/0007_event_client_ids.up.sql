@@ -0,0 +1,19 @@
+-- Client event IDs are only unique per event time in the partitioned events table, so
+-- retries with another timestamp were stored again. They are now claimed in their own
+-- table, in the transaction writing the event.
+CREATE TABLE IF NOT EXISTS event_client_ids (
+	project_id uuid NOT NULL,
+	client_event_id uuid NOT NULL,
+	event_id uuid NOT NULL,
+	created_at timestamptz,
+	PRIMARY KEY (project_id, client_event_id)
+);
+CREATE INDEX IF NOT EXISTS idx_event_client_ids_created_at ON event_client_ids (created_at);
+
+-- The earliest copy of an event stored more than once keeps the ID
+INSERT INTO event_client_ids (project_id, client_event_id, event_id, created_at)
+SELECT DISTINCT ON (project_id, client_event_id) project_id, client_event_id, id, received_at
+FROM events
+WHERE client_event_id IS NOT NULL
+ORDER BY project_id, client_event_id, received_at, id
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_devices_project_created_at;
+DROP INDEX IF EXISTS idx_sessions_project_updated_at;
+DROP INDEX IF EXISTS idx_events_project_created_at;
+ALTER TABLE rollup_watermarks DROP COLUMN IF EXISTS aggregated_at;
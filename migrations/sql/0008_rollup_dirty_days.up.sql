@@ -0,0 +1,7 @@
+-- Days aggregated before the rollup late window are aggregated again when rows are
+-- written for them after a run, found through the write times of the raw rows
+ALTER TABLE rollup_watermarks ADD COLUMN IF NOT EXISTS aggregated_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_events_project_created_at ON events (project_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_sessions_project_updated_at ON sessions (project_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_devices_project_created_at ON devices (project_id, created_at);
@@ -0,0 +1,3 @@
+-- Locked users can't log in until an operator resets their password. The server locks the
+-- admin that earlier versions created with well-known credentials if it still uses them.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_at timestamptz;
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ApiKeyScopeIngest     = "ingest"      // Register devices, sessions and events
+	ApiKeyScopeReadConfig = "read-config" // Read project configuration
+	ApiKeyScopePrivacy    = "privacy"     // Export and erase the data of a player, for game backends only
+)
+
+// apiKeySecretPrefix marks generated keys so they are easy to recognise in code and logs
+const apiKeySecretPrefix = "kg_"
+
+// apiKeyDisplayLength is the number of key characters kept in plaintext for display
+const apiKeyDisplayLength = 11
+
+// AllApiKeyScopes lists every scope an API key can be granted
+var AllApiKeyScopes = []string{ApiKeyScopeIngest, ApiKeyScopeReadConfig, ApiKeyScopePrivacy}
+
+// DefaultApiKeyScopes are granted to keys created without explicit scopes. Keys shipped
+// in game clients must never be able to erase player data, so privacy is left out.
+var DefaultApiKeyScopes = []string{ApiKeyScopeIngest, ApiKeyScopeReadConfig}
+
+type ApiKeyScopes []string
+
+func (s ApiKeyScopes) Value() (driver.Value, error) {
+	if s == nil {
+		return json.Marshal([]string{})
+	}
+	return json.Marshal(s)
+}
+
+func (s *ApiKeyScopes) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, &s)
+}
+
+// Contains reports whether the scope is granted
+func (s ApiKeyScopes) Contains(scope string) bool {
+	for _, granted := range s {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type ApiKey struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	ProjectID   uuid.UUID      `json:"project_id" gorm:"type:uuid;not null;index"`
+	Name        string         `json:"name" gorm:"not null"`
+	Prefix      string         `json:"prefix" gorm:"not null"`        // First characters of the key, for display
+	KeyHash     string         `json:"-" gorm:"uniqueIndex;not null"` // SHA-256 digest of the key
+	Secret      string         `json:"-" gorm:"-"`                    // Plaintext key, only set right after creation
+	Scopes      ApiKeyScopes   `json:"scopes" gorm:"type:jsonb;default:'[]'"`
+	CreatedByID *uuid.UUID     `json:"created_by_id,omitempty" gorm:"type:uuid"`
+	LastUsedAt  *time.Time     `json:"last_used_at,omitempty"`
+	ExpiresAt   *time.Time     `json:"expires_at,omitempty"` // Key stops working after this time (used for grace periods)
+	RevokedAt   *time.Time     `json:"revoked_at,omitempty"` // Key was revoked immediately
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	Project     Project        `json:"-" gorm:"foreignKey:ProjectID;references:ID"`
+	CreatedBy   *User          `json:"-" gorm:"foreignKey:CreatedByID;references:ID"`
+}
+
+func (apiKey *ApiKey) BeforeCreate(_ *gorm.DB) error {
+	if apiKey.ID == uuid.Nil {
+		apiKey.ID = uuid.New()
+	}
+
+	if apiKey.KeyHash == "" {
+		secret, err := GenerateSecret(apiKeySecretPrefix, 24)
+		if err != nil {
+			return err
+		}
+		apiKey.SetSecret(secret)
+	}
+
+	if apiKey.Scopes == nil {
+		apiKey.Scopes = append(ApiKeyScopes{}, DefaultApiKeyScopes...)
+	}
+
+	return nil
+}
+
+// SetSecret stores the digest and display prefix of the given plaintext key
+func (apiKey *ApiKey) SetSecret(secret string) {
+	apiKey.Secret = secret
+	apiKey.KeyHash = HashSecret(secret)
+	apiKey.Prefix = secretDisplayPrefix(secret)
+}
+
+// IsActive reports whether the key can still be used to authenticate at the given time
+func (apiKey *ApiKey) IsActive(at time.Time) bool {
+	if apiKey.RevokedAt != nil && !apiKey.RevokedAt.After(at) {
+		return false
+	}
+
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(at) {
+		return false
+	}
+
+	return true
+}
+
+// ActiveApiKeys restricts a query to keys that are neither revoked nor expired
+func ActiveApiKeys(db *gorm.DB) *gorm.DB {
+	now := time.Now()
+	return db.Where("(revoked_at IS NULL OR revoked_at > ?)", now).
+		Where("(expires_at IS NULL OR expires_at > ?)", now)
+}
+
+func secretDisplayPrefix(secret string) string {
+	if len(secret) <= apiKeyDisplayLength {
+		return secret
+	}
+	return secret[:apiKeyDisplayLength]
+}
@@ -7,16 +7,25 @@ import (
 	"gorm.io/gorm"
 )
 
+// AuthToken is an access token paired with a refresh token. Every refresh rotates
+// both into a new row of the same family, so a family represents one login on one device.
 type AuthToken struct {
-	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
-	UserID     uuid.UUID      `json:"user_id" gorm:"type:uuid;not null"`
-	Token      string         `json:"token" gorm:"not null"`
-	ExpiresAt  time.Time      `json:"expires_at" gorm:"not null"`
-	LastUsedAt time.Time      `json:"last_used_at" gorm:"not null"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
-	User       User           `json:"-" gorm:"foreignKey:UserID;references:ID"`
+	ID               uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	UserID           uuid.UUID      `json:"user_id" gorm:"type:uuid;not null"`
+	FamilyID         uuid.UUID      `json:"family_id" gorm:"type:uuid;index"` // Shared by all tokens rotated from the same login
+	TokenHash        string         `json:"-" gorm:"uniqueIndex;not null"`    // SHA-256 digest of the issued JWT
+	RefreshTokenHash string         `json:"-" gorm:"index"`                   // SHA-256 digest of the refresh token
+	ExpiresAt        time.Time      `json:"expires_at" gorm:"not null"`       // Access token expiry
+	RefreshExpiresAt time.Time      `json:"refresh_expires_at"`               // Refresh token expiry
+	LastUsedAt       time.Time      `json:"last_used_at" gorm:"not null"`     // Last authenticated request
+	RotatedAt        *time.Time     `json:"rotated_at,omitempty"`             // Refresh token was exchanged for a new pair
+	RevokedAt        *time.Time     `json:"revoked_at,omitempty"`             // Token was logged out or revoked
+	UserAgent        string         `json:"user_agent,omitempty"`             // Client that logged in
+	IpAddress        string         `json:"ip_address,omitempty"`             // Address the token was issued to
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+	User             User           `json:"-" gorm:"foreignKey:UserID;references:ID"`
 }
 
 func (token *AuthToken) BeforeCreate(_ *gorm.DB) error {
@@ -24,9 +33,25 @@ func (token *AuthToken) BeforeCreate(_ *gorm.DB) error {
 		token.ID = uuid.New()
 	}
 
+	if token.FamilyID == uuid.Nil {
+		token.FamilyID = token.ID
+	}
+
 	if token.LastUsedAt.IsZero() {
 		token.LastUsedAt = time.Now()
 	}
 
 	return nil
 }
+
+// ActiveAuthTokens restricts a query to tokens whose refresh token can still be used
+func ActiveAuthTokens(db *gorm.DB) *gorm.DB {
+	return db.Where("revoked_at IS NULL AND rotated_at IS NULL AND refresh_expires_at > ?", time.Now())
+}
+
+// RevokeAuthTokenFamily revokes every token of a login family that is still valid
+func RevokeAuthTokenFamily(db *gorm.DB, familyID uuid.UUID) error {
+	return db.Model(&AuthToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
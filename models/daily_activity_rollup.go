@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DailyActivityRollup aggregates the sessions and new devices of a project on a UTC day,
+// per device platform, country and app version. Devices are counted under their current
+// attributes, so each device falls in a single row of a day and active devices add up.
+type DailyActivityRollup struct {
+	ID            uuid.UUID     `json:"id" gorm:"type:uuid;primary_key"`
+	ProjectID     uuid.UUID     `json:"project_id" gorm:"type:uuid;not null;uniqueIndex:idx_daily_activity_rollups_project_day_dims,priority:1"`
+	Day           time.Time     `json:"day" gorm:"type:date;not null;uniqueIndex:idx_daily_activity_rollups_project_day_dims,priority:2"`
+	Platform      string        `json:"platform" gorm:"not null;uniqueIndex:idx_daily_activity_rollups_project_day_dims,priority:3"`
+	Country       string        `json:"country" gorm:"not null;uniqueIndex:idx_daily_activity_rollups_project_day_dims,priority:4"`
+	AppVersion    string        `json:"app_version" gorm:"not null;uniqueIndex:idx_daily_activity_rollups_project_day_dims,priority:5"`
+	ActiveDevices int64         `json:"active_devices" gorm:"not null"` // Distinct devices that began a session
+	NewDevices    int64         `json:"new_devices" gorm:"not null"`    // Devices first seen
+	Sessions      int64         `json:"sessions" gorm:"not null"`       // Sessions begun
+	EndedSessions int64         `json:"ended_sessions" gorm:"not null"` // Sessions begun that have ended
+	TotalDuration time.Duration `json:"total_duration" gorm:"not null"` // Summed duration of the ended sessions
+	CreatedAt     time.Time     `json:"created_at"`
+	Project       Project       `json:"-" gorm:"foreignKey:ProjectID;references:ID"`
+}
+
+func (rollup *DailyActivityRollup) BeforeCreate(_ *gorm.DB) error {
+	if rollup.ID == uuid.Nil {
+		rollup.ID = uuid.New()
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DailyEventRollup counts the events of a project on a UTC day, by event time, per event
+// type and name and per device platform, country and app version
+type DailyEventRollup struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	ProjectID  uuid.UUID `json:"project_id" gorm:"type:uuid;not null;uniqueIndex:idx_daily_event_rollups_project_day_dims,priority:1"`
+	Day        time.Time `json:"day" gorm:"type:date;not null;uniqueIndex:idx_daily_event_rollups_project_day_dims,priority:2"`
+	EventType  string    `json:"event_type" gorm:"type:varchar(50);not null;uniqueIndex:idx_daily_event_rollups_project_day_dims,priority:3"`
+	EventName  string    `json:"event_name" gorm:"not null;uniqueIndex:idx_daily_event_rollups_project_day_dims,priority:4"`
+	Platform   string    `json:"platform" gorm:"not null;uniqueIndex:idx_daily_event_rollups_project_day_dims,priority:5"`
+	Country    string    `json:"country" gorm:"not null;uniqueIndex:idx_daily_event_rollups_project_day_dims,priority:6"`
+	AppVersion string    `json:"app_version" gorm:"not null;uniqueIndex:idx_daily_event_rollups_project_day_dims,priority:7"`
+	Events     int64     `json:"events" gorm:"not null"`
+	Devices    int64     `json:"devices" gorm:"not null"` // Distinct devices that sent the events
+	CreatedAt  time.Time `json:"created_at"`
+	Project    Project   `json:"-" gorm:"foreignKey:ProjectID;references:ID"`
+}
+
+func (rollup *DailyEventRollup) BeforeCreate(_ *gorm.DB) error {
+	if rollup.ID == uuid.Nil {
+		rollup.ID = uuid.New()
+	}
+
+	return nil
+}
@@ -16,8 +16,10 @@ type Device struct {
 	AppVersion      string         `json:"app_version" gorm:"not null"`          // App version
 	FirstSeen       time.Time      `json:"first_seen" gorm:"not null"`           // First session timestamp
 	LastSeen        time.Time      `json:"last_seen" gorm:"not null"`            // Last session timestamp
-	IpAddress       string         `json:"ip_address,omitempty" gorm:"not null"` // Hashed/anonymized IP address
+	IpAddress       string         `json:"ip_address,omitempty" gorm:"not null"` // Stored according to the project's IP privacy mode
 	Country         string         `json:"country,omitempty"`                    // Country based on IP (optional)
+	Region          string         `json:"region,omitempty"`                     // Region based on IP (optional)
+	City            string         `json:"city,omitempty"`                       // City based on IP (optional)
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
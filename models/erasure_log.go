@@ -0,0 +1,169 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrErasureLogImmutable is returned when an erasure log entry is updated or deleted
+var ErrErasureLogImmutable = errors.New("erasure log entries can't be modified")
+
+// ErasureLog records the erasure of a player's data. The entries of a project form a hash
+// chain: each hash covers the entry and the hash of the previous one, so modifying or
+// removing an entry breaks every hash after it.
+type ErasureLog struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	ProjectID      uuid.UUID `json:"project_id" gorm:"type:uuid;not null;uniqueIndex:idx_erasure_logs_project_sequence"`
+	Sequence       int64     `json:"sequence" gorm:"not null;uniqueIndex:idx_erasure_logs_project_sequence"` // Position in the project's chain, starting at 1
+	IdentifierHash string    `json:"identifier_hash" gorm:"not null;index"`                                  // HMAC-SHA256 of the device identifier, which was erased
+	Devices        int64     `json:"devices" gorm:"not null"`                                                // Rows deleted per table
+	Sessions       int64     `json:"sessions" gorm:"not null"`
+	Events         int64     `json:"events" gorm:"not null"`
+	RequestedBy    string    `json:"requested_by" gorm:"not null"` // user:<id> or api_key:<id>
+	ErasedAt       time.Time `json:"erased_at" gorm:"not null"`
+	PreviousHash   string    `json:"previous_hash" gorm:"not null"` // Empty for the first entry of a project
+	Hash           string    `json:"hash" gorm:"not null"`
+	CreatedAt      time.Time `json:"created_at"`
+	Project        Project   `json:"-" gorm:"foreignKey:ProjectID;references:ID"`
+}
+
+func (erasure *ErasureLog) BeforeCreate(_ *gorm.DB) error {
+	if erasure.ID == uuid.Nil {
+		erasure.ID = uuid.New()
+	}
+
+	return nil
+}
+
+func (erasure *ErasureLog) BeforeUpdate(_ *gorm.DB) error {
+	return ErrErasureLogImmutable
+}
+
+func (erasure *ErasureLog) BeforeDelete(_ *gorm.DB) error {
+	return ErrErasureLogImmutable
+}
+
+// HashDeviceIdentifier returns the digest under which an erased device identifier of the
+// project is logged. Identifiers are easy to guess, so the digest is keyed with the
+// project's secret to keep them from being recovered by trying candidates.
+func (project Project) HashDeviceIdentifier(identifier string) string {
+	mac := hmac.New(sha256.New, []byte(project.IdentifierHashSalt))
+	mac.Write([]byte(identifier))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ComputeHash returns the chain hash of the entry
+func (erasure ErasureLog) ComputeHash() string {
+	content := fmt.Sprintf("%s|%d|%s|%d|%d|%d|%s|%s|%s",
+		erasure.ProjectID,
+		erasure.Sequence,
+		erasure.IdentifierHash,
+		erasure.Devices,
+		erasure.Sessions,
+		erasure.Events,
+		erasure.RequestedBy,
+		erasure.ErasedAt.UTC().Format(time.RFC3339Nano),
+		erasure.PreviousHash,
+	)
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// AppendErasureLog chains the entry after the last one of its project and creates it. It
+// must run in the transaction that erased the data, which it locks against concurrent appends.
+func AppendErasureLog(tx *gorm.DB, erasure *ErasureLog) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "erasure_logs/"+erasure.ProjectID.String()).Error; err != nil {
+		return err
+	}
+
+	var previous ErasureLog
+	err := tx.Model(&ErasureLog{}).
+		Where("project_id = ?", erasure.ProjectID).
+		Order("sequence DESC").
+		First(&previous).Error
+	switch {
+	case err == nil:
+		erasure.Sequence = previous.Sequence + 1
+		erasure.PreviousHash = previous.Hash
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		erasure.Sequence = 1
+		erasure.PreviousHash = ""
+	default:
+		return err
+	}
+
+	// Postgres keeps microseconds, the hash must survive a round trip
+	if erasure.ErasedAt.IsZero() {
+		erasure.ErasedAt = time.Now()
+	}
+	erasure.ErasedAt = erasure.ErasedAt.UTC().Truncate(time.Microsecond)
+	erasure.Hash = erasure.ComputeHash()
+
+	return tx.Create(erasure).Error
+}
+
+// VerifyErasureLogs checks the chain of a project's entries ordered by sequence. It returns
+// the sequence of the first entry that doesn't match its hash or predecessor, or 0 when
+// the chain is intact.
+func VerifyErasureLogs(erasures []ErasureLog) int64 {
+	previousHash := ""
+	for i, erasure := range erasures {
+		if erasure.Sequence != int64(i+1) || erasure.PreviousHash != previousHash || erasure.ComputeHash() != erasure.Hash {
+			return int64(i + 1)
+		}
+		previousHash = erasure.Hash
+	}
+	return 0
+}
+
+// RekeyErasureLogs replaces the unkeyed SHA-256 identifier digests logged by earlier
+// versions with the project's keyed digest of them, and chains the entries again. The
+// chain of a project is only rewritten when it is intact, so earlier tampering stays visible.
+func RekeyErasureLogs(tx *gorm.DB) error {
+	var projects []Project
+	if err := tx.Unscoped().Model(&Project{}).
+		Select("id, identifier_hash_salt").
+		Where("id IN (?)", tx.Model(&ErasureLog{}).Select("project_id")).
+		Find(&projects).Error; err != nil {
+		return err
+	}
+
+	for _, project := range projects {
+		var erasures []ErasureLog
+		if err := tx.Model(&ErasureLog{}).
+			Where("project_id = ?", project.ID).
+			Order("sequence ASC").
+			Find(&erasures).Error; err != nil {
+			return err
+		}
+
+		if brokenAt := VerifyErasureLogs(erasures); brokenAt != 0 {
+			log.Printf("Warning: the erasure log of project %s is broken at entry %d, its identifier digests were not rekeyed", project.ID, brokenAt)
+			continue
+		}
+
+		previousHash := ""
+		for _, erasure := range erasures {
+			erasure.IdentifierHash = project.HashDeviceIdentifier(erasure.IdentifierHash)
+			erasure.PreviousHash = previousHash
+			erasure.Hash = erasure.ComputeHash()
+			previousHash = erasure.Hash
+
+			// Entries refuse updates through the model, this is the one sanctioned rewrite
+			if err := tx.Exec("UPDATE erasure_logs SET identifier_hash = ?, previous_hash = ?, hash = ? WHERE id = ?",
+				erasure.IdentifierHash, erasure.PreviousHash, erasure.Hash, erasure.ID).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -10,6 +10,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// EventTypePredefined marks events emitted by the SDK and the server itself, such as installs
+const EventTypePredefined = "predefined"
+
 type Payloads map[string]interface{}
 
 func (p Payloads) Value() (driver.Value, error) {
@@ -25,20 +28,23 @@ func (p *Payloads) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, &p)
 }
 
+// Event is stored in a table partitioned by month on Timestamp, which AutoMigrate can't express
 type Event struct {
-	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
-	ProjectID  uuid.UUID      `json:"project_id" gorm:"type:uuid;not null"`
-	DeviceID   uuid.UUID      `json:"device_id" gorm:"type:uuid;not null"`
-	EventType  string         `json:"event_type" gorm:"not null;type:varchar(50)"`
-	EventName  string         `json:"event_name" gorm:"not null"`              // For custom events
-	Payloads   Payloads       `json:"payloads" gorm:"type:jsonb;default:'{}'"` // JSON payloads
-	Timestamp  time.Time      `json:"timestamp" gorm:"not null"`               // When event occurred (client-side)
-	ReceivedAt time.Time      `json:"received_at" gorm:"not null"`             // When event was received by server
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
-	Project    Project        `json:"-" gorm:"foreignKey:ProjectID;references:ID"`
-	Device     Device         `json:"-" gorm:"foreignKey:DeviceID;references:ID"`
+	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	ProjectID     uuid.UUID      `json:"project_id" gorm:"type:uuid;not null"`
+	ClientEventID *uuid.UUID     `json:"client_event_id,omitempty" gorm:"type:uuid"` // SDK-generated ID used to drop retried events
+	DeviceID      uuid.UUID      `json:"device_id" gorm:"type:uuid;not null"`
+	SessionID     *uuid.UUID     `json:"session_id,omitempty" gorm:"type:uuid;index"` // Session the event happened in, if any
+	EventType     string         `json:"event_type" gorm:"not null;type:varchar(50)"`
+	EventName     string         `json:"event_name" gorm:"not null"`              // For custom events
+	Payloads      Payloads       `json:"payloads" gorm:"type:jsonb;default:'{}'"` // JSON payloads
+	Timestamp     time.Time      `json:"timestamp" gorm:"not null"`               // When event occurred (client-side)
+	ReceivedAt    time.Time      `json:"received_at" gorm:"not null"`             // When event was received by server
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	Project       Project        `json:"-" gorm:"foreignKey:ProjectID;references:ID"`
+	Device        Device         `json:"-" gorm:"foreignKey:DeviceID;references:ID"`
 }
 
 func (event *Event) BeforeCreate(_ *gorm.DB) error {
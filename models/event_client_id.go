@@ -0,0 +1,71 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// eventClientIDClaimBatchSize bounds the number of client event IDs claimed by one statement
+const eventClientIDClaimBatchSize = 1000
+
+// EventClientID records the client event IDs of a project that were written, so events
+// retried by an SDK are only stored once. The events table is partitioned by event time
+// and can't enforce a unique client event ID per project on its own.
+type EventClientID struct {
+	ProjectID     uuid.UUID `json:"project_id" gorm:"type:uuid;primaryKey"`
+	ClientEventID uuid.UUID `json:"client_event_id" gorm:"type:uuid;primaryKey"`
+	EventID       uuid.UUID `json:"event_id" gorm:"type:uuid;not null"` // The event written with the ID
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+}
+
+// ClaimEventClientIDs records the client event IDs of the events and returns the events
+// that may be written: those without a client event ID and those whose ID wasn't claimed
+// before. It must run in the transaction writing the events, so a claim is only kept
+// when its event is.
+func ClaimEventClientIDs(tx *gorm.DB, events []Event) ([]Event, error) {
+	var claims []Event
+	for _, event := range events {
+		if event.ClientEventID != nil {
+			claims = append(claims, event)
+		}
+	}
+	if len(claims) == 0 {
+		return events, nil
+	}
+
+	claimed := make(map[uuid.UUID]bool, len(claims))
+	now := time.Now()
+	for start := 0; start < len(claims); start += eventClientIDClaimBatchSize {
+		end := min(start+eventClientIDClaimBatchSize, len(claims))
+
+		values := make([]string, 0, end-start)
+		args := make([]interface{}, 0, 4*(end-start))
+		for _, event := range claims[start:end] {
+			values = append(values, "(?, ?, ?, ?)")
+			args = append(args, event.ProjectID, *event.ClientEventID, event.ID, now)
+		}
+
+		// RETURNING only lists the rows inserted, IDs claimed earlier are left out
+		var eventIDs []uuid.UUID
+		if err := tx.Raw(`INSERT INTO event_client_ids (project_id, client_event_id, event_id, created_at) VALUES `+
+			strings.Join(values, ", ")+` ON CONFLICT DO NOTHING RETURNING event_id`, args...).
+			Scan(&eventIDs).Error; err != nil {
+			return nil, err
+		}
+		for _, eventID := range eventIDs {
+			claimed[eventID] = true
+		}
+	}
+
+	kept := make([]Event, 0, len(events))
+	for _, event := range events {
+		if event.ClientEventID == nil || claimed[event.ID] {
+			kept = append(kept, event)
+		}
+	}
+
+	return kept, nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EventDeadLetter keeps an accepted event the ingestion pipeline failed to write, so it
+// can be written again later instead of being lost
+type EventDeadLetter struct {
+	ID        uuid.UUID       `json:"id" gorm:"type:uuid;primary_key"`
+	ProjectID uuid.UUID       `json:"project_id" gorm:"type:uuid;not null;index"`
+	DeviceID  uuid.UUID       `json:"device_id" gorm:"type:uuid;not null;index"`
+	Event     json.RawMessage `json:"event" gorm:"type:jsonb;not null"`   // The event as queued
+	Error     string          `json:"error"`                              // Why the last write failed
+	Attempts  int             `json:"attempts" gorm:"not null;default:0"` // Times the event was written back and failed again
+	CreatedAt time.Time       `json:"created_at" gorm:"index"`
+}
+
+func (letter *EventDeadLetter) BeforeCreate(_ *gorm.DB) error {
+	if letter.ID == uuid.Nil {
+		letter.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// NewEventDeadLetter wraps an event that could not be written
+func NewEventDeadLetter(event Event, cause error) (EventDeadLetter, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return EventDeadLetter{}, err
+	}
+
+	return EventDeadLetter{
+		ProjectID: event.ProjectID,
+		DeviceID:  event.DeviceID,
+		Event:     data,
+		Error:     cause.Error(),
+	}, nil
+}
+
+// DecodeEvent returns the event kept by the dead letter
+func (letter EventDeadLetter) DecodeEvent() (Event, error) {
+	var event Event
+	err := json.Unmarshal(letter.Event, &event)
+	return event, err
+}
@@ -0,0 +1,228 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EventPartitionsAhead is the number of monthly partitions kept ready after the current month
+const EventPartitionsAhead = 3
+
+// eventPartitionsBehind is the number of past monthly partitions created when the events
+// table is first partitioned. Older events go to the default partition.
+const eventPartitionsBehind = 36
+
+// eventDefaultPartition holds events outside of every monthly partition, such as events
+// with a wrong client clock
+const eventDefaultPartition = "events_default"
+
+// eventColumns lists the columns of the events table, in table order
+const eventColumns = "id, project_id, client_event_id, device_id, session_id, event_type, event_name, payloads, timestamp, received_at, created_at, updated_at, deleted_at"
+
+// createEventsTableSQL creates the events table partitioned by month on the event time. The
+// primary key and unique indexes of a partitioned table must include the partition key.
+const createEventsTableSQL = `
+CREATE TABLE %s (
+	id uuid NOT NULL,
+	project_id uuid NOT NULL REFERENCES projects (id),
+	client_event_id uuid,
+	device_id uuid NOT NULL REFERENCES devices (id),
+	session_id uuid,
+	event_type varchar(50) NOT NULL,
+	event_name text NOT NULL,
+	payloads jsonb DEFAULT '{}',
+	timestamp timestamptz NOT NULL,
+	received_at timestamptz NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id, timestamp)
+) PARTITION BY RANGE (timestamp)`
+
+// eventIndexesSQL creates the indexes of the events table, inherited by every partition.
+// Client event IDs are only unique per timestamp here, retries with another timestamp
+// are dropped by claiming their ID in event_client_ids when the event is written.
+var eventIndexesSQL = []string{
+	`CREATE INDEX IF NOT EXISTS idx_events_project_timestamp ON events (project_id, timestamp)`,
+	`CREATE INDEX IF NOT EXISTS idx_events_project_name_timestamp ON events (project_id, event_name, timestamp)`,
+	`CREATE INDEX IF NOT EXISTS idx_events_device_timestamp ON events (device_id, timestamp)`,
+	`CREATE INDEX IF NOT EXISTS idx_events_session_id ON events (session_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_events_deleted_at ON events (deleted_at)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_events_project_client_event ON events (project_id, client_event_id, timestamp) WHERE client_event_id IS NOT NULL`,
+}
+
+// migrateEventsTable creates the partitioned events table, converting an unpartitioned
+// one left by earlier versions, and its partitions for the coming months
+func migrateEventsTable(db *gorm.DB) error {
+	var kind string
+	if err := db.Raw(`SELECT COALESCE((SELECT relkind::text FROM pg_class WHERE oid = to_regclass('events')), '')`).
+		Scan(&kind).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	switch kind {
+	case "p":
+		// Already partitioned
+	case "":
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(fmt.Sprintf(createEventsTableSQL, "events")).Error; err != nil {
+				return err
+			}
+			return createEventDefaultPartition(tx)
+		})
+		if err != nil {
+			return err
+		}
+	case "r":
+		// Bring the table up to date first, the copy expects every current column
+		if err := db.AutoMigrate(&Event{}); err != nil {
+			return err
+		}
+		if err := partitionEventsTable(db, now); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("events is not a table (relkind %q)", kind)
+	}
+
+	for _, statement := range eventIndexesSQL {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return EnsureEventPartitions(db, now)
+}
+
+// partitionEventsTable copies an unpartitioned events table into a partitioned one and
+// replaces it. It runs once, in a single transaction, and blocks ingestion meanwhile.
+func partitionEventsTable(db *gorm.DB, now time.Time) error {
+	log.Println("Partitioning the events table, this may take a while...")
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`LOCK TABLE events IN ACCESS EXCLUSIVE MODE`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(fmt.Sprintf(createEventsTableSQL, "events_partitioned")).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(fmt.Sprintf(`CREATE TABLE %s PARTITION OF events_partitioned DEFAULT`, eventDefaultPartition)).Error; err != nil {
+			return err
+		}
+
+		first := eventMonth(now).AddDate(0, -eventPartitionsBehind, 0)
+		for month := first; !month.After(eventMonth(now)); month = month.AddDate(0, 1, 0) {
+			if err := tx.Exec(fmt.Sprintf(`CREATE TABLE %s PARTITION OF events_partitioned FOR VALUES FROM ('%s') TO ('%s')`,
+				EventPartitionName(month), month.Format(time.RFC3339), month.AddDate(0, 1, 0).Format(time.RFC3339))).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Exec(fmt.Sprintf(`INSERT INTO events_partitioned (%s) SELECT %s FROM events`, eventColumns, eventColumns))
+		if result.Error != nil {
+			return result.Error
+		}
+		if err := tx.Exec(`DROP TABLE events`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`ALTER TABLE events_partitioned RENAME TO events`).Error; err != nil {
+			return err
+		}
+
+		log.Printf("Moved %d events to the partitioned events table", result.RowsAffected)
+		return nil
+	})
+}
+
+func createEventDefaultPartition(tx *gorm.DB) error {
+	return tx.Exec(fmt.Sprintf(`CREATE TABLE %s PARTITION OF events DEFAULT`, eventDefaultPartition)).Error
+}
+
+// EnsureEventPartitions creates the monthly partitions of the events table from the
+// current month to EventPartitionsAhead months ahead. Events of those months that were
+// written to the default partition are moved to the new partition.
+func EnsureEventPartitions(db *gorm.DB, now time.Time) error {
+	existing, err := eventPartitionSet(db)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i <= EventPartitionsAhead; i++ {
+		month := eventMonth(now).AddDate(0, i, 0)
+		name := EventPartitionName(month)
+		if existing[name] {
+			continue
+		}
+
+		from := month.Format(time.RFC3339)
+		to := month.AddDate(0, 1, 0).Format(time.RFC3339)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(fmt.Sprintf(`CREATE TABLE %s (LIKE events INCLUDING DEFAULTS INCLUDING CONSTRAINTS)`, name)).Error; err != nil {
+				return err
+			}
+			// The default partition can't hold rows of a range attached next to it
+			if err := tx.Exec(fmt.Sprintf(`WITH moved AS (DELETE FROM %s WHERE timestamp >= ? AND timestamp < ? RETURNING *) INSERT INTO %s SELECT * FROM moved`,
+				eventDefaultPartition, name), month, month.AddDate(0, 1, 0)).Error; err != nil {
+				return err
+			}
+			return tx.Exec(fmt.Sprintf(`ALTER TABLE events ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')`, name, from, to)).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create partition %s: %w", name, err)
+		}
+
+		log.Printf("Created events partition %s", name)
+	}
+
+	return nil
+}
+
+// EventPartitions returns the monthly partitions of the events table by month
+func EventPartitions(db *gorm.DB) (map[time.Time]string, error) {
+	existing, err := eventPartitionSet(db)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make(map[time.Time]string, len(existing))
+	for name := range existing {
+		month, err := time.Parse("200601", strings.TrimPrefix(name, "events_p"))
+		if err != nil {
+			// Not a monthly partition
+			continue
+		}
+		partitions[month] = name
+	}
+
+	return partitions, nil
+}
+
+// EventPartitionName returns the name of the partition holding the events of a month
+func EventPartitionName(month time.Time) string {
+	return "events_p" + month.UTC().Format("200601")
+}
+
+func eventPartitionSet(db *gorm.DB) (map[string]bool, error) {
+	var names []string
+	if err := db.Raw(`SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid WHERE i.inhparent = 'events'::regclass`).
+		Scan(&names).Error; err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(names))
+	for _, name := range names {
+		existing[name] = true
+	}
+	return existing, nil
+}
+
+// eventMonth returns the start of the UTC month of t, partitions are aligned on UTC months
+func eventMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
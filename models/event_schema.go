@@ -0,0 +1,217 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SchemaMode controls what happens to events that don't match the project's schema registry
+type SchemaMode string
+
+const (
+	SchemaModeOff    SchemaMode = "off"    // Events are not validated
+	SchemaModeWarn   SchemaMode = "warn"   // Invalid events are recorded and counted
+	SchemaModeReject SchemaMode = "reject" // Invalid events are dropped and counted
+)
+
+// IsValid reports whether the mode is one of the known schema modes
+func (m SchemaMode) IsValid() bool {
+	return m == SchemaModeOff || m == SchemaModeWarn || m == SchemaModeReject
+}
+
+// Property types understood by the payload validator, a subset of JSON Schema
+var payloadPropertyTypes = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"object":  true,
+	"array":   true,
+	"null":    true,
+}
+
+// PropertySchema describes a single payload property using JSON Schema keywords
+type PropertySchema struct {
+	Type      string        `json:"type"`
+	Enum      []interface{} `json:"enum,omitempty"`
+	Minimum   *float64      `json:"minimum,omitempty"`
+	Maximum   *float64      `json:"maximum,omitempty"`
+	MinLength *int          `json:"minLength,omitempty"`
+	MaxLength *int          `json:"maxLength,omitempty"`
+}
+
+// PayloadSchema describes the payload of an event using a subset of JSON Schema
+type PayloadSchema struct {
+	Properties           map[string]PropertySchema `json:"properties"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties *bool                     `json:"additionalProperties,omitempty"` // Undeclared properties are allowed unless false
+}
+
+func (s PayloadSchema) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *PayloadSchema) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, &s)
+}
+
+// Check reports the first problem with the schema definition itself
+func (s PayloadSchema) Check() error {
+	for name, property := range s.Properties {
+		if !payloadPropertyTypes[property.Type] {
+			return fmt.Errorf("property %q has unsupported type %q", name, property.Type)
+		}
+		if property.Minimum != nil && property.Maximum != nil && *property.Minimum > *property.Maximum {
+			return fmt.Errorf("property %q has minimum greater than maximum", name)
+		}
+		if property.MinLength != nil && property.MaxLength != nil && *property.MinLength > *property.MaxLength {
+			return fmt.Errorf("property %q has minLength greater than maxLength", name)
+		}
+	}
+
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok {
+			return fmt.Errorf("required property %q is not declared", name)
+		}
+	}
+
+	return nil
+}
+
+// Validate returns every way the payloads differ from the schema, or nothing when they match
+func (s PayloadSchema) Validate(payloads map[string]interface{}) []string {
+	var violations []string
+
+	for _, name := range s.Required {
+		if _, ok := payloads[name]; !ok {
+			violations = append(violations, fmt.Sprintf("payload property %q is required", name))
+		}
+	}
+
+	names := make([]string, 0, len(payloads))
+	for name := range payloads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, declared := s.Properties[name]
+		if !declared {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				violations = append(violations, fmt.Sprintf("payload property %q is not declared", name))
+			}
+			continue
+		}
+
+		if violation := property.validate(payloads[name]); violation != "" {
+			violations = append(violations, fmt.Sprintf("payload property %q %s", name, violation))
+		}
+	}
+
+	return violations
+}
+
+func (p PropertySchema) validate(value interface{}) string {
+	if !matchesType(p.Type, value) {
+		return "must be of type " + p.Type
+	}
+
+	if len(p.Enum) > 0 {
+		allowed := false
+		for _, candidate := range p.Enum {
+			if reflect.DeepEqual(candidate, value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "is not one of the allowed values"
+		}
+	}
+
+	switch value := value.(type) {
+	case float64:
+		if p.Minimum != nil && value < *p.Minimum {
+			return fmt.Sprintf("must be at least %v", *p.Minimum)
+		}
+		if p.Maximum != nil && value > *p.Maximum {
+			return fmt.Sprintf("must be at most %v", *p.Maximum)
+		}
+	case string:
+		length := utf8.RuneCountInString(value)
+		if p.MinLength != nil && length < *p.MinLength {
+			return fmt.Sprintf("must be at least %d characters long", *p.MinLength)
+		}
+		if p.MaxLength != nil && length > *p.MaxLength {
+			return fmt.Sprintf("must be at most %d characters long", *p.MaxLength)
+		}
+	}
+
+	return ""
+}
+
+// matchesType checks a value decoded from JSON against a JSON Schema type name
+func matchesType(typeName string, value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return typeName == "null"
+	case string:
+		return typeName == "string"
+	case bool:
+		return typeName == "boolean"
+	case float64:
+		return typeName == "number" || (typeName == "integer" && value == math.Trunc(value))
+	case map[string]interface{}:
+		return typeName == "object"
+	case []interface{}:
+		return typeName == "array"
+	}
+	return false
+}
+
+// EventSchema declares an event of a project and the payload it is expected to carry
+type EventSchema struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	ProjectID   uuid.UUID      `json:"project_id" gorm:"type:uuid;not null;uniqueIndex:idx_event_schemas_project_name,where:deleted_at IS NULL"`
+	EventName   string         `json:"event_name" gorm:"not null;uniqueIndex:idx_event_schemas_project_name,where:deleted_at IS NULL"`
+	EventType   string         `json:"event_type" gorm:"type:varchar(50)"` // Expected event type, any type is allowed when empty
+	Description string         `json:"description"`
+	Schema      PayloadSchema  `json:"schema" gorm:"type:jsonb;not null"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	Project     Project        `json:"-" gorm:"foreignKey:ProjectID;references:ID"`
+}
+
+func (schema *EventSchema) BeforeCreate(_ *gorm.DB) error {
+	if schema.ID == uuid.Nil {
+		schema.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// Validate returns every way the event differs from the schema, or nothing when it matches
+func (schema EventSchema) Validate(eventType string, payloads map[string]interface{}) []string {
+	var violations []string
+	if schema.EventType != "" && schema.EventType != eventType {
+		violations = append(violations, fmt.Sprintf("event type must be %q", schema.EventType))
+	}
+
+	return append(violations, schema.Schema.Validate(payloads)...)
+}
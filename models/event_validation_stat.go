@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EventValidationStat counts the events of a project that failed schema validation on a given day
+type EventValidationStat struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	ProjectID uuid.UUID `json:"project_id" gorm:"type:uuid;not null;uniqueIndex:idx_event_validation_stats_project_day_name"`
+	Day       time.Time `json:"day" gorm:"type:date;not null;uniqueIndex:idx_event_validation_stats_project_day_name"`
+	EventName string    `json:"event_name" gorm:"not null;uniqueIndex:idx_event_validation_stats_project_day_name"`
+	Warned    int64     `json:"warned" gorm:"not null;default:0"`   // Invalid events recorded in warn mode
+	Rejected  int64     `json:"rejected" gorm:"not null;default:0"` // Invalid events dropped in reject mode
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Project   Project   `json:"-" gorm:"foreignKey:ProjectID;references:ID"`
+}
+
+func (stat *EventValidationStat) BeforeCreate(_ *gorm.DB) error {
+	if stat.ID == uuid.Nil {
+		stat.ID = uuid.New()
+	}
+
+	return nil
+}
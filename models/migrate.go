@@ -1,16 +1,28 @@
 package models
 
 import (
+	"fmt"
 	"log"
-	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-func MigrateDB(db *gorm.DB) error {
-	log.Println("Running database migrations...")
+// UpgradeLegacySchema brings a database created before versioned migrations, when every
+// start ran AutoMigrate, to the schema of the baseline migration. It also runs the data
+// backfills of those earlier versions. AutoMigrate creates what the current models
+// describe, so later migrations must tolerate objects that already exist.
+func UpgradeLegacySchema(db *gorm.DB) error {
+	log.Println("Upgrading legacy database schema...")
+
+	// Replace plaintext credentials with digests before the new columns become mandatory
+	if err := hashPlaintextSecrets(db); err != nil {
+		log.Printf("Failed to hash stored secrets: %v", err)
+		return err
+	}
+
+	// Devices of projects created before IP privacy modes hold the addresses as received
+	hadIPPrivacy := db.Migrator().HasColumn(&Project{}, "IPPrivacy")
 
 	err := db.AutoMigrate(&Project{})
 	if err != nil {
@@ -18,12 +30,34 @@ func MigrateDB(db *gorm.DB) error {
 		return err
 	}
 
+	// The column default only suits new projects, report the mode existing devices are in
+	if !hadIPPrivacy {
+		if err := db.Unscoped().Model(&Project{}).
+			Where("1 = 1").
+			Update("ip_privacy", IPPrivacyFull).Error; err != nil {
+			log.Printf("Failed to backfill IP privacy modes: %v", err)
+			return err
+		}
+	}
+
 	err = db.AutoMigrate(&User{})
 	if err != nil {
 		log.Printf("Failed to migrate user tables: %v", err)
 		return err
 	}
 
+	err = db.AutoMigrate(&ProjectMember{})
+	if err != nil {
+		log.Printf("Failed to migrate ProjectMember table: %v", err)
+		return err
+	}
+
+	err = db.AutoMigrate(&ApiKey{})
+	if err != nil {
+		log.Printf("Failed to migrate ApiKey table: %v", err)
+		return err
+	}
+
 	err = db.AutoMigrate(&AuthToken{})
 	if err != nil {
 		log.Printf("Failed to migrate AuthToken table: %v", err)
@@ -42,73 +76,209 @@ func MigrateDB(db *gorm.DB) error {
 		return err
 	}
 
-	err = db.AutoMigrate(&Event{})
+	// Events are partitioned by month, which AutoMigrate can't express
+	err = migrateEventsTable(db)
 	if err != nil {
 		log.Printf("Failed to migrate Event table: %v", err)
 		return err
 	}
 
-	// Create default admin user if it doesn't exist
-	if err := createDefaultAdminUser(db); err != nil {
-		log.Printf("Warning: Failed to create default admin user: %v", err)
-		// Continue anyway, not a critical error
+	err = db.AutoMigrate(&EventSchema{}, &EventValidationStat{})
+	if err != nil {
+		log.Printf("Failed to migrate event schema tables: %v", err)
+		return err
+	}
+
+	err = db.AutoMigrate(&ErasureLog{})
+	if err != nil {
+		log.Printf("Failed to migrate ErasureLog table: %v", err)
+		return err
+	}
+
+	err = db.AutoMigrate(&PurgeRun{})
+	if err != nil {
+		log.Printf("Failed to migrate PurgeRun table: %v", err)
+		return err
+	}
+
+	err = db.AutoMigrate(&DailyActivityRollup{}, &DailyEventRollup{}, &RollupWatermark{})
+	if err != nil {
+		log.Printf("Failed to migrate rollup tables: %v", err)
+		return err
+	}
+
+	// Sessions started before heartbeats existed were last active when they began
+	if err := db.Model(&Session{}).
+		Where("last_activity_at IS NULL").
+		Update("last_activity_at", gorm.Expr("begin_at")).Error; err != nil {
+		log.Printf("Failed to backfill session activity: %v", err)
+		return err
+	}
+
+	// Projects created before IP hashing existed need their own salt
+	if err := backfillIPHashSalts(db); err != nil {
+		log.Printf("Failed to backfill IP hash salts: %v", err)
+		return err
+	}
+
+	// Give every project owner an explicit owner membership
+	if err := backfillProjectOwners(db); err != nil {
+		log.Printf("Failed to backfill project owners: %v", err)
+		return err
+	}
+
+	// Tokens issued before refresh tokens existed each form their own family
+	if err := db.Model(&AuthToken{}).
+		Where("family_id IS NULL").
+		Update("family_id", gorm.Expr("id")).Error; err != nil {
+		log.Printf("Failed to backfill auth token families: %v", err)
+		return err
+	}
+
+	// Move the single per-project API key into the api_keys table
+	if err := migrateLegacyProjectApiKeys(db); err != nil {
+		log.Printf("Failed to migrate legacy project API keys: %v", err)
+		return err
 	}
 
-	log.Println("Database migration completed successfully")
+	log.Println("Legacy database schema upgraded successfully")
 	return nil
 }
 
-func createDefaultAdminUser(db *gorm.DB) error {
-	// Check if any users exist
-	var count int64
-	if err := db.Model(&User{}).Count(&count).Error; err != nil {
+func backfillProjectOwners(db *gorm.DB) error {
+	var projects []Project
+	if err := db.Model(&Project{}).
+		Where("id NOT IN (?)", db.Model(&ProjectMember{}).Select("project_id").Where("role = ?", ProjectRoleOwner)).
+		Find(&projects).Error; err != nil {
 		return err
 	}
 
-	// Only create default admin if no users exist
-	if count == 0 {
-		// Default admin credentials - these should be changed after first login
-		defaultEmail := "admin@kogase.io"
-		defaultPassword := "Admin@123" // This should be a secure password
+	for _, project := range projects {
+		member := ProjectMember{
+			ProjectID: project.ID,
+			UserID:    project.OwnerID,
+			Role:      ProjectRoleOwner,
+		}
+		if err := db.Create(&member).Error; err != nil {
+			return err
+		}
+	}
 
-		// Hash the password
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(defaultPassword), bcrypt.DefaultCost)
+	if len(projects) > 0 {
+		log.Printf("Created owner memberships for %d projects", len(projects))
+	}
+
+	return nil
+}
+
+func backfillIPHashSalts(db *gorm.DB) error {
+	var projects []Project
+	if err := db.Unscoped().Model(&Project{}).
+		Select("id").
+		Where("ip_hash_salt = ''").
+		Find(&projects).Error; err != nil {
+		return err
+	}
+
+	for _, project := range projects {
+		salt, err := GenerateSecret("", 32)
 		if err != nil {
 			return err
 		}
+		if err := db.Unscoped().Model(&Project{}).
+			Where("id = ?", project.ID).
+			Update("ip_hash_salt", salt).Error; err != nil {
+			return err
+		}
+	}
 
-		defaultAdmin := User{
-			ID:        uuid.New(),
-			Email:     defaultEmail,
-			Password:  string(hashedPassword),
-			Name:      "Admin",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+	return nil
+}
+
+func hashPlaintextSecrets(db *gorm.DB) error {
+	legacyColumns := []struct {
+		table      string
+		column     string
+		hashColumn string
+		prefix     bool
+	}{
+		{table: "api_keys", column: "key", hashColumn: "key_hash", prefix: true},
+		{table: "auth_tokens", column: "token", hashColumn: "token_hash"},
+	}
+
+	for _, legacy := range legacyColumns {
+		if !db.Migrator().HasTable(legacy.table) || !db.Migrator().HasColumn(legacy.table, legacy.column) {
+			continue
 		}
 
-		// Create the admin user
-		if err := db.Create(&defaultAdmin).Error; err != nil {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s text`, legacy.table, legacy.hashColumn)).Error; err != nil {
+				return err
+			}
+
+			// Must match HashSecret: hex-encoded SHA-256 of the UTF-8 secret
+			assignments := fmt.Sprintf(`%s = encode(sha256(convert_to(%s, 'UTF8')), 'hex')`, legacy.hashColumn, legacy.column)
+			if legacy.prefix {
+				if err := tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS prefix text`, legacy.table)).Error; err != nil {
+					return err
+				}
+				assignments += fmt.Sprintf(`, prefix = left(%s, %d)`, legacy.column, apiKeyDisplayLength)
+			}
+
+			if err := tx.Exec(fmt.Sprintf(`UPDATE %s SET %s WHERE %s IS NULL`, legacy.table, assignments, legacy.hashColumn)).Error; err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropColumn(legacy.table, legacy.column)
+		})
+		if err != nil {
 			return err
 		}
 
-		log.Printf("Created default admin user: %s", defaultEmail)
+		log.Printf("Hashed plaintext %s.%s values", legacy.table, legacy.column)
+	}
 
-		// // Optionally, create a default project for the admin
-		// defaultProject := Project{
-		// 	ID:        uuid.New(),
-		// 	Name:      "Default Project",
-		// 	ApiKey:    uuid.New().String(),
-		// 	OwnerID:   defaultAdmin.ID,
-		// 	CreatedAt: time.Now(),
-		// 	UpdatedAt: time.Now(),
-		// }
+	return nil
+}
 
-		// if err := db.Create(&defaultProject).Error; err != nil {
-		// 	return err
-		// }
+func migrateLegacyProjectApiKeys(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&Project{}, "api_key") {
+		return nil
+	}
 
-		// log.Printf("Created default project for admin user")
+	type legacyProject struct {
+		ID      uuid.UUID
+		ApiKey  string
+		OwnerID uuid.UUID
 	}
 
-	return nil
+	return db.Transaction(func(tx *gorm.DB) error {
+		var projects []legacyProject
+		if err := tx.Table("projects").
+			Select("id, api_key, owner_id").
+			Where("api_key IS NOT NULL AND api_key <> ''").
+			Scan(&projects).Error; err != nil {
+			return err
+		}
+
+		for _, project := range projects {
+			ownerID := project.OwnerID
+			apiKey := ApiKey{
+				ProjectID:   project.ID,
+				Name:        "Default",
+				CreatedByID: &ownerID,
+			}
+			apiKey.SetSecret(project.ApiKey)
+			if err := tx.Create(&apiKey).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Migrator().DropColumn(&Project{}, "api_key"); err != nil {
+			return err
+		}
+
+		log.Printf("Migrated %d legacy project API keys", len(projects))
+		return nil
+	})
 }
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrOrganizationRequired is returned when a user belonging to several organizations
+// creates a project without choosing one of them
+var ErrOrganizationRequired = errors.New("organization required")
+
+// Organization owns projects and the users managing them, such as a team of a studio.
+// Every project belongs to exactly one organization.
+type Organization struct {
+	ID        uuid.UUID            `json:"id" gorm:"type:uuid;primary_key"`
+	Name      string               `json:"name" gorm:"not null"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+	DeletedAt gorm.DeletedAt       `json:"-" gorm:"index"`
+	Members   []OrganizationMember `json:"members,omitempty" gorm:"foreignKey:OrganizationID;references:ID"`
+	Projects  []Project            `json:"projects,omitempty" gorm:"foreignKey:OrganizationID;references:ID"`
+}
+
+func (organization *Organization) BeforeCreate(_ *gorm.DB) error {
+	if organization.ID == uuid.Nil {
+		organization.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// CreateOrganization creates an organization with the given user as its owner
+func CreateOrganization(tx *gorm.DB, name string, ownerID uuid.UUID) (Organization, error) {
+	organization := Organization{Name: name}
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&organization).Error; err != nil {
+			return err
+		}
+
+		member := OrganizationMember{
+			OrganizationID: organization.ID,
+			UserID:         ownerID,
+			Role:           OrganizationRoleOwner,
+		}
+		return tx.Create(&member).Error
+	})
+
+	return organization, err
+}
+
+// DefaultOrganization returns the organization a user's new project goes to when none is
+// chosen: their only organization, or a new one they own when they have none. It returns
+// ErrOrganizationRequired when they belong to several organizations.
+func DefaultOrganization(tx *gorm.DB, user User) (Organization, error) {
+	var organizations []Organization
+	if err := tx.Model(&Organization{}).
+		Where("id IN (?)", MemberOrganizationIDs(tx, user.ID, OrganizationRoleMember)).
+		Limit(2).
+		Find(&organizations).Error; err != nil {
+		return Organization{}, err
+	}
+
+	switch len(organizations) {
+	case 0:
+		return CreateOrganization(tx, user.Name+"'s organization", user.ID)
+	case 1:
+		return organizations[0], nil
+	default:
+		return Organization{}, ErrOrganizationRequired
+	}
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrganizationRole is the role a user holds within an organization
+type OrganizationRole string
+
+const (
+	OrganizationRoleOwner  OrganizationRole = "owner"  // Full control, including deleting the organization and managing owners. Owner of every project.
+	OrganizationRoleAdmin  OrganizationRole = "admin"  // Manage the organization, its members and usage, and receive projects. Admin of every project.
+	OrganizationRoleMember OrganizationRole = "member" // Create projects in the organization, other projects need a project membership
+)
+
+var organizationRoleRanks = map[OrganizationRole]int{
+	OrganizationRoleMember: 1,
+	OrganizationRoleAdmin:  2,
+	OrganizationRoleOwner:  3,
+}
+
+// organizationProjectRoles is the role each organization role grants on every project of
+// the organization
+var organizationProjectRoles = map[OrganizationRole]ProjectRole{
+	OrganizationRoleOwner: ProjectRoleOwner,
+	OrganizationRoleAdmin: ProjectRoleAdmin,
+}
+
+// IsValid reports whether the role is one of the known organization roles
+func (role OrganizationRole) IsValid() bool {
+	_, ok := organizationRoleRanks[role]
+	return ok
+}
+
+// AtLeast reports whether the role grants at least the permissions of minRole
+func (role OrganizationRole) AtLeast(minRole OrganizationRole) bool {
+	return organizationRoleRanks[role] >= organizationRoleRanks[minRole]
+}
+
+// AndAbove returns the role itself and every role ranked above it
+func (role OrganizationRole) AndAbove() []string {
+	roles := make([]string, 0, len(organizationRoleRanks))
+	for r, rank := range organizationRoleRanks {
+		if rank >= organizationRoleRanks[role] {
+			roles = append(roles, string(r))
+		}
+	}
+	return roles
+}
+
+// ProjectRole returns the role granted on every project of the organization, or "" when
+// the role grants none
+func (role OrganizationRole) ProjectRole() ProjectRole {
+	return organizationProjectRoles[role]
+}
+
+// OrganizationRolesGranting returns the organization roles granting at least minRole on
+// every project of the organization
+func OrganizationRolesGranting(minRole ProjectRole) []string {
+	roles := make([]string, 0, len(organizationProjectRoles))
+	for role, projectRole := range organizationProjectRoles {
+		if projectRole.AtLeast(minRole) {
+			roles = append(roles, string(role))
+		}
+	}
+	return roles
+}
+
+type OrganizationMember struct {
+	ID             uuid.UUID        `json:"id" gorm:"type:uuid;primary_key"`
+	OrganizationID uuid.UUID        `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex:idx_organization_members_organization_user,where:deleted_at IS NULL"`
+	UserID         uuid.UUID        `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_organization_members_organization_user,where:deleted_at IS NULL;index"`
+	Role           OrganizationRole `json:"role" gorm:"type:varchar(20);not null"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt   `json:"-" gorm:"index"`
+	Organization   Organization     `json:"-" gorm:"foreignKey:OrganizationID;references:ID"`
+	User           User             `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
+}
+
+func (member *OrganizationMember) BeforeCreate(_ *gorm.DB) error {
+	if member.ID == uuid.Nil {
+		member.ID = uuid.New()
+	}
+
+	if member.Role == "" {
+		member.Role = OrganizationRoleMember
+	}
+
+	return nil
+}
+
+// MemberOrganizationIDs returns a subquery selecting the IDs of the organizations in
+// which the user holds at least minRole
+func MemberOrganizationIDs(db *gorm.DB, userID uuid.UUID, minRole OrganizationRole) *gorm.DB {
+	return db.Model(&OrganizationMember{}).
+		Select("organization_id").
+		Where("user_id = ? AND role IN ?", userID, minRole.AndAbove())
+}
@@ -1,33 +1,129 @@
 package models
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// DefaultSessionTimeout is the idle time after which open sessions of a new project are ended
+const DefaultSessionTimeout = 30 * time.Minute
+
+// Bounds of a project's session timeout
+const (
+	MinSessionTimeout = time.Minute
+	MaxSessionTimeout = 24 * time.Hour
+)
+
+// MaxRetentionDays bounds a project's data retention settings
+const MaxRetentionDays = 3650
+
+// IPPrivacyMode decides how the IP addresses of a project's devices are stored
+type IPPrivacyMode string
+
+const (
+	IPPrivacyFull     IPPrivacyMode = "full"     // The address is stored as received
+	IPPrivacyTruncate IPPrivacyMode = "truncate" // IPv4 addresses are truncated to /24 and IPv6 addresses to /48
+	IPPrivacyHash     IPPrivacyMode = "hash"     // A salted hash of the address is stored
+	IPPrivacyNone     IPPrivacyMode = "none"     // No address is stored
+)
+
+// IsValid reports whether the mode is one of the supported IP privacy modes
+func (mode IPPrivacyMode) IsValid() bool {
+	switch mode {
+	case IPPrivacyFull, IPPrivacyTruncate, IPPrivacyHash, IPPrivacyNone:
+		return true
+	}
+	return false
+}
+
 type Project struct {
-	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
-	Name      string         `json:"name" gorm:"not null"`
-	ApiKey    string         `json:"api_key,omitempty" gorm:"unique;not null"`
-	OwnerID   uuid.UUID      `json:"owner_id" gorm:"type:uuid;not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
-	Owner     User           `json:"owner,omitempty" gorm:"foreignKey:OwnerID;references:ID"`
-	Devices   []Device       `json:"devices,omitempty" gorm:"foreignKey:ProjectID;references:ID"`
-	Events    []Event        `json:"events,omitempty" gorm:"foreignKey:ProjectID;references:ID"`
+	ID                   uuid.UUID       `json:"id" gorm:"type:uuid;primary_key"`
+	Name                 string          `json:"name" gorm:"not null"`
+	OwnerID              uuid.UUID       `json:"owner_id" gorm:"type:uuid;not null"`
+	OrganizationID       uuid.UUID       `json:"organization_id" gorm:"type:uuid;index"`                                      // NOT NULL is set by the migration assigning existing projects an organization
+	SchemaMode           SchemaMode      `json:"schema_mode" gorm:"type:varchar(10);not null;default:'off'"`                  // How events are checked against the schema registry
+	SessionTimeout       time.Duration   `json:"session_timeout" gorm:"not null;default:1800000000000" swaggertype:"integer"` // Idle time after which an open session is ended, 30 minutes by default
+	IPPrivacy            IPPrivacyMode   `json:"ip_privacy" gorm:"type:varchar(10);not null;default:'truncate'"`              // How device IP addresses are stored
+	IPHashSalt           string          `json:"-" gorm:"not null;default:''"`                                                // Salt of hashed device IP addresses, unique per project
+	IdentifierHashSalt   string          `json:"-" gorm:"not null;default:''"`                                                // Key of the digests of erased device identifiers, unique per project
+	EventRetentionDays   int             `json:"event_retention_days" gorm:"not null;default:0"`                              // Events older than this are purged, 0 keeps them forever
+	SessionRetentionDays int             `json:"session_retention_days" gorm:"not null;default:0"`                            // Sessions that began before this are purged, 0 keeps them forever
+	CreatedAt            time.Time       `json:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt  `json:"-" gorm:"index"`
+	Owner                User            `json:"owner,omitempty" gorm:"foreignKey:OwnerID;references:ID"`
+	Organization         Organization    `json:"-" gorm:"foreignKey:OrganizationID;references:ID"`
+	Devices              []Device        `json:"devices,omitempty" gorm:"foreignKey:ProjectID;references:ID"`
+	Events               []Event         `json:"events,omitempty" gorm:"foreignKey:ProjectID;references:ID"`
+	Members              []ProjectMember `json:"members,omitempty" gorm:"foreignKey:ProjectID;references:ID"`
+	ApiKeys              []ApiKey        `json:"api_keys,omitempty" gorm:"foreignKey:ProjectID;references:ID"`
+	EventSchemas         []EventSchema   `json:"event_schemas,omitempty" gorm:"foreignKey:ProjectID;references:ID"`
 }
 
 func (project *Project) BeforeCreate(tx *gorm.DB) error {
 	if project.ID == uuid.Nil {
 		project.ID = uuid.New()
 	}
-
-	if project.ApiKey == "" {
-		project.ApiKey = uuid.New().String()
+	if project.SchemaMode == "" {
+		project.SchemaMode = SchemaModeOff
+	}
+	if project.SessionTimeout == 0 {
+		project.SessionTimeout = DefaultSessionTimeout
+	}
+	if project.IPPrivacy == "" {
+		project.IPPrivacy = IPPrivacyTruncate
+	}
+	if project.IPHashSalt == "" {
+		salt, err := GenerateSecret("", 32)
+		if err != nil {
+			return err
+		}
+		project.IPHashSalt = salt
+	}
+	if project.IdentifierHashSalt == "" {
+		salt, err := GenerateSecret("", 32)
+		if err != nil {
+			return err
+		}
+		project.IdentifierHashSalt = salt
 	}
 
 	return nil
 }
+
+// AnonymizeIP returns the value stored for a device IP address under the project's
+// privacy mode. Values that were already anonymized are converted where possible, so
+// it can also be applied to stored addresses when the mode changes.
+func (project Project) AnonymizeIP(ipAddress string) string {
+	ip := net.ParseIP(ipAddress)
+
+	switch project.IPPrivacy {
+	case IPPrivacyFull:
+		return ipAddress
+	case IPPrivacyTruncate:
+		if ip == nil {
+			// Hashes can't be truncated
+			return ""
+		}
+		if ipv4 := ip.To4(); ipv4 != nil {
+			return ipv4.Mask(net.CIDRMask(24, 32)).String()
+		}
+		return ip.Mask(net.CIDRMask(48, 128)).String()
+	case IPPrivacyHash:
+		if ip == nil {
+			// Already hashed, or nothing to hash
+			return ipAddress
+		}
+		mac := hmac.New(sha256.New, []byte(project.IPHashSalt))
+		mac.Write([]byte(ip.String()))
+		return hex.EncodeToString(mac.Sum(nil))
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProjectRole is the role a user holds within a project
+type ProjectRole string
+
+const (
+	ProjectRoleOwner   ProjectRole = "owner"   // Full control, including deleting the project and managing owners
+	ProjectRoleAdmin   ProjectRole = "admin"   // Manage project settings, API keys, devices and members
+	ProjectRoleAnalyst ProjectRole = "analyst" // Read raw telemetry (events, sessions, devices) and analytics
+	ProjectRoleViewer  ProjectRole = "viewer"  // Read project details and aggregated analytics
+)
+
+var projectRoleRanks = map[ProjectRole]int{
+	ProjectRoleViewer:  1,
+	ProjectRoleAnalyst: 2,
+	ProjectRoleAdmin:   3,
+	ProjectRoleOwner:   4,
+}
+
+// IsValid reports whether the role is one of the known project roles
+func (role ProjectRole) IsValid() bool {
+	_, ok := projectRoleRanks[role]
+	return ok
+}
+
+// AtLeast reports whether the role grants at least the permissions of minRole
+func (role ProjectRole) AtLeast(minRole ProjectRole) bool {
+	return projectRoleRanks[role] >= projectRoleRanks[minRole]
+}
+
+// AndAbove returns the role itself and every role ranked above it
+func (role ProjectRole) AndAbove() []string {
+	roles := make([]string, 0, len(projectRoleRanks))
+	for r, rank := range projectRoleRanks {
+		if rank >= projectRoleRanks[role] {
+			roles = append(roles, string(r))
+		}
+	}
+	return roles
+}
+
+type ProjectMember struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	ProjectID uuid.UUID      `json:"project_id" gorm:"type:uuid;not null;uniqueIndex:idx_project_members_project_user,where:deleted_at IS NULL"`
+	UserID    uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_project_members_project_user,where:deleted_at IS NULL;index"`
+	Role      ProjectRole    `json:"role" gorm:"type:varchar(20);not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	Project   Project        `json:"-" gorm:"foreignKey:ProjectID;references:ID"`
+	User      User           `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
+}
+
+func (member *ProjectMember) BeforeCreate(_ *gorm.DB) error {
+	if member.ID == uuid.Nil {
+		member.ID = uuid.New()
+	}
+
+	if member.Role == "" {
+		member.Role = ProjectRoleViewer
+	}
+
+	return nil
+}
+
+// MaxProjectRole returns the higher of two roles, "" counting as no role
+func MaxProjectRole(a, b ProjectRole) ProjectRole {
+	if projectRoleRanks[b] > projectRoleRanks[a] {
+		return b
+	}
+	return a
+}
+
+// MemberProjectIDs returns a subquery selecting the IDs of the projects in which
+// the user holds at least minRole, as a project member or through their organization role
+func MemberProjectIDs(db *gorm.DB, userID uuid.UUID, minRole ProjectRole) *gorm.DB {
+	// Grouped so that callers can add conditions to the subquery
+	return db.Model(&Project{}).
+		Select("id").
+		Where(db.Where("id IN (?)", db.Model(&ProjectMember{}).
+			Select("project_id").
+			Where("user_id = ? AND role IN ?", userID, minRole.AndAbove())).
+			Or("organization_id IN (?)", db.Model(&OrganizationMember{}).
+				Select("organization_id").
+				Where("user_id = ? AND role IN ?", userID, OrganizationRolesGranting(minRole))))
+}
+
+// ProjectRoleOf returns the role a user holds in a project: the higher of their project
+// membership and the role granted by their organization role. It returns "" when neither
+// gives them access.
+func ProjectRoleOf(db *gorm.DB, projectID uuid.UUID, userID uuid.UUID) (ProjectRole, error) {
+	var projectRoles []ProjectRole
+	if err := db.Model(&ProjectMember{}).
+		Where("project_id = ? AND user_id = ?", projectID, userID).
+		Limit(1).
+		Pluck("role", &projectRoles).Error; err != nil {
+		return "", err
+	}
+
+	var organizationRoles []OrganizationRole
+	if err := db.Model(&OrganizationMember{}).
+		Joins("JOIN projects ON projects.organization_id = organization_members.organization_id AND projects.deleted_at IS NULL").
+		Where("projects.id = ? AND organization_members.user_id = ?", projectID, userID).
+		Limit(1).
+		Pluck("organization_members.role", &organizationRoles).Error; err != nil {
+		return "", err
+	}
+
+	var role ProjectRole
+	if len(projectRoles) > 0 {
+		role = projectRoles[0]
+	}
+	if len(organizationRoles) > 0 {
+		role = MaxProjectRole(role, organizationRoles[0].ProjectRole())
+	}
+	return role, nil
+}
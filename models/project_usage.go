@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// addProjectUsageSQL adds events to a project's usage of a month, attributed to the
+// organization owning the project when they were received
+const addProjectUsageSQL = `
+INSERT INTO project_usages (id, organization_id, project_id, month, events_ingested, created_at, updated_at)
+SELECT @id, organization_id, id, @month, @events, @now, @now FROM projects WHERE id = @project
+ON CONFLICT (organization_id, project_id, month) DO UPDATE SET
+	events_ingested = project_usages.events_ingested + excluded.events_ingested,
+	updated_at = excluded.updated_at`
+
+// ProjectUsage counts the events ingested for a project in a month, for usage summaries.
+// Unlike events it is never purged.
+type ProjectUsage struct {
+	ID             uuid.UUID    `json:"id" gorm:"type:uuid;primary_key"`
+	OrganizationID uuid.UUID    `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex:idx_project_usages_organization_project_month"`
+	ProjectID      uuid.UUID    `json:"project_id" gorm:"type:uuid;not null;uniqueIndex:idx_project_usages_organization_project_month"`
+	Month          time.Time    `json:"month" gorm:"type:date;not null;uniqueIndex:idx_project_usages_organization_project_month"` // First day of the UTC month
+	EventsIngested int64        `json:"events_ingested" gorm:"not null;default:0"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	Organization   Organization `json:"-" gorm:"foreignKey:OrganizationID;references:ID"`
+	Project        Project      `json:"-" gorm:"foreignKey:ProjectID;references:ID"`
+}
+
+func (usage *ProjectUsage) BeforeCreate(_ *gorm.DB) error {
+	if usage.ID == uuid.Nil {
+		usage.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// UsageMonth returns the start of the UTC month t is counted in
+func UsageMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// AddEventUsage adds count written events to the usage of a project in the month
+// starting at month. It should run in the transaction writing the events, so that the
+// usage only counts events that were kept.
+func AddEventUsage(db *gorm.DB, projectID uuid.UUID, month time.Time, count int64) error {
+	if count == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	return db.Exec(addProjectUsageSQL, map[string]interface{}{
+		"id":      uuid.New(),
+		"project": projectID,
+		"month":   month,
+		"events":  count,
+		"now":     now,
+	}).Error
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PurgeRun records what the data retention job deleted for a project in one run
+type PurgeRun struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	ProjectID      uuid.UUID `json:"project_id" gorm:"type:uuid;not null;index:idx_purge_runs_project_started,priority:1"`
+	StartedAt      time.Time `json:"started_at" gorm:"not null;index:idx_purge_runs_project_started,priority:2"`
+	FinishedAt     time.Time `json:"finished_at" gorm:"not null"`
+	EventsPurged   int64     `json:"events_purged" gorm:"not null"`
+	SessionsPurged int64     `json:"sessions_purged" gorm:"not null"`
+	DevicesPurged  int64     `json:"devices_purged" gorm:"not null"`
+	Error          string    `json:"error,omitempty"` // Why the run stopped early, if it did
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (run *PurgeRun) BeforeCreate(_ *gorm.DB) error {
+	if run.ID == uuid.Nil {
+		run.ID = uuid.New()
+	}
+
+	return nil
+}
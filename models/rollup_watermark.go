@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RollupWatermark tracks the days of a project covered by the daily rollups: every UTC day
+// from FromDay up to, but excluding, Watermark is aggregated
+type RollupWatermark struct {
+	ProjectID    uuid.UUID  `json:"project_id" gorm:"type:uuid;primary_key"`
+	FromDay      time.Time  `json:"from_day" gorm:"type:date;not null"`  // First day of the project's data
+	Watermark    time.Time  `json:"watermark" gorm:"type:date;not null"` // First day not aggregated yet
+	AggregatedAt *time.Time `json:"aggregated_at"`                       // Start of the last run, days with rows written since are aggregated again
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Project      Project    `json:"-" gorm:"foreignKey:ProjectID;references:ID"`
+}
+
+// RollupCoverage returns the days covered by the rollups of every given project, as a
+// half-open range of UTC days. ok is false when a project has no rollups yet.
+func RollupCoverage(db *gorm.DB, projectIDs interface{}) (from time.Time, to time.Time, ok bool, err error) {
+	var coverage struct {
+		Projects   int64
+		Watermarks int64
+		FromDay    *time.Time
+		Watermark  *time.Time
+	}
+	if err := db.Model(&Project{}).
+		Select("COUNT(projects.id) AS projects, COUNT(rollup_watermarks.project_id) AS watermarks, MAX(rollup_watermarks.from_day) AS from_day, MIN(rollup_watermarks.watermark) AS watermark").
+		Joins("LEFT JOIN rollup_watermarks ON rollup_watermarks.project_id = projects.id").
+		Where("projects.id IN (?)", projectIDs).
+		Scan(&coverage).Error; err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+
+	if coverage.Projects == 0 || coverage.Watermarks < coverage.Projects || coverage.FromDay == nil || coverage.Watermark == nil {
+		return time.Time{}, time.Time{}, false, nil
+	}
+
+	from = rollupDay(*coverage.FromDay)
+	to = rollupDay(*coverage.Watermark)
+	return from, to, from.Before(to), nil
+}
+
+// rollupDay returns the start of the UTC day of a date column
+func rollupDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// HashSecret returns the hex-encoded SHA-256 digest under which API keys and auth
+// tokens are stored. Secrets are high-entropy, so an unsalted digest is sufficient
+// for lookups while keeping a database leak from exposing usable credentials.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateSecret returns a random URL-safe secret of size bytes with the given prefix
+func GenerateSecret(prefix string, size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -8,17 +8,18 @@ import (
 )
 
 type Session struct {
-	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
-	ProjectID uuid.UUID      `json:"project_id" gorm:"type:uuid;not null"`
-	DeviceID  uuid.UUID      `json:"device_id" gorm:"type:uuid;not null"`
-	BeginAt   time.Time      `json:"begin_at" gorm:"not null"`
-	EndAt     time.Time      `json:"end_at"`
-	Duration  time.Duration  `json:"duration"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
-	Project   Project        `json:"-" gorm:"foreignKey:ProjectID;references:ID"`
-	Device    Device         `json:"-" gorm:"foreignKey:DeviceID;references:ID"`
+	ID             uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	ProjectID      uuid.UUID      `json:"project_id" gorm:"type:uuid;not null"`
+	DeviceID       uuid.UUID      `json:"device_id" gorm:"type:uuid;not null"`
+	BeginAt        time.Time      `json:"begin_at" gorm:"not null"`
+	EndAt          time.Time      `json:"end_at"`
+	Duration       time.Duration  `json:"duration" swaggertype:"integer"`
+	LastActivityAt time.Time      `json:"last_activity_at"` // Last heartbeat, used to time out abandoned sessions
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	Project        Project        `json:"-" gorm:"foreignKey:ProjectID;references:ID"`
+	Device         Device         `json:"-" gorm:"foreignKey:DeviceID;references:ID"`
 }
 
 func (session *Session) BeforeCreate(_ *gorm.DB) error {
@@ -30,6 +31,19 @@ func (session *Session) BeforeCreate(_ *gorm.DB) error {
 	if session.BeginAt.IsZero() {
 		session.BeginAt = now
 	}
+	if session.LastActivityAt.IsZero() {
+		session.LastActivityAt = session.BeginAt
+	}
 
 	return nil
 }
+
+// IsOpen reports whether the session has not ended yet
+func (session Session) IsOpen() bool {
+	return session.EndAt.IsZero()
+}
+
+// OpenSessions limits a query to sessions that have not ended yet
+func OpenSessions(db *gorm.DB) *gorm.DB {
+	return db.Where("sessions.end_at IS NULL OR sessions.end_at = ?", time.Time{})
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// SetupTokenLifetime is how long a setup token printed at startup can be used
+const SetupTokenLifetime = 24 * time.Hour
+
+// setupTokenPrefix marks setup tokens so they are easy to tell apart from other secrets
+const setupTokenPrefix = "kgs_"
+
+// Credentials created by earlier versions on every fresh installation
+const (
+	legacyAdminEmail    = "admin@kogase.io"
+	legacyAdminPassword = "Admin@123"
+)
+
+var (
+	// ErrSetupCompleted is returned when the first admin is created while users already exist
+	ErrSetupCompleted = errors.New("setup already completed")
+
+	// ErrInvalidSetupToken is returned for unknown or expired setup tokens
+	ErrInvalidSetupToken = errors.New("invalid or expired setup token")
+)
+
+// SetupToken is a one-time token allowing the first admin to be created on an installation
+// without users. Only its digest is stored.
+type SetupToken struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	TokenHash string    `json:"-" gorm:"uniqueIndex;not null"` // SHA-256 digest of the token
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (token *SetupToken) BeforeCreate(_ *gorm.DB) error {
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// SetupRequired reports whether the installation has no users yet. Deleted users count,
+// so deleting every user doesn't reopen the setup.
+func SetupRequired(db *gorm.DB) (bool, error) {
+	var count int64
+	if err := db.Unscoped().Model(&User{}).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// CreateSetupToken stores a new setup token valid for SetupTokenLifetime and returns it
+func CreateSetupToken(db *gorm.DB) (string, error) {
+	secret, err := GenerateSecret(setupTokenPrefix, 32)
+	if err != nil {
+		return "", err
+	}
+
+	token := SetupToken{
+		TokenHash: HashSecret(secret),
+		ExpiresAt: time.Now().Add(SetupTokenLifetime),
+	}
+	if err := db.Create(&token).Error; err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// CompleteSetup creates the first admin with a setup token, then discards every setup token
+func CompleteSetup(db *gorm.DB, secret string, admin *User) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Serialize concurrent attempts, only one of them may create the first user
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "setup_tokens").Error; err != nil {
+			return err
+		}
+
+		required, err := SetupRequired(tx)
+		if err != nil {
+			return err
+		}
+		if !required {
+			return ErrSetupCompleted
+		}
+
+		var token SetupToken
+		if err := tx.Where("token_hash = ? AND expires_at > ?", HashSecret(secret), time.Now()).
+			First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidSetupToken
+			}
+			return err
+		}
+
+		if err := tx.Create(admin).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("1 = 1").Delete(&SetupToken{}).Error
+	})
+}
+
+// LockLegacyAdmin locks the admin that earlier versions created with well-known credentials,
+// if that password is still in use, and signs it out. Anyone could log in with them and
+// choose a new password, so only an operator can unlock it by resetting the password.
+func LockLegacyAdmin(db *gorm.DB) error {
+	var admin User
+	if err := db.Where("email = ? AND locked_at IS NULL", legacyAdminEmail).
+		First(&admin).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte(legacyAdminPassword)) != nil {
+		return nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&admin).Update("locked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&AuthToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", admin.ID).
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Warning: %s still uses the default password and was locked. Unlock it with: kogase user reset-password -email %s",
+		legacyAdminEmail, legacyAdminEmail)
+	return nil
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StreamTokenLifetime is how long a stream token can be used after it was issued
+const StreamTokenLifetime = time.Minute
+
+// streamTokenPrefix marks stream tokens so they are easy to tell apart from other secrets
+const streamTokenPrefix = "kgs_stream_"
+
+// ErrInvalidStreamToken is returned for unknown, used or expired stream tokens
+var ErrInvalidStreamToken = errors.New("invalid or expired stream token")
+
+// StreamToken lets a browser open a project's live stream once. EventSource can't send an
+// Authorization header, so the token goes in the query string instead of an auth token
+// that would end up in access logs. Only its digest is stored.
+type StreamToken struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	TokenHash string    `json:"-" gorm:"uniqueIndex;not null"` // SHA-256 digest of the token
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
+	ProjectID uuid.UUID `json:"project_id" gorm:"type:uuid;not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (token *StreamToken) BeforeCreate(_ *gorm.DB) error {
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// CreateStreamToken stores a new stream token of a user for a project and returns it
+// with its expiry. Expired tokens are discarded on the way.
+func CreateStreamToken(db *gorm.DB, userID, projectID uuid.UUID) (string, time.Time, error) {
+	secret, err := GenerateSecret(streamTokenPrefix, 32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	token := StreamToken{
+		TokenHash: HashSecret(secret),
+		UserID:    userID,
+		ProjectID: projectID,
+		ExpiresAt: now.Add(StreamTokenLifetime),
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", now).Delete(&StreamToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&token).Error
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return secret, token.ExpiresAt, nil
+}
+
+// RedeemStreamToken consumes a stream token, so it can't be used twice, and returns it
+func RedeemStreamToken(db *gorm.DB, secret string) (StreamToken, error) {
+	var tokens []StreamToken
+	if err := db.Clauses(clause.Returning{}).
+		Where("token_hash = ? AND expires_at > ?", HashSecret(secret), time.Now()).
+		Delete(&tokens).Error; err != nil {
+		return StreamToken{}, err
+	}
+
+	if len(tokens) == 0 {
+		return StreamToken{}, ErrInvalidStreamToken
+	}
+
+	return tokens[0], nil
+}
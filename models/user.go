@@ -8,14 +8,16 @@ import (
 )
 
 type User struct {
-	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
-	Email     string         `json:"email" gorm:"unique;not null"`
-	Password  string         `json:"-" gorm:"not null"`
-	Name      string         `json:"name" gorm:"not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
-	Projects  []Project      `json:"projects,omitempty" gorm:"foreignKey:OwnerID;references:ID"`
+	ID                 uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	Email              string         `json:"email" gorm:"unique;not null"`
+	Password           string         `json:"-" gorm:"not null"`
+	Name               string         `json:"name" gorm:"not null"`
+	MustChangePassword bool           `json:"must_change_password" gorm:"not null;default:false"` // Set for passwords the user didn't choose
+	LockedAt           *time.Time     `json:"locked_at,omitempty"`                                // Locked users can't log in until an operator resets their password
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"-" gorm:"index"`
+	Projects           []Project      `json:"projects,omitempty" gorm:"foreignKey:OwnerID;references:ID"`
 }
 
 func (user *User) BeforeCreate(_ *gorm.DB) error {
@@ -1,15 +1,25 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/atqamz/kogase-backend/config"
 	"github.com/atqamz/kogase-backend/controllers"
+	"github.com/atqamz/kogase-backend/ingest"
+	"github.com/atqamz/kogase-backend/jobs"
+	"github.com/atqamz/kogase-backend/live"
 	"github.com/atqamz/kogase-backend/middleware"
+	"github.com/atqamz/kogase-backend/migrations"
 	"github.com/atqamz/kogase-backend/models"
+	"github.com/atqamz/kogase-backend/utils"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -18,11 +28,18 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// shutdownTimeout bounds how long in-flight requests and queued events may take to finish on shutdown
+const shutdownTimeout = 30 * time.Second
+
 // Server represents the main server application
 type Server struct {
 	Router *gin.Engine
 	DB     *gorm.DB
 	Config *config.Config
+	Ingest *ingest.Pipeline
+	Live   *live.Hub
+	Jobs   *jobs.Scheduler
+	Geo    utils.GeoResolver
 }
 
 // New creates a new server instance
@@ -30,7 +47,79 @@ func New() (*Server, error) {
 	// Load configuration from environment
 	cfg := config.NewConfigFromEnv()
 
-	// Database connection
+	db, err := OpenDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Migrate the schema
+	if cfg.MigrateOnStart {
+		migrator, err := migrations.New(db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load migrations: %w", err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+
+		// The partitions job only runs after its first interval. Without migrations on
+		// start the schema may not be ready yet and kogase migrate up creates them.
+		if err := models.EnsureEventPartitions(db, time.Now()); err != nil {
+			return nil, fmt.Errorf("failed to create event partitions: %w", err)
+		}
+	}
+
+	if err := bootstrapAdmin(db, cfg); err != nil {
+		return nil, fmt.Errorf("failed to bootstrap the admin user: %w", err)
+	}
+
+	return NewWithConfig(db, cfg), nil
+}
+
+// bootstrapAdmin prepares the first admin of a fresh installation: from ADMIN_EMAIL and
+// ADMIN_PASSWORD when they are set, otherwise through a one-time setup token
+func bootstrapAdmin(db *gorm.DB, cfg *config.Config) error {
+	required, err := models.SetupRequired(db)
+	if err != nil {
+		return err
+	}
+	if !required {
+		return models.LockLegacyAdmin(db)
+	}
+
+	if cfg.AdminEmail != "" && cfg.AdminPassword != "" {
+		hashedPassword, err := utils.HashPassword(cfg.AdminPassword)
+		if err != nil {
+			return err
+		}
+
+		// The password sits in the environment, so the admin has to replace it
+		admin := models.User{
+			Email:              cfg.AdminEmail,
+			Password:           hashedPassword,
+			Name:               "Admin",
+			MustChangePassword: true,
+		}
+		if err := db.Create(&admin).Error; err != nil {
+			return err
+		}
+
+		log.Printf("Created admin user %s, the password must be changed on first login", admin.Email)
+		return nil
+	}
+
+	token, err := models.CreateSetupToken(db)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("No users exist yet. Create the first admin with POST /api/v1/setup and this one-time setup token, valid for %s: %s",
+		models.SetupTokenLifetime, token)
+	return nil
+}
+
+// OpenDB connects to the configured database
+func OpenDB(cfg *config.Config) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBSSLMode)
 
@@ -52,43 +141,55 @@ func New() (*Server, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Migrate the schema
-	if err := models.MigrateDB(db); err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
-	}
+	return db, nil
+}
 
+// NewWithConfig creates a new server with custom configuration (useful for testing)
+func NewWithConfig(db *gorm.DB, cfg *config.Config) *Server {
 	// Set up Gin
 	r := gin.Default()
 
+	// Start the event ingestion workers
+	pipeline := ingest.NewPipeline(db, ingest.Config{
+		Workers:       cfg.IngestWorkers,
+		QueueSize:     cfg.IngestQueueSize,
+		BatchSize:     cfg.IngestBatchSize,
+		FlushInterval: cfg.IngestFlushInterval,
+	})
+
 	// Create a new server
 	s := &Server{
 		Router: r,
 		DB:     db,
 		Config: cfg,
+		Ingest: pipeline,
+		Live:   live.NewHub(db),
+		Jobs:   jobs.NewScheduler(),
+		Geo:    newGeoResolver(cfg),
 	}
 
 	// Initialize routes
 	s.setupRoutes()
 
-	return s, nil
+	return s
 }
 
-// NewWithConfig creates a new server with custom configuration (useful for testing)
-func NewWithConfig(db *gorm.DB, cfg *config.Config) *Server {
-	// Set up Gin
-	r := gin.Default()
-
-	// Create a new server
-	s := &Server{
-		Router: r,
-		DB:     db,
-		Config: cfg,
+// newGeoResolver loads the configured geolocation database, geolocation is disabled
+// when none is configured or it can't be loaded
+func newGeoResolver(cfg *config.Config) utils.GeoResolver {
+	if cfg.GeoIPDatabasePath == "" {
+		log.Println("No geolocation database configured, device locations won't be resolved")
+		return utils.NoGeoResolver{}
 	}
 
-	// Initialize routes
-	s.setupRoutes()
+	resolver, err := utils.NewMMDBGeoResolver(cfg.GeoIPDatabasePath)
+	if err != nil {
+		log.Printf("Geolocation disabled: %v", err)
+		return utils.NoGeoResolver{}
+	}
 
-	return s
+	log.Printf("Loaded geolocation database %s (%s)", cfg.GeoIPDatabasePath, resolver.DatabaseType())
+	return utils.NewCachedGeoResolver(resolver, cfg.GeoIPCacheSize)
 }
 
 // setupRoutes sets up all the routes
@@ -101,14 +202,32 @@ func (s *Server) setupRoutes() {
 
 	// Create controllers
 	analyticsController := controllers.NewAnalyticsController(s.DB)
+	apiKeyController := controllers.NewApiKeyController(s.DB)
 	authController := controllers.NewAuthController(s.DB)
-	deviceController := controllers.NewDeviceController(s.DB)
-	eventController := controllers.NewEventController(s.DB)
+	deviceController := controllers.NewDeviceController(s.DB, s.Live, s.Geo)
+	eventController := controllers.NewEventController(s.DB, s.Ingest, s.Live)
+	eventSchemaController := controllers.NewEventSchemaController(s.DB, s.Ingest)
 	healthController := controllers.NewHealthController(s.DB)
-	projectController := controllers.NewProjectController(s.DB)
-	sessionController := controllers.NewSessionController(s.DB)
+	projectController := controllers.NewProjectController(s.DB, s.Jobs)
+	projectMemberController := controllers.NewProjectMemberController(s.DB)
+	liveController := controllers.NewLiveController(s.DB, s.Live)
+	organizationController := controllers.NewOrganizationController(s.DB)
+	organizationMemberController := controllers.NewOrganizationMemberController(s.DB)
+	privacyController := controllers.NewPrivacyController(s.DB, s.Ingest)
+	sessionController := controllers.NewSessionController(s.DB, s.Live)
+	setupController := controllers.NewSetupController(s.DB)
 	userController := controllers.NewUserController(s.DB)
 
+	// Project role checks for dashboard routes
+	projectRole := func(minRole models.ProjectRole, resolve middleware.ProjectResolver) gin.HandlerFunc {
+		return middleware.ProjectRoleMiddleware(s.DB, minRole, resolve)
+	}
+
+	// Organization role checks for the routes of the organization in the id parameter
+	organizationRole := func(minRole models.OrganizationRole) gin.HandlerFunc {
+		return middleware.OrganizationRoleMiddleware(s.DB, minRole, "id")
+	}
+
 	// API v1 routes
 	v1 := s.Router.Group("/api/v1")
 
@@ -116,22 +235,31 @@ func (s *Server) setupRoutes() {
 	analytics := v1.Group("/analytics")
 	analytics.Use(middleware.AuthMiddleware(s.DB))
 	{
-		analytics.GET("", analyticsController.GetAnalytics)
+		analytics.GET("", projectRole(models.ProjectRoleViewer, middleware.ProjectFromQuery("project_id")), analyticsController.GetAnalytics)
+		analytics.GET("/retention", projectRole(models.ProjectRoleViewer, middleware.ProjectFromQuery("project_id")), analyticsController.GetRetention)
+		analytics.GET("/timeseries", projectRole(models.ProjectRoleViewer, middleware.ProjectFromQuery("project_id")), analyticsController.GetTimeseries)
+		analytics.POST("/aggregate", projectRole(models.ProjectRoleViewer, middleware.ProjectFromQuery("project_id")), analyticsController.AggregateEvents)
+		analytics.POST("/funnel", projectRole(models.ProjectRoleViewer, middleware.ProjectFromQuery("project_id")), analyticsController.GetFunnel)
 	}
 
 	// Auth routes
 	auth := v1.Group("/auth")
 	{
 		auth.POST("/login", authController.Login)
-		auth.POST("/logout", middleware.AuthMiddleware(s.DB), authController.Logout)
-		auth.GET("/me", middleware.AuthMiddleware(s.DB), authController.Me)
+		auth.POST("/refresh", authController.Refresh)
+		auth.POST("/logout", middleware.PasswordChangeAuthMiddleware(s.DB), authController.Logout)
+		auth.GET("/me", middleware.PasswordChangeAuthMiddleware(s.DB), authController.Me)
+		auth.POST("/password", middleware.PasswordChangeAuthMiddleware(s.DB), authController.ChangePassword)
+		auth.GET("/sessions", middleware.AuthMiddleware(s.DB), authController.GetSessions)
+		auth.DELETE("/sessions", middleware.AuthMiddleware(s.DB), authController.RevokeSessions)
+		auth.DELETE("/sessions/:id", middleware.AuthMiddleware(s.DB), authController.RevokeSession)
 	}
 
 	// Device routes
 	devices := v1.Group("/devices")
 	{
 		apiKeyDevices := devices.Group("")
-		apiKeyDevices.Use(middleware.ApiKeyMiddleware(s.DB))
+		apiKeyDevices.Use(middleware.ApiKeyMiddleware(s.DB), middleware.ApiKeyScopeMiddleware(models.ApiKeyScopeIngest))
 		{
 			apiKeyDevices.POST("", deviceController.CreateOrUpdateDevice)
 		}
@@ -139,9 +267,9 @@ func (s *Server) setupRoutes() {
 		authDevices := devices.Group("")
 		authDevices.Use(middleware.AuthMiddleware(s.DB))
 		{
-			authDevices.GET("", deviceController.GetDevices)
-			authDevices.GET("/:id", deviceController.GetDevice)
-			authDevices.DELETE("/:id", deviceController.DeleteDevice)
+			authDevices.GET("", projectRole(models.ProjectRoleAnalyst, middleware.ProjectFromQuery("project_id")), deviceController.GetDevices)
+			authDevices.GET("/:id", projectRole(models.ProjectRoleAnalyst, middleware.ProjectFromRecord(&models.Device{}, "id")), deviceController.GetDevice)
+			authDevices.DELETE("/:id", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromRecord(&models.Device{}, "id")), deviceController.DeleteDevice)
 		}
 	}
 
@@ -149,7 +277,7 @@ func (s *Server) setupRoutes() {
 	events := v1.Group("/events")
 	{
 		apiKeyEvents := events.Group("")
-		apiKeyEvents.Use(middleware.ApiKeyMiddleware(s.DB))
+		apiKeyEvents.Use(middleware.ApiKeyMiddleware(s.DB), middleware.ApiKeyScopeMiddleware(models.ApiKeyScopeIngest))
 		{
 			apiKeyEvents.POST("", eventController.RecordEvent)
 			apiKeyEvents.POST("/batch", eventController.RecordEvents)
@@ -158,8 +286,8 @@ func (s *Server) setupRoutes() {
 		authEvents := events.Group("")
 		authEvents.Use(middleware.AuthMiddleware(s.DB))
 		{
-			authEvents.GET("", eventController.GetEvents)
-			authEvents.GET("/:id", eventController.GetEvent)
+			authEvents.GET("", projectRole(models.ProjectRoleAnalyst, middleware.ProjectFromQuery("project_id")), eventController.GetEvents)
+			authEvents.GET("/:id", projectRole(models.ProjectRoleAnalyst, middleware.ProjectFromRecord(&models.Event{}, "id")), eventController.GetEvent)
 		}
 	}
 
@@ -169,50 +297,117 @@ func (s *Server) setupRoutes() {
 		health.GET("/apikey", middleware.ApiKeyMiddleware(s.DB), healthController.GetHealthWithApiKey)
 	}
 
+	// Organization routes
+	organizations := v1.Group("/organizations")
+	organizations.Use(middleware.AuthMiddleware(s.DB))
+	{
+		organizations.POST("", organizationController.CreateOrganization)
+		organizations.GET("", organizationController.GetOrganizations)
+		organizations.GET("/:id", organizationRole(models.OrganizationRoleMember), organizationController.GetOrganization)
+		organizations.PATCH("/:id", organizationRole(models.OrganizationRoleAdmin), organizationController.UpdateOrganization)
+		organizations.DELETE("/:id", organizationRole(models.OrganizationRoleOwner), organizationController.DeleteOrganization)
+		organizations.GET("/:id/usage", organizationRole(models.OrganizationRoleAdmin), organizationController.GetUsage)
+
+		organizations.GET("/:id/members", organizationRole(models.OrganizationRoleMember), organizationMemberController.GetMembers)
+		organizations.POST("/:id/members", organizationRole(models.OrganizationRoleAdmin), organizationMemberController.AddMember)
+		organizations.PATCH("/:id/members/:user_id", organizationRole(models.OrganizationRoleAdmin), organizationMemberController.UpdateMember)
+		organizations.DELETE("/:id/members/:user_id", organizationRole(models.OrganizationRoleAdmin), organizationMemberController.RemoveMember)
+	}
+
 	// Project routes
 	projects := v1.Group("/projects")
 	{
-		projects.POST("", projectController.CreateProject)
-
 		authProjects := projects.Group("")
 		authProjects.Use(middleware.AuthMiddleware(s.DB))
 		{
-			authProjects.GET("", projectController.GetProjects)
-			authProjects.GET("/:id", projectController.GetProject)
-			authProjects.PATCH("/:id", projectController.UpdateProject)
-			authProjects.DELETE("/:id", projectController.DeleteProject)
-			authProjects.POST("/:id/apikey", projectController.RegenerateApiKey)
+			authProjects.POST("", projectController.CreateProject)
+			authProjects.GET("", projectRole(models.ProjectRoleViewer, middleware.AnyProject), projectController.GetProjects)
+			authProjects.GET("/:id", projectRole(models.ProjectRoleViewer, middleware.ProjectFromParam("id")), projectController.GetProject)
+			authProjects.PATCH("/:id", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), projectController.UpdateProject)
+			authProjects.DELETE("/:id", projectRole(models.ProjectRoleOwner, middleware.ProjectFromParam("id")), projectController.DeleteProject)
+			authProjects.POST("/:id/transfer", projectRole(models.ProjectRoleOwner, middleware.ProjectFromParam("id")), projectController.TransferProject)
+			authProjects.POST("/:id/apikey", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), projectController.RegenerateApiKey)
+			authProjects.GET("/:id/data-retention", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), projectController.GetDataRetention)
+			authProjects.POST("/:id/devices/anonymize", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), projectController.RewriteDeviceIPs)
+
+			authProjects.GET("/:id/apikeys", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), apiKeyController.GetApiKeys)
+			authProjects.POST("/:id/apikeys", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), apiKeyController.CreateApiKey)
+			authProjects.DELETE("/:id/apikeys/:key_id", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), apiKeyController.RevokeApiKey)
+
+			authProjects.GET("/:id/members", projectRole(models.ProjectRoleViewer, middleware.ProjectFromParam("id")), projectMemberController.GetMembers)
+			authProjects.POST("/:id/members", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), projectMemberController.AddMember)
+			authProjects.PATCH("/:id/members/:user_id", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), projectMemberController.UpdateMember)
+			authProjects.DELETE("/:id/members/:user_id", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), projectMemberController.RemoveMember)
+
+			authProjects.POST("/:id/live/token", projectRole(models.ProjectRoleViewer, middleware.ProjectFromParam("id")), liveController.CreateStreamToken)
+
+			authProjects.GET("/:id/privacy/export", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), privacyController.ExportProjectDeviceData)
+			authProjects.POST("/:id/privacy/erase", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), privacyController.EraseProjectDeviceData)
+			authProjects.GET("/:id/privacy/erasures", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), privacyController.GetErasureLogs)
+
+			authProjects.GET("/:id/schemas", projectRole(models.ProjectRoleAnalyst, middleware.ProjectFromParam("id")), eventSchemaController.GetEventSchemas)
+			authProjects.POST("/:id/schemas", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), eventSchemaController.CreateEventSchema)
+			authProjects.GET("/:id/schemas/stats", projectRole(models.ProjectRoleViewer, middleware.ProjectFromParam("id")), eventSchemaController.GetSchemaStats)
+			authProjects.PUT("/:id/schemas/mode", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), eventSchemaController.UpdateSchemaMode)
+			authProjects.PUT("/:id/schemas/:schema_id", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), eventSchemaController.UpdateEventSchema)
+			authProjects.DELETE("/:id/schemas/:schema_id", projectRole(models.ProjectRoleAdmin, middleware.ProjectFromParam("id")), eventSchemaController.DeleteEventSchema)
 		}
 
-		projects.GET("/apikey", middleware.ApiKeyMiddleware(s.DB), projectController.GetProjectWithApiKey)
+		// Browsers open the live stream with a stream token, EventSource can't send headers
+		projects.GET("/:id/live", middleware.StreamTokenMiddleware(s.DB, "id"), projectRole(models.ProjectRoleViewer, middleware.ProjectFromParam("id")), liveController.StreamLiveMetrics)
+
+		projects.GET("/apikey", middleware.ApiKeyMiddleware(s.DB), middleware.ApiKeyScopeMiddleware(models.ApiKeyScopeReadConfig), projectController.GetProjectWithApiKey)
+	}
+
+	// Data subject request routes for game backends (API key with the privacy scope)
+	privacy := v1.Group("/privacy")
+	privacy.Use(middleware.ApiKeyMiddleware(s.DB), middleware.ApiKeyScopeMiddleware(models.ApiKeyScopePrivacy))
+	{
+		privacy.GET("/export", privacyController.ExportDeviceData)
+		privacy.POST("/erase", privacyController.EraseDeviceData)
 	}
 
 	// Session routes
 	sessions := v1.Group("/sessions")
 	{
 		apiSessions := sessions.Group("")
-		apiSessions.Use(middleware.ApiKeyMiddleware(s.DB))
+		apiSessions.Use(middleware.ApiKeyMiddleware(s.DB), middleware.ApiKeyScopeMiddleware(models.ApiKeyScopeIngest))
 		{
 			apiSessions.POST("/begin", sessionController.BeginSession)
 			apiSessions.POST("/end", sessionController.EndSession)
+			apiSessions.POST("/heartbeat", sessionController.Heartbeat)
 		}
 
 		authSessions := sessions.Group("")
 		authSessions.Use(middleware.AuthMiddleware(s.DB))
 		{
-			authSessions.GET("", sessionController.GetSessions)
-			authSessions.GET("/:id", sessionController.GetSession)
+			authSessions.GET("", projectRole(models.ProjectRoleAnalyst, middleware.ProjectFromQuery("project_id")), sessionController.GetSessions)
+			authSessions.GET("/:id", projectRole(models.ProjectRoleAnalyst, middleware.ProjectFromRecord(&models.Session{}, "id")), sessionController.GetSession)
+			authSessions.GET("/:id/events", projectRole(models.ProjectRoleAnalyst, middleware.ProjectFromRecord(&models.Session{}, "id")), sessionController.GetSessionEvents)
 		}
 	}
 
+	// First-run setup routes
+	setup := v1.Group("/setup")
+	{
+		setup.GET("", setupController.GetSetup)
+		setup.POST("", setupController.CompleteSetup)
+	}
+
 	// User routes
 	users := v1.Group("/users")
 	{
-		users.POST("", userController.CreateUser)
+		// Accounts are created by logged-in users unless open registration is enabled
+		if s.Config.AllowRegistration {
+			users.POST("", userController.CreateUser)
+		}
 
 		authUsers := users.Group("")
 		authUsers.Use(middleware.AuthMiddleware(s.DB))
 		{
+			if !s.Config.AllowRegistration {
+				authUsers.POST("", userController.CreateUser)
+			}
 			authUsers.GET("", userController.GetUsers)
 			authUsers.GET("/:id", userController.GetUser)
 			authUsers.PATCH("/:id", userController.UpdateUser)
@@ -221,9 +416,56 @@ func (s *Server) setupRoutes() {
 	}
 }
 
-// Run starts the server
+// Run starts the server and blocks until it receives SIGINT or SIGTERM, then
+// shuts down gracefully and drains the event ingestion queue
 func (s *Server) Run() error {
-	return s.Router.Run(":" + s.Config.Port)
+	srv := &http.Server{
+		Addr:    ":" + s.Config.Port,
+		Handler: s.Router,
+	}
+	// Live metric streams never finish on their own, end them when shutdown begins
+	srv.RegisterOnShutdown(s.Live.Stop)
+
+	s.startJobs()
+
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return err
+		}
+	case sig := <-quit:
+		log.Printf("Received %s, shutting down...", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Failed to shut down HTTP server gracefully: %v", err)
+	}
+
+	if err := s.Jobs.Stop(ctx); err != nil {
+		log.Printf("Failed to stop background jobs: %v", err)
+	}
+
+	if err := s.Ingest.Stop(ctx); err != nil {
+		return fmt.Errorf("failed to drain event queue: %w", err)
+	}
+
+	log.Println("Shutdown complete")
+	return nil
 }
 
 // The helper functions below can stay for backwards compatibility
@@ -237,3 +479,12 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// startJobs schedules the background jobs
+func (s *Server) startJobs() {
+	s.Jobs.Every("session sweeper", s.Config.SessionSweepInterval, jobs.SweepSessions(s.DB, s.Live))
+	s.Jobs.Every("event partitions", jobs.EventPartitionInterval, jobs.CreateEventPartitions(s.DB))
+	s.Jobs.Every("data purge", s.Config.DataPurgeInterval, jobs.PurgeExpiredData(s.DB, s.Config.DeletedRowRetention))
+	s.Jobs.Every("rollups", s.Config.RollupInterval, jobs.AggregateRollups(s.DB))
+	s.Jobs.Every("dead letter replay", jobs.DeadLetterReplayInterval, jobs.ReplayDeadLetters(s.Ingest))
+}
@@ -1,73 +1,122 @@
 package utils
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"time"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
 )
 
-// GeoLocationResponse represents the response from the IP geolocation API
-type GeoLocationResponse struct {
-	Status      string  `json:"status"`
-	Country     string  `json:"country"`
-	CountryCode string  `json:"countryCode"`
-	Region      string  `json:"region"`
-	RegionName  string  `json:"regionName"`
-	City        string  `json:"city"`
-	Zip         string  `json:"zip"`
-	Lat         float64 `json:"lat"`
-	Lon         float64 `json:"lon"`
-	Timezone    string  `json:"timezone"`
-	ISP         string  `json:"isp"`
-	Org         string  `json:"org"`
-	AS          string  `json:"as"`
-	Query       string  `json:"query"`
+// GeoLocation is where an IP address is located. Fields the database doesn't know are empty.
+type GeoLocation struct {
+	Country string // ISO 3166-1 alpha-2 country code
+	Region  string // Name of the first-level subdivision (state, province...)
+	City    string
+}
+
+// GeoResolver resolves the location of IP addresses
+type GeoResolver interface {
+	Lookup(ipAddress string) (GeoLocation, error)
 }
 
-// GetCountryFromIP returns the country for a given IP address
-// Uses the free IP-API service (http://ip-api.com/json/)
-func GetCountryFromIP(ipAddress string) (string, error) {
-	// Handle localhost or empty IP
-	if ipAddress == "" || ipAddress == "127.0.0.1" || ipAddress == "::1" {
-		return "Unknown", nil
+// NoGeoResolver resolves every address to an empty location, it is used when no
+// geolocation database is configured
+type NoGeoResolver struct{}
+
+func (NoGeoResolver) Lookup(_ string) (GeoLocation, error) {
+	return GeoLocation{}, nil
+}
+
+// MMDBGeoResolver resolves locations from a local MaxMind DB file such as GeoLite2-City
+// or GeoLite2-Country. Addresses never leave the server.
+type MMDBGeoResolver struct {
+	reader *maxminddb.Reader
+}
+
+// mmdbRecord holds the fields of a GeoIP2/GeoLite2 record used to locate an address
+type mmdbRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// NewMMDBGeoResolver opens the MaxMind DB file at path, memory-mapped for the lifetime of the process
+func NewMMDBGeoResolver(path string) (*MMDBGeoResolver, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geolocation database %s: %w", path, err)
 	}
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 5 * time.Second,
+	return &MMDBGeoResolver{reader: reader}, nil
+}
+
+// DatabaseType returns the type of the loaded database, e.g. "GeoLite2-City"
+func (r *MMDBGeoResolver) DatabaseType() string {
+	return r.reader.Metadata.DatabaseType
+}
+
+func (r *MMDBGeoResolver) Lookup(ipAddress string) (GeoLocation, error) {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return GeoLocation{}, fmt.Errorf("invalid IP address %q", ipAddress)
+	}
+	// Local addresses are never in the database
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() {
+		return GeoLocation{}, nil
 	}
 
-	// Make request to geolocation API
-	url := fmt.Sprintf("http://ip-api.com/json/%s?fields=status,country,countryCode", ipAddress)
-	resp, err := client.Get(url)
-	if err != nil {
-		return "Unknown", fmt.Errorf("failed to query geolocation API: %w", err)
+	var record mmdbRecord
+	if err := r.reader.Lookup(ip, &record); err != nil {
+		return GeoLocation{}, err
 	}
-	defer resp.Body.Close()
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "Unknown", fmt.Errorf("failed to read geolocation API response: %w", err)
+	var location GeoLocation
+	location.Country = record.Country.ISOCode
+	if location.Country == "" {
+		// Anonymous proxies and satellite providers only have a registered country
+		location.Country = record.RegisteredCountry.ISOCode
+	}
+	if len(record.Subdivisions) > 0 {
+		location.Region = record.Subdivisions[0].Names["en"]
 	}
+	location.City = record.City.Names["en"]
 
-	// Parse JSON response
-	var geoResp GeoLocationResponse
-	if err := json.Unmarshal(body, &geoResp); err != nil {
-		return "Unknown", fmt.Errorf("failed to parse geolocation API response: %w", err)
+	return location, nil
+}
+
+// CachedGeoResolver keeps the most recently resolved addresses in memory
+type CachedGeoResolver struct {
+	resolver GeoResolver
+	cache    *LRUCache[string, GeoLocation]
+}
+
+// NewCachedGeoResolver caches up to size locations resolved by resolver
+func NewCachedGeoResolver(resolver GeoResolver, size int) *CachedGeoResolver {
+	return &CachedGeoResolver{
+		resolver: resolver,
+		cache:    NewLRUCache[string, GeoLocation](size),
 	}
+}
 
-	// Check if the request was successful
-	if geoResp.Status != "success" {
-		return "Unknown", fmt.Errorf("geolocation API returned non-success status")
+func (r *CachedGeoResolver) Lookup(ipAddress string) (GeoLocation, error) {
+	if location, ok := r.cache.Get(ipAddress); ok {
+		return location, nil
 	}
 
-	// Return country code if available, otherwise country name
-	if geoResp.CountryCode != "" {
-		return geoResp.CountryCode, nil
+	location, err := r.resolver.Lookup(ipAddress)
+	if err != nil {
+		return location, err
 	}
 
-	return geoResp.Country, nil
+	r.cache.Add(ipAddress, location)
+	return location, nil
 }
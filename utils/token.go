@@ -7,29 +7,20 @@ import (
 	"github.com/atqamz/kogase-backend/middleware"
 	"github.com/atqamz/kogase-backend/models"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-// CreateToken creates a JWT token for a user
+// CreateToken creates a short-lived JWT access token for a user
 func CreateToken(user models.User) (string, time.Time, error) {
 	// Get JWT expiry from env
-	expiryStr := os.Getenv("JWT_EXPIRES_IN")
-	if expiryStr == "" {
-		expiryStr = "24h" // Default to 24 hours
-	}
-
-	// Parse the duration
-	expiryDuration, err := time.ParseDuration(expiryStr)
-	if err != nil {
-		expiryDuration = 24 * time.Hour // Default to 24 hours
-	}
-
-	expiresAt := time.Now().Add(expiryDuration)
+	expiresAt := time.Now().Add(durationFromEnv("JWT_EXPIRES_IN", 15*time.Minute))
 
 	// Create claims
 	claims := middleware.JWTClaims{
 		UserID: user.ID,
 		Email:  user.Email,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -49,3 +40,31 @@ func CreateToken(user models.User) (string, time.Time, error) {
 
 	return tokenString, expiresAt, nil
 }
+
+// CreateRefreshToken creates an opaque refresh token used to obtain new access tokens
+func CreateRefreshToken() (string, time.Time, error) {
+	// Get refresh token expiry from env
+	expiresAt := time.Now().Add(durationFromEnv("JWT_REFRESH_EXPIRES_IN", 30*24*time.Hour))
+
+	refreshToken, err := models.GenerateSecret("kgr_", 32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return refreshToken, expiresAt, nil
+}
+
+// durationFromEnv parses a duration environment variable, falling back to the default when unset or invalid
+func durationFromEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue
+	}
+
+	return duration
+}